@@ -0,0 +1,57 @@
+package consistent
+
+import (
+	"runtime"
+	"sync"
+	"unsafe"
+)
+
+// WithArena stores points of the default ring, and of snapshots GetNode
+// reads, in memory mapped outside of Go heap. Points hold no pointers, so
+// collector doesn't scan them either way; what arena saves is allocation
+// churn: storage outgrown by the ring is unmapped at once instead of left
+// as garbage, and rings of tens of millions of points don't count toward
+// heap size, which paces collections. Each change still copies points
+// into a new snapshot, whose memory is unmapped once the snapshot is
+// unreachable. Release ring with Close. Clone copies points to heap,
+// platforms without mmap use heap as well.
+func WithArena() Option {
+	return func(c *Consistent) { c.ring = &sliceRing{arena: &arena{}, ids: make(map[string]uint32)} }
+}
+
+// snapshotRing returns copy of r for snapshot. Points of WithArena are
+// copied within arena, and unmapped once snapshot is unreachable.
+func snapshotRing(r ring) ring {
+	s, ok := r.(*sliceRing)
+	if !ok || s.arena == nil {
+		return r.Clone()
+	}
+	a := s.arena
+	n := s.clone(append(a.alloc(len(s.points)), s.points...))
+	runtime.SetFinalizer(n, func(n *sliceRing) { a.free(n.points) })
+	return n
+}
+
+// arena allocates point slices from memory it releases explicitly
+type arena struct {
+	mu     sync.Mutex
+	mapped map[*point]int // first point of mapped spans to their capacity
+}
+
+const pointSize = int(unsafe.Sizeof(point{}))
+
+// Close releases memory of WithArena and removes all nodes like Reset,
+// recording removals for listeners, store, change log and audit.
+// Consistent stays usable, later points are allocated on heap. Other
+// modes have nothing to release, Close is then same as Reset.
+func (c *Consistent) Close() error {
+	c.lock()
+	defer c.unlock()
+	var err error
+	if r, ok := c.ring.(*sliceRing); ok && r.arena != nil {
+		err = r.arena.free(r.points)
+		r.points, r.arena = nil, nil
+	}
+	c.removeAll()
+	return err
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package consistent
+
+// alloc returns heap slice, platforms without mmap get no off heap arena
+func (a *arena) alloc(size int) points {
+	return make(points, 0, size)
+}
+
+func (a *arena) free(p points) error {
+	return nil
+}
@@ -0,0 +1,133 @@
+package consistent
+
+import "fmt"
+import "reflect"
+import "runtime"
+import "testing"
+import "time"
+
+func TestArena(t *testing.T) {
+	c := NewConsistentWithOptions(WithArena())
+	plain := NewConsistent()
+	for i := 0; i < 50; i++ {
+		c.AddNode(fmt.Sprintf("node%d", i))
+		plain.AddNode(fmt.Sprintf("node%d", i))
+	}
+	c.RemoveNodes([]string{"node1", "node7"})
+	plain.RemoveNodes([]string{"node1", "node7"})
+	for i := 0; i < 100; i++ {
+		exp, _ := plain.GetNNode(fmt.Sprint(i), 3)
+		if got, _ := c.GetNNode(fmt.Sprint(i), 3); !reflect.DeepEqual(got, exp) {
+			t.Errorf("arena GetNNode exp: %v, got %v\n", exp, got)
+		}
+	}
+	s := c.Snapshot()
+	exp, _ := s.GetNode("xxx")
+
+	if err := c.Close(); err != nil {
+		t.Errorf("Close err: %v\n", err)
+	}
+	if c.NodeNumber() != 0 {
+		t.Errorf("Close should remove nodes, got %v\n", c.NodeNumber())
+	}
+	if got, _ := s.GetNode("xxx"); got != exp {
+		t.Errorf("snapshot should outlive Close, exp: %v, got %v\n", exp, got)
+	}
+	c.AddNode("node1")
+	if got, err := c.GetNode("xxx"); err != nil || got != "node1" {
+		t.Errorf("consistent should be usable after Close, got %v, %v\n", got, err)
+	}
+	if err := NewConsistent().Close(); err != nil {
+		t.Errorf("Close without arena err: %v\n", err)
+	}
+}
+
+func TestArenaCloseRecords(t *testing.T) {
+	s := &memStore{}
+	c := NewConsistentWithOptions(WithArena(), WithStore(s), WithAudit(10))
+	c.AddNodes([]string{"a", "b"})
+	l := &recordingListener{}
+	c.OnChange(l)
+	epoch := c.Epoch()
+
+	c.Close()
+	if c.Epoch() != epoch+2 {
+		t.Errorf("Close epoch exp: %v, got %v\n", epoch+2, c.Epoch())
+	}
+	if len(s.cfg.Nodes) != 0 {
+		t.Errorf("Close exp: empty ring saved, got %+v\n", s.cfg)
+	}
+	if got, _ := NewConsistentFromStore(s); got.NodeNumber() != 0 {
+		t.Errorf("ring of store after Close exp: empty, got %v\n", got.Members())
+	}
+	if len(l.events) != 2 || l.events[0][0] != '-' || l.events[1][0] != '-' {
+		t.Errorf("Close events exp: two removals, got %v\n", l.events)
+	}
+	h := c.History(0)
+	if last := h[len(h)-1]; last.Op != "remove" || last.After != c.Fingerprint() {
+		t.Errorf("Close history exp: removal to empty ring, got %+v\n", last)
+	}
+	if add := h[len(h)-3]; add.Op != "add" || add.After == c.Fingerprint() {
+		t.Errorf("add before Close exp: fingerprint of a and b, got %+v\n", add)
+	}
+}
+
+func TestArenaFreeHeap(t *testing.T) {
+	a := &arena{}
+	// page sized and likely page aligned, must not be unmapped
+	heap := make(points, 0, 1<<16)
+	heap = append(heap, point{hash: 1})
+	if err := a.free(heap); err != nil || heap[0].hash != 1 {
+		t.Errorf("free of heap slice exp: ignored, got %v\n", err)
+	}
+	p := a.alloc(1 << 16)
+	p = append(p, point{hash: 2})
+	if err := a.free(p); err != nil || len(a.mapped) != 0 {
+		t.Errorf("free of mapped span exp: unmapped, got %v %v\n", err, a.mapped)
+	}
+	if err := a.free(p); err != nil {
+		t.Errorf("second free exp: ignored, got %v\n", err)
+	}
+}
+
+func TestArenaSnapshots(t *testing.T) {
+	c := NewConsistentWithOptions(WithArena(), WithReplicas(1<<16))
+	a := c.ring.(*sliceRing).arena
+	for i := 0; i < 8; i++ {
+		c.AddNode(fmt.Sprintf("node%d", i))
+	}
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	c.AddNode("node8")
+	runtime.ReadMemStats(&after)
+	// over half a million points take 9 MB, new node's hashes 0.5 MB
+	grown := int64(after.HeapAlloc) - int64(before.HeapAlloc)
+	if mapped(a) > 0 && grown > 4<<20 {
+		t.Errorf("published snapshot exp: points off heap, got %v heap bytes\n", grown)
+	}
+	s := c.Snapshot()
+	exp, _ := s.GetNode("key")
+	c.RemoveNode("node0")
+	if got, _ := s.GetNode("key"); got != exp {
+		t.Errorf("snapshot exp: unchanged, got %v, exp: %v\n", got, exp)
+	}
+
+	// unreachable snapshots are unmapped, live ring and last one stay
+	s = nil
+	deadline := time.Now().Add(5 * time.Second)
+	for mapped(a) > 2 && time.Now().Before(deadline) {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+	if n := mapped(a); n > 2 {
+		t.Errorf("mapped spans exp: at most 2, got %v\n", n)
+	}
+	c.Close()
+}
+
+func mapped(a *arena) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.mapped)
+}
@@ -0,0 +1,46 @@
+//go:build unix
+
+package consistent
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// alloc maps anonymous memory for size points
+func (a *arena) alloc(size int) points {
+	if size == 0 {
+		return nil
+	}
+	b, err := syscall.Mmap(-1, 0, size*pointSize, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		// out of address space, fall back to heap
+		return make(points, 0, size)
+	}
+	first := (*point)(unsafe.Pointer(&b[0]))
+	a.mu.Lock()
+	if a.mapped == nil {
+		a.mapped = make(map[*point]int)
+	}
+	a.mapped[first] = size
+	a.mu.Unlock()
+	return unsafe.Slice(first, size)[:0]
+}
+
+// free unmaps memory of p, p must be whole result of alloc. Heap slices
+// of failed mappings are left to garbage collector.
+func (a *arena) free(p points) error {
+	if cap(p) == 0 {
+		return nil
+	}
+	first := &p[:1][0]
+	a.mu.Lock()
+	size, ok := a.mapped[first]
+	delete(a.mapped, first)
+	a.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	b := unsafe.Slice((*byte)(unsafe.Pointer(first)), size*pointSize)
+	return syscall.Munmap(b)
+}
@@ -0,0 +1,125 @@
+package consistent
+
+import (
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AuditEntry is membership change kept by WithAudit
+type AuditEntry struct {
+	Time   time.Time
+	Op     string // "add", "remove" or "weight"
+	Node   string
+	VNodes int    // virtual nodes after change, 0 on remove
+	Caller string // file:line which called consistent
+	Before uint64 // fingerprint of consistent before change
+	After  uint64
+}
+
+// WithAudit keeps last size membership changes for History
+func WithAudit(size int) Option {
+	return func(c *Consistent) { c.audit = &auditLog{size: size} }
+}
+
+// auditLog is ring buffer of entries. Fingerprints are worked out when
+// read, walking back from current one, so changes don't hash whole ring.
+type auditLog struct {
+	size    int
+	entries []auditRecord
+	next    int // oldest entry once full
+}
+
+// auditRecord is entry without fingerprints and what works them out
+type auditRecord struct {
+	AuditEntry
+	prev     int    // virtual nodes before change
+	settings uint64 // settingsTerm at change
+}
+
+func (a *auditLog) add(e auditRecord) {
+	if a.size <= 0 {
+		return
+	}
+	if len(a.entries) < a.size {
+		a.entries = append(a.entries, e)
+		return
+	}
+	a.entries[a.next] = e
+	a.next = (a.next + 1) % a.size
+}
+
+// History returns up to limit latest membership changes, oldest first. Zero
+// or negative limit returns all kept. It's nil without WithAudit.
+func (c *Consistent) History(limit int) []AuditEntry {
+	c.rlock()
+	defer c.runlock()
+	if c.audit == nil {
+		return nil
+	}
+	return c.history(limit)
+}
+
+// history is History of consistent with audit, caller must hold lock
+func (c *Consistent) history(limit int) []AuditEntry {
+	a := c.audit
+	all := append(append([]auditRecord(nil), a.entries[a.next:]...), a.entries[:a.next]...)
+	if limit > 0 && limit < len(all) {
+		all = all[len(all)-limit:]
+	}
+	entries := make([]AuditEntry, len(all))
+	sum, buf := c.nodesTerm(), make([]byte, 0, 64)
+	for i := len(all) - 1; i >= 0; i-- {
+		e := all[i]
+		e.After = mix64(e.settings ^ sum)
+		if e.Op != "remove" {
+			sum -= nodeTerm(buf, e.Node, e.VNodes)
+		}
+		if e.Op != "add" {
+			sum += nodeTerm(buf, e.Node, e.prev)
+		}
+		e.Before = mix64(e.settings ^ sum)
+		entries[i] = e.AuditEntry
+	}
+	return entries
+}
+
+var auditOps = [...]string{nodeAdded: "add", nodeRemoved: "remove", weightChanged: "weight"}
+
+// audited adds change to audit log, caller must hold write lock
+func (c *Consistent) audited(kind changeKind, node string, prev, vnodes int) {
+	c.audit.add(auditRecord{
+		AuditEntry: AuditEntry{
+			Time:   time.Now(),
+			Op:     auditOps[kind],
+			Node:   node,
+			VNodes: vnodes,
+			Caller: caller(),
+		},
+		prev:     prev,
+		settings: c.settingsTerm(),
+	})
+}
+
+// pkgDir is directory of this package's sources
+var pkgDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file)
+}()
+
+// caller returns file:line of first frame outside this package
+func caller() string {
+	pc := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pc[:runtime.Callers(2, pc)])
+	for {
+		f, more := frames.Next()
+		if filepath.Dir(f.File) != pkgDir || strings.HasSuffix(f.File, "_test.go") {
+			return f.File + ":" + strconv.Itoa(f.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
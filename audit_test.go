@@ -0,0 +1,92 @@
+package consistent
+
+import "strings"
+import "testing"
+
+func TestHistory(t *testing.T) {
+	if c := NewConsistent(); c.History(0) != nil {
+		t.Errorf("History without WithAudit exp: nil, got %v\n", c.History(0))
+	}
+
+	c := NewConsistentWithOptions(WithAudit(3))
+	empty := c.fingerprint()
+	c.AddNode("node1")
+	c.AddNode("node2")
+	c.UpdateWeight("node1", 2)
+	c.RemoveNode("node2")
+	h := c.History(0)
+	exp := []struct {
+		op, node string
+		vnodes   int
+	}{
+		{"add", "node2", DefaultReplica},
+		{"weight", "node1", 2 * DefaultReplica},
+		{"remove", "node2", 0},
+	}
+	if len(h) != len(exp) {
+		t.Fatalf("History exp: %v entries, got %+v\n", len(exp), h)
+	}
+	for i, e := range exp {
+		if h[i].Op != e.op || h[i].Node != e.node || h[i].VNodes != e.vnodes {
+			t.Errorf("entry %v exp: %v, got %+v\n", i, e, h[i])
+		}
+		if i > 0 && h[i].Before != h[i-1].After {
+			t.Errorf("entry %v before exp: %x, got %x\n", i, h[i-1].After, h[i].Before)
+		}
+		if !strings.HasSuffix(strings.Split(h[i].Caller, ":")[0], "audit_test.go") || h[i].Time.IsZero() {
+			t.Errorf("entry %v caller exp: audit_test.go, got %v at %v\n", i, h[i].Caller, h[i].Time)
+		}
+	}
+	if h[2].After != c.fingerprint() || h[0].Before == empty {
+		t.Errorf("fingerprints got %+v\n", h)
+	}
+	if h := c.History(1); len(h) != 1 || h[0].Op != "remove" {
+		t.Errorf("History(1) exp: latest remove, got %+v\n", h)
+	}
+
+	c.Reset()
+	if h := c.History(1); h[0].Node != "node1" || h[0].After != empty {
+		t.Errorf("Reset exp: removal of node1 to empty ring, got %+v\n", h)
+	}
+}
+
+func TestHistoryFingerprints(t *testing.T) {
+	c := NewConsistentWithOptions(WithAudit(100))
+	// fingerprint after each single change
+	var exp []uint64
+	step := func(f func()) {
+		f()
+		exp = append(exp, c.Fingerprint())
+	}
+	step(func() { c.AddNode("node1") })
+	step(func() { c.AddNodeWithReplicas("node2", 7) })
+	step(func() { c.UpdateWeight("node1", 3) })
+	c.SetHashFunc(XXHash64)
+	step(func() { c.ReplaceNode("node2", "node3") })
+	step(func() { c.RemoveNode("node1") })
+	c.SetHashName("crc64")
+	step(func() { c.AddNode("node4") })
+	before := c.Fingerprint()
+	c.Reset()
+
+	h := c.History(0)
+	if len(h) != len(exp)+3 {
+		t.Fatalf("History exp: %v entries, got %+v\n", len(exp)+3, h)
+	}
+	// replace is two entries
+	after := []uint64{exp[0], exp[1], exp[2], 0, exp[3], exp[4], exp[5]}
+	for i, fp := range after {
+		if fp != 0 && h[i].After != fp {
+			t.Errorf("entry %v after exp: %x, got %x\n", i, fp, h[i].After)
+		}
+	}
+	if h[0].Before != NewConsistent().Fingerprint() || h[3].After != h[4].Before || h[6].Before == exp[4] {
+		t.Errorf("before fingerprints got %+v\n", h)
+	}
+	if h[7].Before != before || h[8].After != c.Fingerprint() {
+		t.Errorf("reset exp: %x to %x, got %+v %+v\n", before, c.Fingerprint(), h[7], h[8])
+	}
+	if h := c.History(2); h[0].Before != before || h[1].After != c.Fingerprint() {
+		t.Errorf("History(2) exp: %x to %x, got %+v\n", before, c.Fingerprint(), h)
+	}
+}
@@ -0,0 +1,108 @@
+//go:build bbolt
+
+package consistent
+
+import (
+	"bytes"
+	"encoding/json"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	boltSettings = []byte("settings")
+	boltNodes    = []byte("nodes")
+)
+
+// BoltStore is Store keeping config in bucket of bbolt database: settings
+// under key "settings" and node per key of nested bucket "nodes", values
+// in JSON. Save writes only changed nodes in one transaction. Build with
+// -tags bbolt to use it.
+type BoltStore struct {
+	db     *bolt.DB
+	bucket []byte
+}
+
+// NewBoltStore returns store of bucket in db, created on first Save.
+// Closing db is up to caller.
+func NewBoltStore(db *bolt.DB, bucket string) *BoltStore {
+	return &BoltStore{db: db, bucket: []byte(bucket)}
+}
+
+// Load implements Store
+func (s *BoltStore) Load() (Config, error) {
+	var cfg Config
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(s.bucket)
+		if b == nil {
+			return nil
+		}
+		if err := json.Unmarshal(b.Get(boltSettings), &cfg); err != nil {
+			return err
+		}
+		nodes := b.Bucket(boltNodes)
+		if nodes == nil {
+			return nil
+		}
+		return nodes.ForEach(func(k, v []byte) error {
+			var n NodeConfig
+			if err := json.Unmarshal(v, &n); err != nil {
+				return err
+			}
+			cfg.Nodes = append(cfg.Nodes, n)
+			return nil
+		})
+	})
+	return cfg, err
+}
+
+// Save implements Store
+func (s *BoltStore) Save(cfg Config) error {
+	settings, err := json.Marshal(Config{Replicas: cfg.Replicas, Hash: cfg.Hash, Seed: cfg.Seed, Epoch: cfg.Epoch})
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(s.bucket)
+		if err != nil {
+			return err
+		}
+		if err := b.Put(boltSettings, settings); err != nil {
+			return err
+		}
+		nodes, err := b.CreateBucketIfNotExists(boltNodes)
+		if err != nil {
+			return err
+		}
+		keep := make(map[string]bool, len(cfg.Nodes))
+		for _, n := range cfg.Nodes {
+			keep[n.Node] = true
+			v, err := json.Marshal(n)
+			if err != nil {
+				return err
+			}
+			if bytes.Equal(nodes.Get([]byte(n.Node)), v) {
+				continue
+			}
+			if err := nodes.Put([]byte(n.Node), v); err != nil {
+				return err
+			}
+		}
+		var gone [][]byte
+		err = nodes.ForEach(func(k, _ []byte) error {
+			if !keep[string(k)] {
+				gone = append(gone, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range gone {
+			if err := nodes.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
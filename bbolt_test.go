@@ -0,0 +1,48 @@
+//go:build bbolt
+
+package consistent
+
+import "fmt"
+import "path/filepath"
+import "reflect"
+import "testing"
+
+import bolt "go.etcd.io/bbolt"
+
+func TestBoltStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ring.db")
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewBoltStore(db, "ring")
+	c, err := NewConsistentFromStore(s, WithReplicas(10))
+	if err != nil || c.NodeNumber() != 0 {
+		t.Fatalf("empty store exp: empty ring, got %v %v\n", c, err)
+	}
+	c.AddNodes([]string{"node1", "node2", "node3"})
+	c.AddNodeInfo(Node{ID: "node4", Meta: map[string]string{ZoneLabel: "z1"}})
+	c.RemoveNode("node2")
+	c.UpdateWeight("node3", 2)
+	db.Close()
+
+	db, err = bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	got, err := NewConsistentFromStore(NewBoltStore(db, "ring"))
+	if err != nil {
+		t.Fatalf("NewConsistentFromStore %v\n", err)
+	}
+	if !reflect.DeepEqual(got.Config(), c.Config()) {
+		t.Errorf("restored config exp: %+v, got %+v\n", c.Config(), got.Config())
+	}
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprint(i)
+		exp, _ := c.GetNode(key)
+		if node, _ := got.GetNode(key); node != exp {
+			t.Fatalf("restored GetNode(%v) exp: %v, got %v\n", key, exp, node)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package consistent
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// binaryVersion is format version written by MarshalBinary
+const binaryVersion = 1
+
+var binaryMagic = [3]byte{'C', 'H', 'R'}
+
+const (
+	binaryPoints = 1 << iota // virtual nodes follow nodes
+	binaryEpoch              // epoch follows seed
+)
+
+const (
+	nodeCapacity = 1 << iota
+	nodeToken
+	nodeAddress
+	nodeMeta
+	nodeExplicit // no data follows
+)
+
+// MarshalBinary encodes Config of consistent with its virtual nodes, so
+// UnmarshalBinary loads large rings without hashing. Points are taken as
+// they are, but later changes place nodes by placement, hash function
+// and seed of the decoding consistent, so it must place them the same
+// way: decoding checks first two virtual nodes of each node and fails
+// with ErrPlacement otherwise. Custom hash function is needed to hash
+// keys too. encoding/gob uses it, name custom hashes with RegisterHash to
+// carry them over.
+func (c *Consistent) MarshalBinary() ([]byte, error) {
+	c.rlock()
+	defer c.runlock()
+	return c.appendBinary(nil, true), nil
+}
+
+// MarshalBinaryCompact is MarshalBinary without virtual nodes, which are
+// rehashed on decoding
+func (c *Consistent) MarshalBinaryCompact() ([]byte, error) {
+	c.rlock()
+	defer c.runlock()
+	return c.appendBinary(nil, false), nil
+}
+
+// appendBinary appends encoded consistent to dst, caller must hold lock
+func (c *Consistent) appendBinary(dst []byte, points bool) []byte {
+	cfg := c.config()
+	e := encoder{buf: dst}
+	e.buf = append(e.buf, binaryMagic[:]...)
+	e.buf = append(e.buf, binaryVersion)
+	var flags byte
+	if points {
+		flags |= binaryPoints
+	}
+	if cfg.Epoch != 0 {
+		flags |= binaryEpoch
+	}
+	e.buf = append(e.buf, flags)
+	e.uvarint(uint64(cfg.Replicas))
+	e.string(cfg.Hash)
+	e.fixed64(cfg.Seed)
+	if cfg.Epoch != 0 {
+		e.uvarint(cfg.Epoch)
+	}
+	e.uvarint(uint64(len(cfg.Nodes)))
+	index := make(map[string]uint64, len(cfg.Nodes))
+	for i, n := range cfg.Nodes {
+		index[n.Node] = uint64(i)
+		e.string(n.Node)
+		e.uvarint(uint64(n.VNodes))
+		var nf byte
+		if n.Capacity > 0 {
+			nf |= nodeCapacity
+		}
+		if n.Token != "" {
+			nf |= nodeToken
+		}
+		if n.Address != "" {
+			nf |= nodeAddress
+		}
+		if n.Meta != nil {
+			nf |= nodeMeta
+		}
+		if n.Explicit {
+			nf |= nodeExplicit
+		}
+		e.buf = append(e.buf, nf)
+		if n.Capacity > 0 {
+			e.fixed64(math.Float64bits(n.Capacity))
+		}
+		if n.Token != "" {
+			e.string(n.Token)
+		}
+		if n.Address != "" {
+			e.string(n.Address)
+		}
+		if n.Meta != nil {
+			keys := make([]string, 0, len(n.Meta))
+			for k := range n.Meta {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			e.uvarint(uint64(len(keys)))
+			for _, k := range keys {
+				e.string(k)
+				e.string(n.Meta[k])
+			}
+		}
+	}
+	if !points {
+		return e.buf
+	}
+	// ascending hashes as deltas, most are shorter than 8 bytes
+	l := c.ring.Len()
+	e.uvarint(uint64(l))
+	var prev uint64
+	for i := 0; i < l; i++ {
+		h := c.ring.Hash(i)
+		e.uvarint(h - prev)
+		e.uvarint(index[c.ring.Owner(i)])
+		prev = h
+	}
+	shadowed := make([]uint64, 0, len(c.shadow))
+	for h := range c.shadow {
+		shadowed = append(shadowed, h)
+	}
+	sort.Slice(shadowed, func(i, j int) bool { return shadowed[i] < shadowed[j] })
+	e.uvarint(uint64(len(shadowed)))
+	for _, h := range shadowed {
+		e.fixed64(h)
+		e.uvarint(uint64(len(c.shadow[h])))
+		for _, node := range c.shadow[h] {
+			e.uvarint(index[node])
+		}
+	}
+	return e.buf
+}
+
+// UnmarshalBinary replaces state of consistent by data of MarshalBinary
+// or MarshalBinaryCompact, like UnmarshalJSON
+func (c *Consistent) UnmarshalBinary(data []byte) error {
+	cfg, placed, err := decodeBinary(data)
+	if err != nil {
+		return err
+	}
+	c.lock()
+	defer c.unlock()
+	c.init()
+	return c.setConfig(cfg, placed)
+}
+
+func decodeBinary(data []byte) (Config, *placedPoints, error) {
+	var cfg Config
+	if len(data) < len(binaryMagic)+2 || string(data[:len(binaryMagic)]) != string(binaryMagic[:]) {
+		return cfg, nil, fmt.Errorf("%w: bad magic", ErrInvalidData)
+	}
+	if v := data[len(binaryMagic)]; v != binaryVersion {
+		return cfg, nil, fmt.Errorf("%w: %d", ErrBadVersion, v)
+	}
+	flags := data[len(binaryMagic)+1]
+	d := decoder{buf: data[len(binaryMagic)+2:]}
+	cfg.Replicas = int(d.uvarint())
+	cfg.Hash = d.string()
+	cfg.Seed = d.fixed64()
+	if flags&binaryEpoch != 0 {
+		cfg.Epoch = d.uvarint()
+	}
+	cfg.Nodes = make([]NodeConfig, d.count())
+	for i := range cfg.Nodes {
+		n := &cfg.Nodes[i]
+		n.Node = d.string()
+		n.VNodes = int(d.uvarint())
+		nf := d.byte()
+		n.Explicit = nf&nodeExplicit != 0
+		if nf&nodeCapacity != 0 {
+			n.Capacity = math.Float64frombits(d.fixed64())
+		}
+		if nf&nodeToken != 0 {
+			n.Token = d.string()
+		}
+		if nf&nodeAddress != 0 {
+			n.Address = d.string()
+		}
+		if nf&nodeMeta != 0 {
+			n.Meta = make(map[string]string)
+			for k := d.count(); k > 0; k-- {
+				key := d.string()
+				n.Meta[key] = d.string()
+			}
+		}
+	}
+	if d.err != nil || flags&binaryPoints == 0 {
+		return cfg, nil, d.done()
+	}
+	placed := &placedPoints{shadow: make(map[uint64][]string)}
+	node := func() string {
+		i := d.uvarint()
+		if i >= uint64(len(cfg.Nodes)) {
+			d.fail("node index out of range")
+			return ""
+		}
+		return cfg.Nodes[i].Node
+	}
+	l := d.count()
+	placed.hashes = make([]uint64, 0, l)
+	placed.owners = make([]string, 0, l)
+	points := map[string]int{}
+	var h uint64
+	for i := 0; i < l && d.err == nil; i++ {
+		delta := d.uvarint()
+		if i > 0 && (delta == 0 || h+delta < h) {
+			d.fail("points out of order")
+		}
+		h += delta
+		owner := node()
+		placed.hashes = append(placed.hashes, h)
+		placed.owners = append(placed.owners, owner)
+		points[owner]++
+	}
+	for i, l := 0, d.count(); i < l && d.err == nil; i++ {
+		h := d.fixed64()
+		for k := d.count(); k > 0 && d.err == nil; k-- {
+			heir := node()
+			placed.shadow[h] = append(placed.shadow[h], heir)
+			points[heir]++
+		}
+	}
+	if err := d.done(); err != nil {
+		return cfg, nil, err
+	}
+	for _, n := range cfg.Nodes {
+		if points[n.Node] != n.VNodes {
+			return cfg, nil, fmt.Errorf("%w: node %q has %d points of %d", ErrInvalidData, n.Node,
+				points[n.Node], n.VNodes)
+		}
+	}
+	return cfg, placed, nil
+}
+
+type encoder struct {
+	buf     []byte
+	scratch [binary.MaxVarintLen64]byte
+}
+
+func (e *encoder) uvarint(v uint64) {
+	e.buf = append(e.buf, e.scratch[:binary.PutUvarint(e.scratch[:], v)]...)
+}
+
+func (e *encoder) fixed64(v uint64) {
+	binary.LittleEndian.PutUint64(e.scratch[:8], v)
+	e.buf = append(e.buf, e.scratch[:8]...)
+}
+
+func (e *encoder) string(s string) {
+	e.uvarint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+// decoder reads encoder output, keeping first error
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) fail(msg string) {
+	if d.err == nil {
+		d.err = fmt.Errorf("%w: %s", ErrInvalidData, msg)
+	}
+	d.buf = nil
+}
+
+func (d *decoder) uvarint() uint64 {
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.fail("truncated varint")
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+// count reads number of following items, each taking at least a byte
+func (d *decoder) count() int {
+	n := d.uvarint()
+	if n > uint64(len(d.buf)) {
+		d.fail("count exceeds data")
+		return 0
+	}
+	return int(n)
+}
+
+func (d *decoder) fixed64() uint64 {
+	if len(d.buf) < 8 {
+		d.fail("truncated fixed64")
+		return 0
+	}
+	v := binary.LittleEndian.Uint64(d.buf)
+	d.buf = d.buf[8:]
+	return v
+}
+
+func (d *decoder) byte() byte {
+	if len(d.buf) < 1 {
+		d.fail("truncated")
+		return 0
+	}
+	b := d.buf[0]
+	d.buf = d.buf[1:]
+	return b
+}
+
+func (d *decoder) string() string {
+	n := d.count()
+	s := string(d.buf[:n])
+	d.buf = d.buf[n:]
+	return s
+}
+
+// done returns first error, or one for trailing data
+func (d *decoder) done() error {
+	if d.err == nil && len(d.buf) > 0 {
+		d.fail("trailing data")
+	}
+	return d.err
+}
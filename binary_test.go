@@ -0,0 +1,155 @@
+package consistent
+
+import "errors"
+import "fmt"
+import "hash/crc32"
+import "reflect"
+import "testing"
+
+func TestBinary(t *testing.T) {
+	c := NewConsistentWithOptions(WithReplicas(20), WithHashName("murmur3"), WithSeed(3))
+	c.AddNodes([]string{"node1", "node2"})
+	c.AddWeightedNode("node3", 2)
+	c.AddNodeWithCapacity("node4", 1.5)
+	c.AddNodeInfo(Node{ID: "node5", Address: "10.0.0.5:11211", Meta: map[string]string{ZoneLabel: "z1", "rack": "r2"}})
+	c.ReplaceNode("node1", "node6")
+
+	full, _ := c.MarshalBinary()
+	compact, _ := c.MarshalBinaryCompact()
+	if len(compact) >= len(full) {
+		t.Errorf("compact exp: shorter than %v bytes, got %v\n", len(full), len(compact))
+	}
+	for _, data := range [][]byte{full, compact} {
+		// default ring loads points at once, persistent ring inserts them
+		for _, got := range []*Consistent{{}, NewConsistentWithOptions(WithPersistentRing())} {
+			if err := got.UnmarshalBinary(data); err != nil {
+				t.Fatalf("UnmarshalBinary %v\n", err)
+			}
+			if !reflect.DeepEqual(got.Config(), c.Config()) || got.Fingerprint() != c.Fingerprint() {
+				t.Errorf("decoded config exp: %+v, got %+v\n", c.Config(), got.Config())
+			}
+			sameLookups(t, c, got)
+		}
+	}
+}
+
+func TestBinaryCollisions(t *testing.T) {
+	// few distinct hashes, so many points collide
+	few := func(key []byte) uint64 { return uint64(crc32.ChecksumIEEE(key)%64) << 58 }
+	c := NewConsistentWithHash(20, few)
+	c.AddNodes([]string{"node1", "node2", "node3"})
+	data, _ := c.MarshalBinary()
+	got := NewConsistentWithHash(20, few)
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary %v\n", err)
+	}
+	if got.Collisions() != c.Collisions() || got.Collisions() == 0 {
+		t.Errorf("collisions exp: %v, got %v\n", c.Collisions(), got.Collisions())
+	}
+	// shadowed points of removed node go to the same heirs
+	c.RemoveNode("node2")
+	got.RemoveNode("node2")
+	sameLookups(t, c, got)
+}
+
+func TestBinaryPlacement(t *testing.T) {
+	c := NewConsistentWithOptions(WithPlacement(DoubleHashPlacement))
+	c.AddNodes([]string{"n1", "n2", "n3"})
+	data, _ := c.MarshalBinary()
+	tests := []struct {
+		name string
+		got  *Consistent
+		err  error
+	}{
+		{"same placement", NewConsistentWithOptions(WithPlacement(DoubleHashPlacement)), nil},
+		{"default placement", NewConsistent(), ErrPlacement},
+		{"other hash", NewConsistentWithOptions(WithPlacement(DoubleHashPlacement), WithHashFunc(XXHash64)), nil},
+		{"other seed", NewConsistentWithOptions(WithPlacement(DoubleHashPlacement), WithSeed(7)), nil},
+	}
+	for _, tt := range tests {
+		tt.got.AddNode("old")
+		err := tt.got.UnmarshalBinary(data)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%v exp: %v, got %v\n", tt.name, tt.err, err)
+		}
+		if err != nil {
+			if members := tt.got.Members(); !reflect.DeepEqual(members, []string{"old"}) {
+				t.Errorf("%v exp: consistent kept, got %v\n", tt.name, members)
+			}
+			continue
+		}
+		// removed node must leave no points behind
+		tt.got.RemoveNode("n1")
+		for i := 0; i < 1000; i++ {
+			if node, _ := tt.got.GetNode(fmt.Sprint(i)); node == "n1" {
+				t.Fatalf("%v: removed n1 still owns key %v\n", tt.name, i)
+			}
+		}
+	}
+
+	// custom hash isn't encoded, decoder's own one must match
+	custom := NewConsistentWithOptions(WithHashFunc(func(b []byte) uint64 { return XXHash64(b) + 1 }))
+	custom.AddNodes([]string{"n1", "n2"})
+	data, _ = custom.MarshalBinary()
+	if err := NewConsistent().UnmarshalBinary(data); !errors.Is(err, ErrPlacement) {
+		t.Errorf("other custom hash exp: %v, got %v\n", ErrPlacement, err)
+	}
+}
+
+func TestBinaryErrors(t *testing.T) {
+	c := NewConsistentWithN(5)
+	c.AddNodes([]string{"node1", "node2"})
+	data, _ := c.MarshalBinary()
+	bad := func(f func(b []byte) []byte) []byte {
+		return f(append([]byte(nil), data...))
+	}
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"magic", bad(func(b []byte) []byte { b[0] = 'X'; return b }), ErrInvalidData},
+		{"version", bad(func(b []byte) []byte { b[3] = 9; return b }), ErrBadVersion},
+		{"truncated", data[:len(data)-3], ErrInvalidData},
+		{"trailing", append(bad(func(b []byte) []byte { return b }), 0), ErrInvalidData},
+		{"empty", nil, ErrInvalidData},
+	}
+	for _, tt := range tests {
+		got := NewConsistent()
+		if err := got.UnmarshalBinary(tt.data); !errors.Is(err, tt.err) {
+			t.Errorf("%v exp: %v, got %v\n", tt.name, tt.err, err)
+		}
+	}
+}
+
+// sameLookups checks a and b place keys the same
+func sameLookups(t *testing.T, a, b *Consistent) {
+	t.Helper()
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprint(i)
+		exp, _ := a.GetNode(key)
+		if node, _ := b.GetNode(key); node != exp {
+			t.Fatalf("GetNode(%v) exp: %v, got %v\n", key, exp, node)
+		}
+	}
+}
+
+func BenchmarkUnmarshalBinary(b *testing.B) {
+	c := NewConsistentWithN(1000)
+	for i := 0; i < 1000; i++ {
+		c.AddNode(fmt.Sprintf("node%d", i))
+	}
+	full, _ := c.MarshalBinary()
+	compact, _ := c.MarshalBinaryCompact()
+	for _, bb := range []struct {
+		name string
+		data []byte
+	}{{"points", full}, {"compact", compact}} {
+		b.Run(bb.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				var got Consistent
+				got.UnmarshalBinary(bb.data)
+			}
+		})
+	}
+}
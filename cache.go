@@ -0,0 +1,119 @@
+package consistent
+
+import (
+	"container/list"
+	"sync"
+)
+
+// WithCache keeps up to size recent GetNode results, dropped whenever
+// consistent changes. It pays off for skewed keys whose lookups cost more
+// than a map access, e.g. with WithKeyNormalizer or many points, see
+// BenchmarkCacheParallel. Results are kept in shards of their own lock,
+// each evicting its least recently used keys, so concurrent lookups
+// rarely wait on each other.
+func WithCache(size int) Option {
+	return func(c *Consistent) { c.cacheSize = size }
+}
+
+// cacheShards is most shards of WithCache cache
+const cacheShards = 64
+
+// shardedCache spreads keys over LRU caches by FNV-1a hash of key
+type shardedCache struct {
+	shards []*lruCache
+}
+
+func newShardedCache(size int) *shardedCache {
+	n := cacheShards
+	if size < n {
+		n = size
+	}
+	s := &shardedCache{shards: make([]*lruCache, n)}
+	for i := range s.shards {
+		// first shards take remainder, total is size
+		s.shards[i] = newLRUCache(size / n)
+		if i < size%n {
+			s.shards[i].size++
+		}
+	}
+	return s
+}
+
+func (s *shardedCache) shard(key []byte) *lruCache {
+	h := uint32(2166136261)
+	for _, b := range key {
+		h = (h ^ uint32(b)) * 16777619
+	}
+	return s.shards[h%uint32(len(s.shards))]
+}
+
+func (s *shardedCache) get(key []byte) (string, bool) {
+	return s.shard(key).get(key)
+}
+
+func (s *shardedCache) add(key []byte, node string) {
+	s.shard(key).add(key, node)
+}
+
+func (s *shardedCache) len() int {
+	n := 0
+	for _, l := range s.shards {
+		n += l.len()
+	}
+	return n
+}
+
+// lruCache is bounded key to node map evicting least recently used keys
+type lruCache struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key  string
+	node string
+}
+
+func newLRUCache(size int) *lruCache {
+	return &lruCache{
+		size:  size,
+		ll:    list.New(),
+		items: make(map[string]*list.Element, size),
+	}
+}
+
+func (l *lruCache) get(key []byte) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e, ok := l.items[string(key)]
+	if !ok {
+		return "", false
+	}
+	l.ll.MoveToFront(e)
+	return e.Value.(*cacheEntry).node, true
+}
+
+func (l *lruCache) add(key []byte, node string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e, ok := l.items[string(key)]; ok {
+		l.ll.MoveToFront(e)
+		e.Value.(*cacheEntry).node = node
+		return
+	}
+	e := l.ll.PushFront(&cacheEntry{key: string(key), node: node})
+	l.items[e.Value.(*cacheEntry).key] = e
+	if l.ll.Len() > l.size {
+		last := l.ll.Back()
+		l.ll.Remove(last)
+		delete(l.items, last.Value.(*cacheEntry).key)
+	}
+}
+
+func (l *lruCache) len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.ll.Len()
+}
@@ -0,0 +1,96 @@
+package consistent
+
+import "fmt"
+import "testing"
+
+func TestLRUCache(t *testing.T) {
+	l := newLRUCache(2)
+	l.add([]byte("a"), "node1")
+	l.add([]byte("b"), "node2")
+	l.get([]byte("a"))
+	l.add([]byte("c"), "node3")
+	if _, ok := l.get([]byte("b")); ok {
+		t.Errorf("least recently used b should be evicted\n")
+	}
+	if node, ok := l.get([]byte("a")); !ok || node != "node1" {
+		t.Errorf("get(a) exp: node1, got %v, %v\n", node, ok)
+	}
+	if l.len() != 2 {
+		t.Errorf("len exp: 2, got %v\n", l.len())
+	}
+}
+
+func TestShardedCache(t *testing.T) {
+	for _, size := range []int{1, 10, cacheShards, 1000} {
+		s := newShardedCache(size)
+		total := 0
+		for _, l := range s.shards {
+			total += l.size
+		}
+		if total != size {
+			t.Errorf("shard sizes of %v exp: sum %v, got %v\n", size, size, total)
+		}
+		for i := 0; i < 2*size; i++ {
+			s.add([]byte(fmt.Sprint(i)), "node1")
+		}
+		if n := s.len(); n > size {
+			t.Errorf("len of %v exp: at most %v, got %v\n", size, size, n)
+		}
+		if node, ok := s.get([]byte(fmt.Sprint(2*size - 1))); !ok || node != "node1" {
+			t.Errorf("last added exp: node1, got %v, %v\n", node, ok)
+		}
+	}
+}
+
+func TestWithCache(t *testing.T) {
+	c := NewConsistentWithOptions(WithCache(10))
+	plain := NewConsistent()
+	for i := 0; i < 5; i++ {
+		c.AddNode(fmt.Sprintf("node%d", i))
+		plain.AddNode(fmt.Sprintf("node%d", i))
+	}
+	check := func() {
+		for i := 0; i < 50; i++ {
+			key := fmt.Sprint(i % 20)
+			exp, _ := plain.GetNode(key)
+			if got, _ := c.GetNode(key); got != exp {
+				t.Errorf("cached GetNode(%v) exp: %v, got %v\n", key, exp, got)
+			}
+		}
+	}
+	check()
+	if n := c.current().cache.len(); n == 0 || n > 10 {
+		t.Errorf("cache len exp: 1 to 10, got %v\n", n)
+	}
+	c.RemoveNode("node2")
+	plain.RemoveNode("node2")
+	check()
+	c.PinKey("1", "node4")
+	plain.PinKey("1", "node4")
+	check()
+}
+
+// BenchmarkCacheParallel looks up skewed keys concurrently, normalized by
+// FoldKey, with and without WithCache
+func BenchmarkCacheParallel(b *testing.B) {
+	keys := make([]string, 1000)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("  User-%d@Example.com ", i%100)
+	}
+	for _, size := range []int{0, 1000} {
+		b.Run(fmt.Sprintf("cache=%d", size), func(b *testing.B) {
+			c := NewConsistentWithOptions(WithCache(size), WithKeyNormalizer(FoldKey))
+			for i := 0; i < 100; i++ {
+				c.AddNode(fmt.Sprintf("node%d", i))
+			}
+			b.ReportAllocs()
+			b.SetParallelism(64)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for i := 0; pb.Next(); i++ {
+					c.GetNode(keys[i%len(keys)])
+				}
+			})
+		})
+	}
+}
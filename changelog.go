@@ -0,0 +1,307 @@
+package consistent
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// logEntry is line of change log, see AppendLog
+type logEntry struct {
+	Epoch    uint64            `json:"epoch"`
+	Time     time.Time         `json:"time"`
+	Op       string            `json:"op"` // "config", "add", "remove", "weight", "info", "pin" or "unpin"
+	Node     string            `json:"node,omitempty"`
+	VNodes   int               `json:"vnodes,omitempty"`
+	Token    string            `json:"token,omitempty"`    // name placed by, see ReplaceNode
+	Address  string            `json:"address,omitempty"`  // of "info" entry
+	Meta     map[string]string `json:"meta,omitempty"`     // of "info" entry
+	Capacity float64           `json:"capacity,omitempty"` // of "info" entry
+	Explicit bool              `json:"explicit,omitempty"` // of "add", "weight" and "info" entries, see NodeConfig
+	Key      string            `json:"key,omitempty"`      // of "pin" and "unpin" entries
+	Config   *Config           `json:"config,omitempty"`   // state of "config" entry
+	Pins     map[string]string `json:"pins,omitempty"`     // of "config" entry
+}
+
+// AppendLog appends changes of consistent to w from now on, one JSON line
+// per change with its epoch and time, for Replay. Log starts with "config"
+// entry holding current state, SetConfig, SetReplicas, SetHashFunc and
+// Replay write such entry instead of their node changes. Node info,
+// capacities and pins are logged as well, they don't count in epoch.
+// Failed appends are logged, see WithLogger. nil w stops appending.
+func (c *Consistent) AppendLog(w io.Writer) error {
+	c.lock()
+	defer c.unlock()
+	c.init()
+	c.wal = nil
+	if w == nil {
+		return nil
+	}
+	if err := writeLogEntry(w, c.configEntry()); err != nil {
+		return err
+	}
+	c.wal = w
+	return nil
+}
+
+// configEntry returns "config" entry of current state, caller must hold
+// lock
+func (c *Consistent) configEntry() logEntry {
+	cfg := c.config()
+	e := logEntry{Epoch: c.epoch, Time: time.Now().UTC(), Op: "config", Config: &cfg}
+	if len(c.pins) > 0 {
+		e.Pins = make(map[string]string, len(c.pins))
+		for k, v := range c.pins {
+			e.Pins[k] = v
+		}
+	}
+	return e
+}
+
+// logStateAfter stops logging node changes until returned func is called,
+// which logs resulting state instead, for changes of settings. Caller
+// must hold write lock.
+func (c *Consistent) logStateAfter() func() {
+	w := c.wal
+	c.wal = nil
+	return func() {
+		c.wal = w
+		if w != nil {
+			c.appendLog(c.configEntry())
+		}
+	}
+}
+
+func writeLogEntry(w io.Writer, e logEntry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// logged appends change to change log, caller must hold write lock
+func (c *Consistent) logged(kind changeKind, node string, vnodes int) {
+	e := logEntry{Epoch: c.epoch, Time: time.Now().UTC(), Op: auditOps[kind], Node: node, VNodes: vnodes}
+	if kind != nodeRemoved {
+		e.Explicit = c.explicit[node]
+	}
+	if kind == nodeAdded {
+		e.Token = c.token[node]
+	}
+	c.appendLog(e)
+}
+
+// changedInfo records change of info or capacity of node for store and
+// change log, caller must hold write lock
+func (c *Consistent) changedInfo(node string) {
+	c.unsaved = true
+	if c.wal == nil {
+		return
+	}
+	info := c.info[node]
+	c.appendLog(logEntry{Epoch: c.epoch, Time: time.Now().UTC(), Op: "info", Node: node,
+		Address: info.Address, Meta: info.Meta, Capacity: c.capacity[node], Explicit: c.explicit[node]})
+}
+
+// loggedPin appends pin of key to change log, empty node unpins it. Caller
+// must hold write lock.
+func (c *Consistent) loggedPin(key, node string) {
+	if c.wal == nil {
+		return
+	}
+	e := logEntry{Epoch: c.epoch, Time: time.Now().UTC(), Op: "pin", Node: node, Key: key}
+	if node == "" {
+		e.Op = "unpin"
+	}
+	c.appendLog(e)
+}
+
+// appendLog writes e to change log, caller must hold write lock
+func (c *Consistent) appendLog(e logEntry) {
+	if err := writeLogEntry(c.wal, e); err != nil && c.logger != nil {
+		c.logger.log(logStore, "appending to change log failed", "epoch", e.Epoch, "error", err)
+	}
+}
+
+// Replay replaces state of consistent by the one at end of change log of
+// AppendLog read from r, at once
+func (c *Consistent) Replay(r io.Reader) error {
+	return c.ReplayTo(r, math.MaxUint64)
+}
+
+// ReplayTo is Replay stopping after change of epoch, giving the ring as
+// it was then. Log must start with "config" entry and have no epoch
+// missing, it's checked before consistent is changed. Incomplete last
+// line, left by crash while appending, is ignored. States of unnamed hash
+// functions, see SetHashFunc, take hash function consistent had before
+// replay.
+func (c *Consistent) ReplayTo(r io.Reader, epoch uint64) error {
+	entries, err := readLog(r, epoch)
+	if err != nil {
+		return err
+	}
+	c.lock()
+	defer c.unlock()
+	c.init()
+	defer c.logStateAfter()()
+	if b, ok := c.ring.(bulkRing); ok && !c.staged {
+		b.Begin()
+		defer b.Commit()
+	}
+	fn, name := c.hashfunc, c.hashName
+	for _, e := range entries {
+		switch e.Op {
+		case "config":
+			if e.Config.Hash == "" {
+				c.hashfunc, c.hashName = fn, name
+			}
+			if err := c.setConfig(*e.Config, nil); err != nil {
+				return err
+			}
+			c.pins = nil
+			for k, v := range e.Pins {
+				if c.pins == nil {
+					c.pins = make(map[string]string, len(e.Pins))
+				}
+				c.pins[k] = v
+			}
+		case "add":
+			if e.Token != "" && e.Token != e.Node {
+				c.token[e.Node] = e.Token
+			}
+			c.setExplicit(e.Node, e.Explicit)
+			c.addNode(e.Node, e.VNodes)
+		case "remove":
+			c.removeNode(e.Node)
+		case "weight":
+			c.resizeNode(e.Node, e.VNodes)
+			c.setExplicit(e.Node, e.Explicit)
+		case "info":
+			if e.Address != "" || e.Meta != nil {
+				c.info[e.Node] = Node{ID: e.Node, Address: e.Address, Meta: e.Meta}
+			} else {
+				delete(c.info, e.Node)
+			}
+			if e.Capacity > 0 {
+				c.capacity[e.Node] = e.Capacity
+			} else {
+				delete(c.capacity, e.Node)
+			}
+			c.setExplicit(e.Node, e.Explicit)
+		case "pin":
+			if c.pins == nil {
+				c.pins = make(map[string]string)
+			}
+			c.pins[e.Key] = e.Node
+		case "unpin":
+			delete(c.pins, e.Key)
+		}
+		c.epoch = e.Epoch
+	}
+	return nil
+}
+
+// readLog returns entries of change log up to epoch, checked to apply
+// cleanly
+func readLog(r io.Reader, epoch uint64) ([]logEntry, error) {
+	var (
+		entries []logEntry
+		nodes   map[string]bool // members after entries
+		prev    uint64
+	)
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		b, err := br.ReadBytes('\n')
+		if err == io.EOF {
+			// incomplete last line
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		fail := func(format string, args ...interface{}) error {
+			return fmt.Errorf("%w: change log line %d: %s", ErrInvalidData, line, fmt.Sprintf(format, args...))
+		}
+		if len(bytes.TrimSpace(b)) == 0 {
+			continue
+		}
+		var e logEntry
+		if err := json.Unmarshal(b, &e); err != nil {
+			return nil, fail("%v", err)
+		}
+		if e.Epoch > epoch {
+			break
+		}
+		if e.Op != "config" {
+			if nodes == nil {
+				return nil, fail("log must start with config entry")
+			}
+			switch e.Op {
+			case "info", "pin", "unpin":
+				// not counted in epoch
+				if e.Epoch != prev {
+					return nil, fail("%s of epoch %d follows %d", e.Op, e.Epoch, prev)
+				}
+			default:
+				if e.Epoch != prev+1 {
+					return nil, fail("epoch %d follows %d", e.Epoch, prev)
+				}
+			}
+		}
+		switch e.Op {
+		case "config":
+			if e.Config == nil {
+				return nil, fail("config entry without config")
+			}
+			if err := e.Config.validate(); err != nil {
+				return nil, fail("%v", err)
+			}
+			if e.Config.Hash != "" {
+				if _, err := lookupHash(e.Config.Hash); err != nil {
+					return nil, fail("%v", err)
+				}
+			}
+			nodes = make(map[string]bool, len(e.Config.Nodes))
+			for _, n := range e.Config.Nodes {
+				nodes[n.Node] = true
+			}
+		case "add":
+			if nodes[e.Node] || e.Node == "" || e.VNodes <= 0 {
+				return nil, fail("invalid add of node %q", e.Node)
+			}
+			nodes[e.Node] = true
+		case "remove":
+			if !nodes[e.Node] {
+				return nil, fail("remove of missing node %q", e.Node)
+			}
+			delete(nodes, e.Node)
+		case "weight":
+			if !nodes[e.Node] || e.VNodes <= 0 {
+				return nil, fail("invalid weight change of node %q", e.Node)
+			}
+		case "info":
+			if !nodes[e.Node] || e.Capacity < 0 {
+				return nil, fail("invalid info of node %q", e.Node)
+			}
+		case "pin":
+			if !nodes[e.Node] {
+				return nil, fail("pin to missing node %q", e.Node)
+			}
+		case "unpin":
+		default:
+			return nil, fail("unknown op %q", e.Op)
+		}
+		prev = e.Epoch
+		entries = append(entries, e)
+	}
+	if entries == nil {
+		return nil, fmt.Errorf("%w: change log has no entries up to epoch %d", ErrInvalidData, epoch)
+	}
+	return entries, nil
+}
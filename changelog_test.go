@@ -0,0 +1,188 @@
+package consistent
+
+import "bytes"
+import "encoding/json"
+import "errors"
+import "fmt"
+import "reflect"
+import "strings"
+import "testing"
+
+func TestAppendLogReplay(t *testing.T) {
+	c := NewConsistentWithOptions(WithReplicas(10))
+	c.AddNodes([]string{"node1", "node2"})
+	var log bytes.Buffer
+	if err := c.AppendLog(&log); err != nil {
+		t.Fatalf("AppendLog %v\n", err)
+	}
+	states := map[uint64]uint64{c.Epoch(): c.Fingerprint()}
+	ops := []func(){
+		func() { c.AddNode("node3") },
+		func() { c.UpdateWeight("node2", 3) },
+		func() { c.ReplaceNode("node1", "node4") },
+		func() { c.RemoveNode("node3") },
+		func() { c.AddNode("node3") },
+		func() {
+			c.SetConfig(Config{Replicas: 5, Hash: "xxhash64", Nodes: []NodeConfig{{Node: "node5", VNodes: 5}}})
+		},
+		func() { c.AddNode("node6") },
+	}
+	for _, op := range ops {
+		op()
+		states[c.Epoch()] = c.Fingerprint()
+	}
+
+	lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+	var ops2 []string
+	for _, l := range lines {
+		var e logEntry
+		if err := json.Unmarshal([]byte(l), &e); err != nil || e.Time.IsZero() {
+			t.Fatalf("log entry %s: %v\n", l, err)
+		}
+		ops2 = append(ops2, e.Op+" "+e.Node)
+	}
+	exp := []string{"config ", "add node3", "weight node2", "remove node1", "add node4",
+		"remove node3", "add node3", "config ", "add node6"}
+	if !reflect.DeepEqual(ops2, exp) {
+		t.Errorf("log ops exp: %q, got %q\n", exp, ops2)
+	}
+
+	got := NewConsistent()
+	if err := got.Replay(bytes.NewReader(log.Bytes())); err != nil {
+		t.Fatalf("Replay %v\n", err)
+	}
+	if !reflect.DeepEqual(got.Config(), c.Config()) || got.Fingerprint() != c.Fingerprint() {
+		t.Errorf("replayed config exp: %+v, got %+v\n", c.Config(), got.Config())
+	}
+	for epoch, fp := range states {
+		if err := got.ReplayTo(bytes.NewReader(log.Bytes()), epoch); err != nil {
+			t.Fatalf("ReplayTo(%v) %v\n", epoch, err)
+		}
+		if got.Epoch() != epoch || got.Fingerprint() != fp {
+			t.Errorf("ReplayTo(%v) exp: fingerprint %x, got %x at %v\n", epoch, fp, got.Fingerprint(), got.Epoch())
+		}
+	}
+
+	// replay into logging ring is logged as its result
+	var relog bytes.Buffer
+	got.AppendLog(&relog)
+	got.Replay(bytes.NewReader(log.Bytes()))
+	if n := strings.Count(relog.String(), "\n"); n != 2 {
+		t.Errorf("replay log exp: 2 config entries, got %v\n", relog.String())
+	}
+	again := NewConsistent()
+	if err := again.Replay(&relog); err != nil || again.Fingerprint() != c.Fingerprint() {
+		t.Errorf("replayed replay log exp: %+v, got %+v %v\n", c.Config(), again.Config(), err)
+	}
+
+	// relog was drained by Replay
+	got.AppendLog(nil)
+	got.AddNode("node7")
+	if n := strings.Count(relog.String(), "\n"); n != 0 {
+		t.Errorf("stopped log exp: nothing appended, got %v\n", relog.String())
+	}
+}
+
+func TestReplayErrors(t *testing.T) {
+	c := NewConsistentWithOptions(WithReplicas(10))
+	c.AddNode("node1")
+	var log bytes.Buffer
+	c.AppendLog(&log)
+	c.AddNode("node2")
+	c.RemoveNode("node1")
+	lines := strings.SplitAfter(log.String(), "\n")
+
+	// incomplete last line is a crash while appending
+	got := NewConsistent()
+	if err := got.Replay(strings.NewReader(log.String() + `{"epoch":4,"op":"ad`)); err != nil {
+		t.Errorf("Replay torn log exp: nil, got %v\n", err)
+	}
+	if members := got.Members(); !reflect.DeepEqual(members, []string{"node2"}) {
+		t.Errorf("Replay torn log members exp: [node2], got %v\n", members)
+	}
+
+	tests := []struct {
+		name string
+		log  string
+		msg  string
+	}{
+		{"no config", lines[1] + lines[2], "line 1: log must start with config entry"},
+		{"gap", lines[0] + lines[2], "line 2: epoch 3 follows 1"},
+		{"remove missing", lines[0] + `{"epoch":2,"op":"remove","node":"node9"}` + "\n", `line 2: remove of missing node "node9"`},
+		{"bad json", lines[0] + "{\n", "line 2: "},
+		{"unknown op", lines[0] + `{"epoch":2,"op":"drop","node":"node1"}` + "\n", `line 2: unknown op "drop"`},
+		{"double add", lines[0] + `{"epoch":2,"op":"add","node":"node1","vnodes":1}` + "\n", `line 2: invalid add of node "node1"`},
+		{"pin missing", lines[0] + `{"epoch":1,"op":"pin","node":"node9","key":"k"}` + "\n", `line 2: pin to missing node "node9"`},
+		{"info epoch", lines[0] + `{"epoch":2,"op":"info","node":"node1"}` + "\n", "line 2: info of epoch 2 follows 1"},
+		{"empty", "", "no entries"},
+	}
+	for _, tt := range tests {
+		got := NewConsistent()
+		got.AddNode("node3")
+		err := got.Replay(strings.NewReader(tt.log))
+		if !errors.Is(err, ErrInvalidData) || !strings.Contains(err.Error(), tt.msg) {
+			t.Errorf("%s Replay exp: %v, got %v\n", tt.name, tt.msg, err)
+		}
+		if !got.HasNode("node3") {
+			t.Errorf("%s error exp: ring kept, got %v\n", tt.name, got.Members())
+		}
+	}
+}
+
+func TestReplayEveryChange(t *testing.T) {
+	hash := func(key []byte) uint64 { return XXHash64(key) }
+	c := NewConsistentWithOptions(WithReplicas(10))
+	c.AddNodes([]string{"node1", "node2"})
+	c.PinKey("cold", "node1")
+	var log bytes.Buffer
+	c.AppendLog(&log)
+
+	var snapshot bytes.Buffer
+	ops := []struct {
+		name string
+		op   func()
+	}{
+		{"AddNodeInfo new", func() {
+			c.AddNodeInfo(Node{ID: "node3", Address: "10.0.0.3", Meta: map[string]string{ZoneLabel: "z1"}})
+		}},
+		{"AddNodeInfo meta", func() { c.AddNodeInfo(Node{ID: "node1", Meta: map[string]string{ZoneLabel: "z2"}}) }},
+		{"PinKey", func() { c.PinKey("hot", "node2") }},
+		{"UnpinKey", func() { c.UnpinKey("cold") }},
+		{"SetHashFunc", func() { c.SetHashFunc(hash) }},
+		{"SetReplicas", func() { c.SetReplicas(20) }},
+		{"AddNodeWithCapacity", func() { c.AddNodeWithCapacity("node4", 2) }},
+		{"SetCapacity", func() { c.SetCapacity("node4", 3) }},
+		{"ReplaceNode", func() { c.ReplaceNode("node4", "node5") }},
+		{"UpdateConfig", func() {
+			cfg := c.Config()
+			cfg.Nodes[0].Meta = map[string]string{ZoneLabel: "z3"}
+			c.UpdateConfig(cfg)
+		}},
+		{"SaveSnapshot", func() { c.SaveSnapshot(&snapshot) }},
+		{"LoadSnapshot", func() {
+			c.PinKey("warm", "node3")
+			c.LoadSnapshot(&snapshot)
+		}},
+	}
+	for _, tt := range ops {
+		tt.op()
+		got := NewConsistentWithOptions(WithHashFunc(hash))
+		if err := got.Replay(bytes.NewReader(log.Bytes())); err != nil {
+			t.Fatalf("%s Replay %v\n", tt.name, err)
+		}
+		if got.Fingerprint() != c.Fingerprint() || !reflect.DeepEqual(got.Config(), c.Config()) {
+			t.Errorf("%s replayed exp: %+v, got %+v\n", tt.name, c.Config(), got.Config())
+		}
+		if !reflect.DeepEqual(got.Pins(), c.Pins()) {
+			t.Errorf("%s replayed pins exp: %v, got %v\n", tt.name, c.Pins(), got.Pins())
+		}
+		for i := 0; i < 100; i++ {
+			key := fmt.Sprint(i)
+			exp, _ := c.GetNode(key)
+			if node, _ := got.GetNode(key); node != exp {
+				t.Errorf("%s replayed GetNode(%v) exp: %v, got %v\n", tt.name, key, exp, node)
+				break
+			}
+		}
+	}
+}
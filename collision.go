@@ -0,0 +1,139 @@
+package consistent
+
+import "sync"
+
+// A virtual node whose hash is already on the ring loses the collision:
+// existing point keeps its owner and loser is kept in shadow. When the
+// owner leaves, first loser takes the point over, so points are never
+// lost or owned by a removed node.
+
+// hashBufPool and seenPool hold scratch space of point changes, rings
+// don't retain slices passed to Insert and Delete
+var (
+	hashBufPool = sync.Pool{New: func() interface{} { return new([]uint64) }}
+	seenPool    = sync.Pool{New: func() interface{} { return make(map[uint64]bool) }}
+)
+
+func getHashBuf() *[]uint64 {
+	bp := hashBufPool.Get().(*[]uint64)
+	*bp = (*bp)[:0]
+	return bp
+}
+
+// insertPoints adds points of node, shadowing colliding ones
+func (c *Consistent) insertPoints(node string, hashes []uint64) {
+	bp := getHashBuf()
+	defer hashBufPool.Put(bp)
+	batch := seenPool.Get().(map[uint64]bool)
+	defer seenPool.Put(batch)
+	fresh := *bp
+	for _, h := range hashes {
+		if _, ok := c.ring.Lookup(h); ok || batch[h] {
+			c.shadow[h] = append(c.shadow[h], node)
+			c.dropped[node]++
+			if c.logger != nil {
+				c.logger.log(logCollision, "virtual node dropped on collision", "node", node, "hash", h)
+			}
+			continue
+		}
+		batch[h] = true
+		fresh = append(fresh, h)
+	}
+	c.ring.Insert(node, fresh)
+	for _, h := range fresh {
+		delete(batch, h)
+	}
+	*bp = fresh
+}
+
+// deletePoints removes points of node, handing them to shadowed losers
+func (c *Consistent) deletePoints(node string, hashes []uint64) {
+	bp := getHashBuf()
+	defer hashBufPool.Put(bp)
+	owned := *bp
+	for _, h := range hashes {
+		if c.unshadow(h, node) {
+			c.dropped[node]--
+			continue
+		}
+		owned = append(owned, h)
+	}
+	c.ring.Delete(owned)
+	for _, h := range owned {
+		if heirs := c.shadow[h]; len(heirs) > 0 {
+			c.unshadow(h, heirs[0])
+			c.dropped[heirs[0]]--
+			c.ring.Insert(heirs[0], []uint64{h})
+		}
+	}
+	if c.dropped[node] == 0 {
+		delete(c.dropped, node)
+	}
+	*bp = owned
+}
+
+// renamePoints moves points of old to new in place
+func (c *Consistent) renamePoints(old, new string, hashes []uint64) {
+	bp := getHashBuf()
+	defer hashBufPool.Put(bp)
+	owned := *bp
+	for _, h := range hashes {
+		if c.unshadow(h, old) {
+			c.shadow[h] = append(c.shadow[h], new)
+			continue
+		}
+		owned = append(owned, h)
+	}
+	c.ring.Delete(owned)
+	c.ring.Insert(new, owned)
+	if n, ok := c.dropped[old]; ok {
+		c.dropped[new] = n
+		delete(c.dropped, old)
+	}
+	*bp = owned
+}
+
+// unshadow removes node from losers of h, reports whether it was one
+func (c *Consistent) unshadow(h uint64, node string) bool {
+	heirs := c.shadow[h]
+	for i, n := range heirs {
+		if n != node {
+			continue
+		}
+		heirs = append(heirs[:i:i], heirs[i+1:]...)
+		if len(heirs) == 0 {
+			delete(c.shadow, h)
+		} else {
+			c.shadow[h] = heirs
+		}
+		return true
+	}
+	return false
+}
+
+// VirtualNodes returns number of points node owns on the ring, which is
+// less than its replicas when some of them lost hash collisions
+func (c *Consistent) VirtualNodes(node string) int {
+	c.rlock()
+	defer c.runlock()
+	return c.node[node] - c.dropped[node]
+}
+
+// TotalVirtualNodes returns number of points on the ring
+func (c *Consistent) TotalVirtualNodes() int {
+	c.rlock()
+	defer c.runlock()
+	return c.ring.Len()
+}
+
+// Collisions returns number of virtual nodes not on the ring because
+// their hash is taken by another point
+func (c *Consistent) Collisions() int {
+	c.rlock()
+	defer c.runlock()
+	n := 0
+	for _, heirs := range c.shadow {
+		n += len(heirs)
+	}
+	return n
+}
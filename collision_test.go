@@ -0,0 +1,99 @@
+package consistent
+
+import "testing"
+
+// coarseHash maps everything to 8 points, forcing collisions
+func coarseHash(key []byte) uint64 {
+	return crc64h(key) % 8
+}
+
+func TestCollisions(t *testing.T) {
+	for _, opt := range []Option{WithReplicas(10), WithPersistentRing()} {
+		c := NewConsistentWithOptions(WithReplicas(10), WithHashFunc(coarseHash), opt)
+		c.AddNodes([]string{"node1", "node2"})
+
+		total := c.TotalVirtualNodes()
+		if total > 8 || c.Collisions() != 20-total {
+			t.Errorf("Wrong collisions, points: %v, collisions: %v\n", total, c.Collisions())
+		}
+		if c.VirtualNodes("node1")+c.VirtualNodes("node2") != total {
+			t.Errorf("Virtual nodes don't add up, %v + %v != %v\n",
+				c.VirtualNodes("node1"), c.VirtualNodes("node2"), total)
+		}
+
+		// node2 takes over points node1 won
+		distinct := map[uint64]bool{}
+		for _, h := range c.nodeKeys("node2", 10) {
+			distinct[h] = true
+		}
+		c.RemoveNode("node1")
+		if c.VirtualNodes("node2") != len(distinct) || c.TotalVirtualNodes() != len(distinct) {
+			t.Errorf("Wrong virtual nodes, exp: %v, got %v\n", len(distinct), c.VirtualNodes("node2"))
+		}
+		for i := 0; i < c.ring.Len(); i++ {
+			if c.ring.Owner(i) != "node2" {
+				t.Errorf("Point %v owned by %v\n", i, c.ring.Owner(i))
+			}
+		}
+
+		c.RemoveNode("node2")
+		if c.TotalVirtualNodes() != 0 || c.Collisions() != 0 || len(c.dropped) != 0 {
+			t.Errorf("Points left, %v, collisions: %v\n", c.TotalVirtualNodes(), c.Collisions())
+		}
+	}
+}
+
+func TestVirtualNodes(t *testing.T) {
+	c := NewConsistent()
+	c.AddNode("node1")
+	c.AddWeightedNode("node2", 2)
+	if c.VirtualNodes("node1") != DefaultReplica || c.VirtualNodes("node2") != 2*DefaultReplica {
+		t.Errorf("Wrong VirtualNodes(), got %v, %v\n", c.VirtualNodes("node1"), c.VirtualNodes("node2"))
+	}
+	if c.TotalVirtualNodes() != 3*DefaultReplica || c.Collisions() != 0 {
+		t.Errorf("Wrong TotalVirtualNodes(), got %v\n", c.TotalVirtualNodes())
+	}
+	if c.VirtualNodes("node3") != 0 {
+		t.Errorf("Unknown node should have no virtual nodes\n")
+	}
+}
+
+func BenchmarkAddRemoveAllocs(b *testing.B) {
+	b.ReportAllocs()
+	c := NewConsistentWithN(1000)
+	c.AddNodes([]string{"node1", "node2", "node3"})
+	node := "node-with-a-longer-name.example.com:8080"
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.AddNode(node)
+		c.RemoveNode(node)
+	}
+}
+
+func TestShadowedNodes(t *testing.T) {
+	for _, opt := range []Option{WithReplicas(10), WithPersistentRing()} {
+		c := NewConsistentWithOptions(WithHashFunc(func([]byte) uint64 { return 1 }), opt)
+		c.AddNodes([]string{"a", "b", "c"})
+		// one point, owned by one of three members
+		if nodes, err := c.GetNNode("k", 1); err != nil || len(nodes) != 1 {
+			t.Errorf("GetNNode 1 exp: one node, got %v %v\n", nodes, err)
+		}
+		dst := []string{"x"}
+		nodes, err := c.GetNNodeAppend(dst, "k", 3)
+		if err != ErrNotEnoughNodes || len(nodes) != 1 {
+			t.Errorf("GetNNodeAppend exp: %v and dst as is, got %v %v\n", ErrNotEnoughNodes, nodes, err)
+		}
+		_, _, errReplicas := c.GetPrimaryAndReplicas("k", 2)
+		_, errHash := c.GetNNodeByHash(1, 3)
+		_, errExcluding := c.GetNNodeExcluding("k", 2, nil)
+		_, errSnapshot := c.Snapshot().GetNNode("k", 3)
+		for _, err := range []error{errReplicas, errHash, errExcluding, errSnapshot} {
+			if err != ErrNotEnoughNodes {
+				t.Errorf("shadowed members exp: %v, got %v\n", ErrNotEnoughNodes, err)
+			}
+		}
+		if nodes, err := c.GetUpToNNode("k", 3); err != nil || len(nodes) != 1 {
+			t.Errorf("GetUpToNNode exp: one node, got %v %v\n", nodes, err)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package consistent
+
+import "encoding/binary"
+
+// CompositeKey encodes parts into single key, every part prefixed with
+// its uvarint length, so ("user", "123") and ("use", "r123") differ
+func CompositeKey(parts ...[]byte) []byte {
+	size := 0
+	for _, p := range parts {
+		size += binary.MaxVarintLen64 + len(p)
+	}
+	b := make([]byte, 0, size)
+	var buf [binary.MaxVarintLen64]byte
+	for _, p := range parts {
+		n := binary.PutUvarint(buf[:], uint64(len(p)))
+		b = append(append(b, buf[:n]...), p...)
+	}
+	return b
+}
+
+// GetNodeComposite returns first found node of multi-part key
+func (c *Consistent) GetNodeComposite(parts ...[]byte) (string, error) {
+	return c.GetNodeBytes(CompositeKey(parts...))
+}
+
+// GetNNodeComposite returns found distinct nodes of multi-part key with
+// given n
+func (c *Consistent) GetNNodeComposite(n int, parts ...[]byte) ([]string, error) {
+	return c.GetNNodeBytes(CompositeKey(parts...), n)
+}
@@ -0,0 +1,43 @@
+package consistent
+
+import "bytes"
+import "fmt"
+import "testing"
+
+func TestCompositeKey(t *testing.T) {
+	cases := []struct {
+		a, b [][]byte
+	}{
+		{[][]byte{[]byte("user"), []byte("123")}, [][]byte{[]byte("use"), []byte("r123")}},
+		{[][]byte{[]byte("ab"), []byte("")}, [][]byte{[]byte(""), []byte("ab")}},
+		{[][]byte{[]byte("a")}, [][]byte{[]byte("a"), []byte("")}},
+	}
+	for _, cs := range cases {
+		if bytes.Equal(CompositeKey(cs.a...), CompositeKey(cs.b...)) {
+			t.Errorf("CompositeKey(%q) and CompositeKey(%q) should differ\n", cs.a, cs.b)
+		}
+	}
+	exp := []byte{4, 'u', 's', 'e', 'r', 3, '1', '2', '3'}
+	if got := CompositeKey([]byte("user"), []byte("123")); !bytes.Equal(got, exp) {
+		t.Errorf("CompositeKey exp: %v, got %v\n", exp, got)
+	}
+}
+
+func TestGetNodeComposite(t *testing.T) {
+	c := NewConsistent()
+	for i := 0; i < 5; i++ {
+		c.AddNode(fmt.Sprintf("node%d", i))
+	}
+	for i := 0; i < 100; i++ {
+		parts := [][]byte{[]byte("user"), []byte(fmt.Sprint(i))}
+		exp, _ := c.GetNodeBytes(CompositeKey(parts...))
+		got, err := c.GetNodeComposite(parts...)
+		if err != nil || got != exp {
+			t.Errorf("GetNodeComposite exp: %v, got %v, %v\n", exp, got, err)
+		}
+		nodes, err := c.GetNNodeComposite(3, parts...)
+		if err != nil || len(nodes) != 3 || nodes[0] != exp {
+			t.Errorf("GetNNodeComposite exp first: %v, got %v, %v\n", exp, nodes, err)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package consistent
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Config is serializable state of consistent: settings and nodes with
+// their exact virtual node numbers. Placement functions, key normalizer
+// and other options aren't included, they stay as configured.
+type Config struct {
+	Replicas int          `json:"replicas"`
+	Hash     string       `json:"hash,omitempty"` // built-in hash name, empty keeps hash function
+	Seed     uint64       `json:"seed,omitempty"`
+	Epoch    uint64       `json:"epoch,omitempty"` // see Epoch, 0 keeps counting
+	Nodes    []NodeConfig `json:"nodes"`
+}
+
+// NodeConfig is node of Config
+type NodeConfig struct {
+	Node     string            `json:"node"`
+	VNodes   int               `json:"vnodes"`
+	Capacity float64           `json:"capacity,omitempty"` // see AddNodeWithCapacity
+	Token    string            `json:"token,omitempty"`    // name placed by, see ReplaceNode
+	Address  string            `json:"address,omitempty"`
+	Meta     map[string]string `json:"meta,omitempty"`
+	Explicit bool              `json:"explicit,omitempty"` // VNodes kept by SetReplicas, see AddNodeWithReplicas
+}
+
+var (
+	registeredMu sync.RWMutex
+	registered   = map[string]HashFunc{}
+)
+
+// RegisterHash names custom hash function, so rings using it through
+// WithHashName keep the name when serialized and decode in processes
+// registering the same. It's meant for init functions, and fails on nil
+// function or taken name.
+func RegisterHash(name string, fn HashFunc) error {
+	if fn == nil {
+		return ErrNilHashFunc
+	}
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+	if _, err := hashByName(name); err == nil {
+		return fmt.Errorf("consistent: hash %q already registered", name)
+	}
+	registered[name] = fn
+	return nil
+}
+
+// WithHashName sets built-in or registered hash by name, e.g. "xxhash64"
+// or WyHashName(seed). It panics on unknown name.
+func WithHashName(name string) Option {
+	fn, err := lookupHash(name)
+	if err != nil {
+		panic(err)
+	}
+	return func(c *Consistent) {
+		c.setHashFunc(fn)
+		c.hashName = name
+	}
+}
+
+// SetHashName is SetHashFunc of built-in or registered hash by name, which
+// Config carries, so stores and change logs restore it
+func (c *Consistent) SetHashName(name string) error {
+	fn, err := lookupHash(name)
+	if err != nil {
+		return err
+	}
+	c.lock()
+	defer c.unlock()
+	c.rehash(fn, name)
+	return nil
+}
+
+// lookupHash is hashByName taking registry lock
+func lookupHash(name string) (HashFunc, error) {
+	registeredMu.RLock()
+	defer registeredMu.RUnlock()
+	return hashByName(name)
+}
+
+// hashByName returns built-in or registered hash function of name, caller
+// must hold registeredMu
+func hashByName(name string) (HashFunc, error) {
+	if fn, ok := hashFuncs[name]; ok {
+		return fn, nil
+	}
+	if fn, ok := registered[name]; ok {
+		return fn, nil
+	}
+	if fn, ok := wyhashByName(name); ok {
+		return fn, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownHash, name)
+}
+
+// Config returns current state of consistent, nodes in name order
+func (c *Consistent) Config() Config {
+	c.rlock()
+	defer c.runlock()
+	return c.config()
+}
+
+// config is Config, caller must hold lock
+func (c *Consistent) config() Config {
+	cfg := Config{
+		Replicas: c.replicas,
+		Hash:     c.hashName,
+		Seed:     c.seed,
+		Epoch:    c.epoch,
+		Nodes:    make([]NodeConfig, 0, len(c.node)),
+	}
+	for node, vnodes := range c.node {
+		info := c.info[node].copy()
+		cfg.Nodes = append(cfg.Nodes, NodeConfig{
+			Node:     node,
+			VNodes:   vnodes,
+			Capacity: c.capacity[node],
+			Token:    c.token[node],
+			Address:  info.Address,
+			Meta:     info.Meta,
+			Explicit: c.explicit[node],
+		})
+	}
+	sort.Slice(cfg.Nodes, func(i, j int) bool { return cfg.Nodes[i].Node < cfg.Nodes[j].Node })
+	return cfg
+}
+
+// NewConsistentFromConfig returns consistent of cfg, options apply first
+func NewConsistentFromConfig(cfg Config, opts ...Option) (*Consistent, error) {
+	c := NewConsistentWithOptions(opts...)
+	if err := c.SetConfig(cfg); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// SetConfig replaces state of consistent by cfg at once. Nodes are placed
+// in name order, so collisions resolve the same on every process.
+func (c *Consistent) SetConfig(cfg Config) error {
+	c.lock()
+	defer c.unlock()
+	return c.setConfig(cfg, nil)
+}
+
+// placedPoints are virtual nodes of decoded ring, placed without hashing
+type placedPoints struct {
+	hashes []uint64            // ascending
+	owners []string            // of hashes
+	shadow map[uint64][]string // see Consistent.shadow
+}
+
+// owns reports whether node has point at h, on ring or shadowed
+func (p *placedPoints) owns(node string, h uint64) bool {
+	i := sort.Search(len(p.hashes), func(i int) bool { return p.hashes[i] >= h })
+	if i < len(p.hashes) && p.hashes[i] == h && p.owners[i] == node {
+		return true
+	}
+	for _, heir := range p.shadow[h] {
+		if heir == node {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPlaced checks placement of consistent with hash fn puts first two
+// virtual nodes of each node of cfg where placed has them, so later
+// resizes and removals find their points. Cheap sample, not a proof.
+func (c *Consistent) checkPlaced(cfg Config, fn HashFunc, placed *placedPoints) error {
+	for _, n := range cfg.Nodes {
+		name, k := n.Node, n.VNodes
+		if n.Token != "" {
+			name = n.Token
+		}
+		if k > 2 {
+			k = 2
+		}
+		for _, h := range c.placement([]byte(name), k, fn) {
+			if cfg.Seed != 0 {
+				h = mix64(h ^ cfg.Seed)
+			}
+			if !placed.owns(n.Node, h) {
+				return fmt.Errorf("%w: node %q", ErrPlacement, n.Node)
+			}
+		}
+	}
+	return nil
+}
+
+// setConfig is SetConfig, caller must hold write lock. Nodes are put on
+// placed points if not nil, which must match placement of consistent.
+// Invalid cfg leaves consistent as is.
+func (c *Consistent) setConfig(cfg Config, placed *placedPoints) error {
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	fn := c.hashfunc
+	if cfg.Hash != "" {
+		var err error
+		if fn, err = lookupHash(cfg.Hash); err != nil {
+			return err
+		}
+	}
+	if placed != nil {
+		if err := c.checkPlaced(cfg, fn, placed); err != nil {
+			return err
+		}
+	}
+	nodes := append([]NodeConfig(nil), cfg.Nodes...)
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Node < nodes[j].Node })
+	// settings change too, log resulting state instead of node changes
+	defer c.logStateAfter()()
+
+	old, vnodes := make([]string, 0, len(c.node)), c.node
+	for node := range c.node {
+		old = append(old, node)
+	}
+	sort.Strings(old)
+	c.reset()
+	var byNode map[string][]uint64
+	if l, ok := c.ring.(loadRing); ok && placed != nil {
+		l.Load(placed.hashes, placed.owners)
+	} else if placed != nil {
+		byNode = make(map[string][]uint64, len(nodes))
+		for i, h := range placed.hashes {
+			byNode[placed.owners[i]] = append(byNode[placed.owners[i]], h)
+		}
+	} else if b, ok := c.ring.(bulkRing); ok && !c.staged {
+		// after reset, which leaves bulk mode
+		b.Begin()
+		defer b.Commit()
+	}
+	for _, node := range old {
+		c.record(nodeRemoved, node, vnodes[node], 0)
+	}
+	c.replicas = cfg.Replicas
+	if cfg.Hash != "" {
+		c.hashfunc, c.hashName = fn, cfg.Hash
+	}
+	c.seed = cfg.Seed
+	for _, n := range nodes {
+		if n.Token != "" && n.Token != n.Node {
+			c.token[n.Node] = n.Token
+		}
+		if n.Capacity > 0 {
+			c.capacity[n.Node] = n.Capacity
+		}
+		if n.Address != "" || n.Meta != nil {
+			c.info[n.Node] = Node{ID: n.Node, Address: n.Address, Meta: n.Meta}.copy()
+		}
+		c.setExplicit(n.Node, n.Explicit)
+		if placed == nil {
+			c.addNode(n.Node, n.VNodes)
+			continue
+		}
+		if byNode != nil {
+			c.ring.Insert(n.Node, byNode[n.Node])
+		}
+		c.added(n.Node, n.VNodes)
+	}
+	if cfg.Epoch != 0 {
+		c.epoch = cfg.Epoch
+	}
+	if placed != nil {
+		for h, heirs := range placed.shadow {
+			c.shadow[h] = heirs
+			for _, node := range heirs {
+				c.dropped[node]++
+			}
+		}
+	}
+	return nil
+}
+
+// validate reports first invalid entry of cfg
+func (cfg Config) validate() error {
+	if cfg.Replicas <= 0 {
+		return ErrInvalidReplicas
+	}
+	seen := make(map[string]bool, len(cfg.Nodes))
+	for i, n := range cfg.Nodes {
+		switch {
+		case n.Node == "":
+			return fmt.Errorf("%w: node %d has no name", ErrInvalidConfig, i)
+		case seen[n.Node]:
+			return fmt.Errorf("%w: node %q: %v", ErrInvalidConfig, n.Node, ErrNodeExists)
+		case n.VNodes <= 0:
+			return fmt.Errorf("%w: node %q: vnodes must be positive", ErrInvalidConfig, n.Node)
+		case n.Capacity < 0:
+			return fmt.Errorf("%w: node %q: %v", ErrInvalidConfig, n.Node, ErrInvalidCapacity)
+		}
+		seen[n.Node] = true
+	}
+	return nil
+}
@@ -0,0 +1,661 @@
+package consistent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LoadConfig returns consistent of topology file at path, options apply
+// first. File is written in restricted subset of YAML (.yaml, .yml) or
+// TOML (.toml) described below, read without a YAML or TOML library.
+// Anything outside the subset is rejected rather than read differently
+// than YAML or TOML would. Errors wrap ErrInvalidConfig and point at file
+// line of offending entry.
+//
+//	replicas: 100
+//	hash: xxhash64
+//	nodes:
+//	  - name: cache-1
+//	    weight: 2
+//	    zone: us-east-1a
+//	  - name: cache-2
+//	    vnodes: 150
+//	    address: 10.0.0.2:11211
+//	    meta:
+//	      rack: r2
+//
+// Node gets weight*replicas virtual nodes, weight 1 by default, or exact
+// vnodes, kept as Explicit. Zone is stored as ZoneLabel of node meta. TOML holds the same
+// keys, nodes as [[nodes]] tables and meta as [nodes.meta].
+//
+// YAML subset has block mappings, block sequences of mappings, one line
+// plain, single-quoted and double-quoted scalars and # comments, file may
+// start with ---. Flow collections, block and multi-line scalars,
+// anchors, aliases, tags and further documents aren't supported. Plain
+// null and bool words, like null, yes or off, must be quoted as strings.
+//
+// TOML subset has key = value pairs of bare keys, one line basic and
+// literal strings, integers, [tables], [[arrays of tables]] and #
+// comments. Inline tables, arrays, dotted and quoted keys and multi-line
+// strings aren't supported.
+//
+// Double-quoted strings of both take escapes \b \t \n \f \r \" \\ \uXXXX
+// and \UXXXXXXXX. Integers are decimal or 0x hexadecimal, unquoted.
+func LoadConfig(path string, opts ...Option) (*Consistent, error) {
+	cfg, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewConsistentFromConfig(cfg, opts...)
+}
+
+// readConfigFile parses topology file at path into Config
+func readConfigFile(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	return parseConfigFile(path, data)
+}
+
+// parseConfigFile parses data of topology file by extension of name
+func parseConfigFile(name string, data []byte) (Config, error) {
+	var (
+		root *confTable
+		err  error
+	)
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml":
+		root, err = parseYAML(data)
+	case ".toml":
+		root, err = parseTOML(data)
+	default:
+		return Config{}, fmt.Errorf("%w: %s: unknown format, expected .yaml, .yml or .toml", ErrInvalidConfig, name)
+	}
+	if err != nil {
+		return Config{}, fileError(name, err)
+	}
+	cfg, err := root.config()
+	if err != nil {
+		return Config{}, fileError(name, err)
+	}
+	return cfg, nil
+}
+
+// confTable is mapping of config file, holding scalars, nested tables
+// and arrays of tables with their lines
+type confTable struct {
+	line   int
+	values map[string]confValue
+	tables map[string]*confTable
+	arrays map[string][]*confTable
+}
+
+// confValue is scalar of config file, unquoted
+type confValue struct {
+	s      string
+	line   int
+	quoted bool // string, never integer
+}
+
+// confError is error at line of config file
+type confError struct {
+	line int
+	msg  string
+}
+
+func (e *confError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.line, e.msg)
+}
+
+func confErrorf(line int, format string, args ...interface{}) error {
+	return &confError{line: line, msg: fmt.Sprintf(format, args...)}
+}
+
+func fileError(name string, err error) error {
+	if e, ok := err.(*confError); ok {
+		return fmt.Errorf("%w: %s:%d: %s", ErrInvalidConfig, name, e.line, e.msg)
+	}
+	return fmt.Errorf("%w: %s: %v", ErrInvalidConfig, name, err)
+}
+
+func newConfTable(line int) *confTable {
+	return &confTable{
+		line:   line,
+		values: make(map[string]confValue),
+		tables: make(map[string]*confTable),
+		arrays: make(map[string][]*confTable),
+	}
+}
+
+// has reports whether key is taken by any kind of entry
+func (t *confTable) has(key string) bool {
+	_, v := t.values[key]
+	_, tb := t.tables[key]
+	_, a := t.arrays[key]
+	return v || tb || a
+}
+
+// check reports first key of t outside allowed, in line order
+func (t *confTable) check(allowed ...string) error {
+	type entry struct {
+		key  string
+		line int
+	}
+	var unknown []entry
+	ok := func(key string) bool {
+		for _, a := range allowed {
+			if key == a {
+				return true
+			}
+		}
+		return false
+	}
+	for k, v := range t.values {
+		if !ok(k) {
+			unknown = append(unknown, entry{k, v.line})
+		}
+	}
+	for k, v := range t.tables {
+		if !ok(k) {
+			unknown = append(unknown, entry{k, v.line})
+		}
+	}
+	for k, v := range t.arrays {
+		if !ok(k) {
+			unknown = append(unknown, entry{k, v[0].line})
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Slice(unknown, func(i, j int) bool { return unknown[i].line < unknown[j].line })
+	return confErrorf(unknown[0].line, "unknown key %q", unknown[0].key)
+}
+
+// int returns positive integer of key, or def if missing
+func (t *confTable) int(key string, def int) (int, error) {
+	v, ok := t.values[key]
+	if !ok {
+		return def, nil
+	}
+	n, err := parseConfInt(v)
+	if err != nil || n == 0 || n > uint64(maxInt) {
+		return 0, confErrorf(v.line, "%s must be positive integer, got %q", key, v.s)
+	}
+	return int(n), nil
+}
+
+const maxInt = int(^uint(0) >> 1)
+
+// parseConfInt parses unquoted decimal or 0x hexadecimal integer, forms
+// YAML and TOML read alike
+func parseConfInt(v confValue) (uint64, error) {
+	s := v.s
+	switch {
+	case v.quoted:
+		return 0, fmt.Errorf("quoted integer %q", s)
+	case strings.HasPrefix(s, "0x"):
+		return strconv.ParseUint(s[2:], 16, 64)
+	case len(s) > 1 && s[0] == '0', strings.HasPrefix(s, "+"):
+		// octal of YAML 1.1, invalid TOML
+		return 0, fmt.Errorf("invalid integer %q", s)
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+// config builds Config of root table, checking entries
+func (t *confTable) config() (Config, error) {
+	var cfg Config
+	if err := t.check("replicas", "hash", "seed", "nodes"); err != nil {
+		return cfg, err
+	}
+	var err error
+	if cfg.Replicas, err = t.int("replicas", DefaultReplica); err != nil {
+		return cfg, err
+	}
+	if v, ok := t.values["hash"]; ok {
+		if _, err := lookupHash(v.s); err != nil || v.s == "" {
+			return cfg, confErrorf(v.line, "unknown hash %q", v.s)
+		}
+		cfg.Hash = v.s
+	}
+	if v, ok := t.values["seed"]; ok {
+		if cfg.Seed, err = parseConfInt(v); err != nil {
+			return cfg, confErrorf(v.line, "seed must be unsigned integer, got %q", v.s)
+		}
+	}
+	if v, ok := t.values["nodes"]; ok && (v.s != "" || v.quoted) {
+		return cfg, confErrorf(v.line, "nodes must be list of tables")
+	}
+	seen := make(map[string]int)
+	for i, n := range t.arrays["nodes"] {
+		node, err := n.node(i+1, cfg.Replicas)
+		if err != nil {
+			return cfg, err
+		}
+		if line, ok := seen[node.Node]; ok {
+			return cfg, confErrorf(n.line, "node %q already defined at line %d", node.Node, line)
+		}
+		seen[node.Node] = n.line
+		cfg.Nodes = append(cfg.Nodes, node)
+	}
+	return cfg, nil
+}
+
+// node builds NodeConfig of i-th nodes entry
+func (t *confTable) node(i, replicas int) (NodeConfig, error) {
+	var n NodeConfig
+	name, ok := t.values["name"]
+	if !ok || name.s == "" {
+		return n, confErrorf(t.line, "node %d: name is required", i)
+	}
+	n.Node = name.s
+	fail := func(err error) (NodeConfig, error) {
+		e := err.(*confError)
+		return n, confErrorf(e.line, "node %q: %s", n.Node, e.msg)
+	}
+	if err := t.check("name", "weight", "vnodes", "zone", "address", "meta"); err != nil {
+		return fail(err)
+	}
+	if w, ok := t.values["weight"]; ok && t.has("vnodes") {
+		return fail(confErrorf(w.line, "weight and vnodes are exclusive"))
+	}
+	weight, err := t.int("weight", 1)
+	if err == nil {
+		n.VNodes, err = t.int("vnodes", weight*replicas)
+	}
+	if err != nil {
+		return fail(err)
+	}
+	n.Explicit = t.has("vnodes")
+	n.Address = t.values["address"].s
+	if v, ok := t.values["meta"]; ok {
+		return fail(confErrorf(v.line, "meta must be table"))
+	}
+	if items := t.arrays["meta"]; len(items) > 0 {
+		return fail(confErrorf(items[0].line, "meta must be table"))
+	}
+	if meta, ok := t.tables["meta"]; ok {
+		if len(meta.tables) > 0 || len(meta.arrays) > 0 {
+			return fail(confErrorf(meta.line, "meta values must be scalars"))
+		}
+		n.Meta = make(map[string]string, len(meta.values)+1)
+		for k, v := range meta.values {
+			n.Meta[k] = v.s
+		}
+	}
+	if zone, ok := t.values["zone"]; ok {
+		if n.Meta == nil {
+			n.Meta = make(map[string]string, 1)
+		}
+		n.Meta[ZoneLabel] = zone.s
+	}
+	return n, nil
+}
+
+// confLine is non-blank line of config file, comment stripped
+type confLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+// splitConfLines splits data into lines, dropping blanks and # comments
+// outside quotes
+func splitConfLines(data []byte) ([]confLine, error) {
+	var lines []confLine
+	for i, raw := range strings.Split(string(data), "\n") {
+		text := stripComment(strings.TrimRight(raw, " \t\r"))
+		trimmed := strings.TrimLeft(text, " \t")
+		if trimmed == "" {
+			continue
+		}
+		prefix := text[:len(text)-len(trimmed)]
+		if strings.Contains(prefix, "\t") {
+			return nil, confErrorf(i+1, "tab in indentation")
+		}
+		lines = append(lines, confLine{num: i + 1, indent: len(prefix), text: strings.TrimRight(trimmed, " \t")})
+	}
+	return lines, nil
+}
+
+// stripComment cuts # comment off s. Quotes count only at start of key
+// or value, like ' of plain it's.
+func stripComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && valueStart(s[:i]):
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// valueStart reports whether key or value may start after prefix
+func valueStart(prefix string) bool {
+	prev := strings.TrimRight(prefix, " \t")
+	spaced := len(prev) < len(prefix)
+	return prev == "" || prev == "-" && spaced || strings.HasSuffix(prev, "=") ||
+		strings.HasSuffix(prev, ":") && spaced
+}
+
+// unquote returns scalar of quoted or plain s. Single-quoted YAML
+// strings escape ' by doubling it, literal strings of TOML can't hold it.
+func unquote(s string, line int, yaml bool) (confValue, error) {
+	v := confValue{s: s, line: line, quoted: true}
+	switch {
+	case strings.HasPrefix(s, `"""`) || strings.HasPrefix(s, "'''") && !yaml:
+		return v, confErrorf(line, "multi-line strings aren't supported")
+	case len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"':
+		for i := 1; i < len(s)-1; i++ {
+			if s[i] != '\\' {
+				continue
+			}
+			i++
+			if !strings.ContainsRune(`btnfr"\uU`, rune(s[i])) {
+				return v, confErrorf(line, "unsupported escape \\%c in %s", s[i], s)
+			}
+		}
+		var err error
+		if v.s, err = strconv.Unquote(s); err != nil {
+			return v, confErrorf(line, "invalid string %s", s)
+		}
+		return v, nil
+	case len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'':
+		v.s = s[1 : len(s)-1]
+		if !yaml && strings.Contains(v.s, "'") {
+			return v, confErrorf(line, "invalid string %s", s)
+		}
+		if strings.Contains(strings.Replace(v.s, "''", "", -1), "'") {
+			return v, confErrorf(line, "invalid string %s", s)
+		}
+		v.s = strings.Replace(v.s, "''", "'", -1)
+		return v, nil
+	case strings.HasPrefix(s, "\"") || strings.HasPrefix(s, "'"):
+		return v, confErrorf(line, "unterminated string %s", s)
+	}
+	v.quoted = false
+	return v, nil
+}
+
+// yamlPlain checks plain scalar s of YAML holds no syntax outside the
+// subset of LoadConfig
+func yamlPlain(s string, line int) error {
+	switch {
+	case s == "":
+		return nil
+	case strings.ContainsRune("[]{},", rune(s[0])):
+		return confErrorf(line, "flow collections aren't supported")
+	case s[0] == '|' || s[0] == '>':
+		return confErrorf(line, "block scalars aren't supported")
+	case s[0] == '&' || s[0] == '*':
+		return confErrorf(line, "anchors and aliases aren't supported")
+	case s[0] == '!':
+		return confErrorf(line, "tags aren't supported")
+	case strings.ContainsRune("@`%", rune(s[0])) || strings.HasPrefix(s, "- ") || strings.HasPrefix(s, "? "):
+		return confErrorf(line, "unsupported plain scalar %q", s)
+	case strings.Contains(s, ": ") || strings.HasSuffix(s, ":"):
+		return confErrorf(line, "nested mapping must start on next line, got %q", s)
+	}
+	switch strings.ToLower(s) {
+	case "~", "null", "true", "false", "yes", "no", "on", "off", "y", "n":
+		// null or bool to YAML
+		return confErrorf(line, "%s isn't a string to YAML, quote it", s)
+	}
+	return nil
+}
+
+// yamlScalar returns scalar of quoted or plain YAML s
+func yamlScalar(s string, line int) (confValue, error) {
+	v, err := unquote(s, line, true)
+	if err == nil && !v.quoted {
+		err = yamlPlain(s, line)
+	}
+	return v, err
+}
+
+// parseYAML parses YAML subset of LoadConfig, block mappings and
+// sequences of mappings of one line scalars
+func parseYAML(data []byte) (*confTable, error) {
+	lines, err := splitConfLines(data)
+	if err != nil {
+		return nil, err
+	}
+	for i, l := range lines {
+		switch {
+		case l.indent > 0:
+		case i == 0 && l.text == "---":
+			lines = lines[1:]
+		case l.text == "---" || strings.HasPrefix(l.text, "--- ") || l.text == "...":
+			return nil, confErrorf(l.num, "multiple documents aren't supported")
+		case strings.HasPrefix(l.text, "%"):
+			return nil, confErrorf(l.num, "directives aren't supported")
+		}
+	}
+	p := yamlParser{lines: lines}
+	root := newConfTable(1)
+	if len(lines) == 0 {
+		return root, nil
+	}
+	if err := p.mapping(root, lines[0].indent); err != nil {
+		return nil, err
+	}
+	if p.pos < len(lines) {
+		return nil, confErrorf(lines[p.pos].num, "unexpected indentation")
+	}
+	return root, nil
+}
+
+type yamlParser struct {
+	lines []confLine
+	pos   int
+}
+
+// mapping reads keys at indent into t
+func (p *yamlParser) mapping(t *confTable, indent int) error {
+	for p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		if l.indent < indent {
+			return nil
+		}
+		if l.indent > indent {
+			return confErrorf(l.num, "unexpected indentation")
+		}
+		if strings.HasPrefix(l.text, "- ") || l.text == "-" {
+			return confErrorf(l.num, "unexpected list item")
+		}
+		// colon ends key before space or at line end, as in host:port
+		i := strings.Index(l.text+" ", ": ")
+		if i <= 0 {
+			return confErrorf(l.num, "expected key: value, got %q", l.text)
+		}
+		k, err := yamlScalar(strings.TrimSpace(l.text[:i]), l.num)
+		if err != nil {
+			return err
+		}
+		key := k.s
+		if t.has(key) {
+			return confErrorf(l.num, "duplicate key %q", key)
+		}
+		rest := strings.TrimSpace(l.text[i+1:])
+		p.pos++
+		if rest != "" {
+			if t.values[key], err = yamlScalar(rest, l.num); err != nil {
+				return err
+			}
+			continue
+		}
+		if p.pos == len(p.lines) || p.lines[p.pos].indent < indent ||
+			p.lines[p.pos].indent == indent && !isListItem(p.lines[p.pos].text) {
+			t.values[key] = confValue{line: l.num}
+			continue
+		}
+		next := p.lines[p.pos]
+		if isListItem(next.text) {
+			items, err := p.sequence(next.indent)
+			if err != nil {
+				return err
+			}
+			t.arrays[key] = items
+			continue
+		}
+		sub := newConfTable(l.num)
+		if err := p.mapping(sub, next.indent); err != nil {
+			return err
+		}
+		t.tables[key] = sub
+	}
+	return nil
+}
+
+// sequence reads list items of mappings at indent
+func (p *yamlParser) sequence(indent int) ([]*confTable, error) {
+	var items []*confTable
+	for p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		if l.indent < indent || l.indent == indent && !isListItem(l.text) {
+			break
+		}
+		if l.indent > indent {
+			return nil, confErrorf(l.num, "unexpected indentation")
+		}
+		item := newConfTable(l.num)
+		rest := strings.TrimLeft(l.text[1:], " ")
+		if rest == "" {
+			p.pos++
+			if p.pos == len(p.lines) || p.lines[p.pos].indent <= indent {
+				return nil, confErrorf(l.num, "empty list item")
+			}
+		} else {
+			// content after dash starts mapping at its column
+			p.lines[p.pos] = confLine{num: l.num, indent: l.indent + len(l.text) - len(rest), text: rest}
+		}
+		if !strings.Contains(p.lines[p.pos].text, ":") {
+			return nil, confErrorf(p.lines[p.pos].num, "list item must be mapping")
+		}
+		if err := p.mapping(item, p.lines[p.pos].indent); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func isListItem(text string) bool {
+	return strings.HasPrefix(text, "- ") || text == "-"
+}
+
+// parseTOML parses TOML subset of LoadConfig, key/value pairs of strings
+// and integers, [tables] and [[arrays of tables]]
+func parseTOML(data []byte) (*confTable, error) {
+	lines, err := splitConfLines(data)
+	if err != nil {
+		return nil, err
+	}
+	root := newConfTable(1)
+	cur := root
+	for _, l := range lines {
+		if strings.HasPrefix(l.text, "[") {
+			array := strings.HasPrefix(l.text, "[[")
+			name := strings.TrimPrefix(l.text, "[")
+			end := "]"
+			if array {
+				name, end = strings.TrimPrefix(name, "["), "]]"
+			}
+			if !strings.HasSuffix(name, end) {
+				return nil, confErrorf(l.num, "invalid table header %q", l.text)
+			}
+			path := strings.Split(strings.TrimSuffix(name, end), ".")
+			for i, seg := range path {
+				if path[i] = strings.TrimSpace(seg); !bareKey(path[i]) {
+					return nil, confErrorf(l.num, "invalid table header %q", l.text)
+				}
+			}
+			parent := root
+			for _, seg := range path[:len(path)-1] {
+				if items := parent.arrays[seg]; len(items) > 0 {
+					parent = items[len(items)-1]
+				} else if sub, ok := parent.tables[seg]; ok {
+					parent = sub
+				} else if parent.has(seg) {
+					return nil, confErrorf(l.num, "key %q is not table", seg)
+				} else {
+					sub = newConfTable(l.num)
+					parent.tables[seg] = sub
+					parent = sub
+				}
+			}
+			key := path[len(path)-1]
+			cur = newConfTable(l.num)
+			switch {
+			case array && (parent.arrays[key] != nil || !parent.has(key)):
+				parent.arrays[key] = append(parent.arrays[key], cur)
+			case !array && !parent.has(key):
+				parent.tables[key] = cur
+			default:
+				return nil, confErrorf(l.num, "duplicate key %q", key)
+			}
+			continue
+		}
+		i := strings.Index(l.text, "=")
+		if i <= 0 {
+			return nil, confErrorf(l.num, "expected key = value, got %q", l.text)
+		}
+		key := strings.TrimSpace(l.text[:i])
+		if !bareKey(key) {
+			return nil, confErrorf(l.num, "only bare keys are supported, got %q", key)
+		}
+		if cur.has(key) {
+			return nil, confErrorf(l.num, "duplicate key %q", key)
+		}
+		raw := strings.TrimSpace(l.text[i+1:])
+		if strings.HasPrefix(raw, "[") || strings.HasPrefix(raw, "{") {
+			return nil, confErrorf(l.num, "inline arrays and tables aren't supported")
+		}
+		v, err := unquote(raw, l.num, false)
+		if err != nil {
+			return nil, err
+		}
+		if !v.quoted && !tomlInt(raw) {
+			return nil, confErrorf(l.num, "value must be string or integer, got %q", raw)
+		}
+		cur.values[key] = v
+	}
+	return root, nil
+}
+
+// bareKey reports whether s is bare key of TOML
+func bareKey(s string) bool {
+	for _, c := range s {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_' || c == '-') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// tomlInt reports whether s looks like integer of TOML, parseConfInt
+// takes subset of those
+func tomlInt(s string) bool {
+	digits := "0123456789_"
+	if strings.HasPrefix(s, "0x") {
+		s, digits = s[2:], digits+"abcdefABCDEF"
+	} else if s != "" && (s[0] == '-' || s[0] == '+') {
+		s = s[1:]
+	}
+	return s != "" && strings.Trim(s, digits) == ""
+}
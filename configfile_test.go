@@ -0,0 +1,175 @@
+package consistent
+
+import "errors"
+import "os"
+import "path/filepath"
+import "reflect"
+import "strings"
+import "testing"
+
+const testYAML = `# topology
+replicas: 10
+hash: "xxhash64"
+nodes:
+  - name: cache-1
+    weight: 2
+    zone: us-east-1a   # first zone
+  - name: 'cache-2'
+    vnodes: 15
+    address: 10.0.0.2:11211
+    meta:
+      rack: "r#2"
+  -
+    name: cache-3
+`
+
+const testTOML = `# topology
+replicas = 10
+hash = "xxhash64"
+
+[[nodes]]
+name = "cache-1"
+weight = 2
+zone = "us-east-1a" # first zone
+
+[[nodes]]
+name = 'cache-2'
+vnodes = 15
+address = "10.0.0.2:11211"
+[nodes.meta]
+rack = "r#2"
+
+[[nodes]]
+name = "cache-3"
+`
+
+func TestParseConfigFile(t *testing.T) {
+	exp := Config{Replicas: 10, Hash: "xxhash64", Nodes: []NodeConfig{
+		{Node: "cache-1", VNodes: 20, Meta: map[string]string{ZoneLabel: "us-east-1a"}},
+		{Node: "cache-2", VNodes: 15, Explicit: true, Address: "10.0.0.2:11211", Meta: map[string]string{"rack": "r#2"}},
+		{Node: "cache-3", VNodes: 10},
+	}}
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"ring.yaml", testYAML},
+		{"ring.yml", testYAML},
+		{"ring.toml", testTOML},
+		{"ring.yaml", "---\nnodes:\n- name: cache-1\n  weight: 2\n  zone: us-east-1a # it's 1a\n" +
+			"- name: cache-2\n  vnodes: 15\n  address: 10.0.0.2:11211\n  meta:\n    rack: r#2\n" +
+			"- name: cache-3\nhash: xxhash64\nreplicas: 10\n"},
+	}
+	for _, tt := range tests {
+		cfg, err := parseConfigFile(tt.name, []byte(tt.data))
+		if err != nil || !reflect.DeepEqual(cfg, exp) {
+			t.Errorf("parseConfigFile(%v) exp: %+v, got %+v %v\n", tt.name, exp, cfg, err)
+		}
+	}
+
+	cfg, err := parseConfigFile("empty.yaml", []byte("nodes:\n"))
+	if err != nil || cfg.Replicas != DefaultReplica || len(cfg.Nodes) != 0 {
+		t.Errorf("empty config exp: default replicas, got %+v %v\n", cfg, err)
+	}
+
+	// scalars read as YAML and TOML read them
+	cfg, err = parseConfigFile("ring.yaml", []byte("seed: 0x10\nnodes:\n  - name: it's\n    address: 'a''b' # c\n    meta:\n      note: \"\\u00e9\\t#\"\n"))
+	exp = Config{Replicas: DefaultReplica, Seed: 16, Nodes: []NodeConfig{
+		{Node: "it's", VNodes: DefaultReplica, Address: "a'b", Meta: map[string]string{"note": "\u00e9\t#"}},
+	}}
+	if err != nil || !reflect.DeepEqual(cfg, exp) {
+		t.Errorf("yaml scalars exp: %+v, got %+v %v\n", exp, cfg, err)
+	}
+	cfg, err = parseConfigFile("ring.toml", []byte("seed=0x10\n[[nodes]]\nname='it\"s'\naddress=\"a'b\" # c\n"))
+	exp.Nodes = []NodeConfig{{Node: `it"s`, VNodes: DefaultReplica, Address: "a'b"}}
+	if err != nil || !reflect.DeepEqual(cfg, exp) {
+		t.Errorf("toml scalars exp: %+v, got %+v %v\n", exp, cfg, err)
+	}
+}
+
+func TestParseConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		msg  string
+	}{
+		{"ring.json", `{}`, "ring.json: unknown format"},
+		{"ring.yaml", "replicas: 0\n", `ring.yaml:1: replicas must be positive integer, got "0"`},
+		{"ring.yaml", "replica: 10\n", `ring.yaml:1: unknown key "replica"`},
+		{"ring.yaml", "hash: md5\n", `ring.yaml:1: unknown hash "md5"`},
+		{"ring.yaml", "nodes:\n  - name: a\n  - weight: 2\n", `ring.yaml:3: node 2: name is required`},
+		{"ring.yaml", "nodes:\n  - name: a\n    wieght: 2\n", `ring.yaml:3: node "a": unknown key "wieght"`},
+		{"ring.yaml", "nodes:\n  - name: a\n    weight: -1\n", `ring.yaml:3: node "a": weight must be positive integer, got "-1"`},
+		{"ring.yaml", "nodes:\n  - name: a\n    weight: 2\n    vnodes: 9\n", `ring.yaml:3: node "a": weight and vnodes are exclusive`},
+		{"ring.yaml", "nodes:\n  - name: a\n  - name: b\n  - name: a\n", `ring.yaml:4: node "a" already defined at line 2`},
+		{"ring.yaml", "nodes:\n  - name: a\n    meta: r1\n", `ring.yaml:3: node "a": meta must be table`},
+		{"ring.yaml", "nodes: a\n", `ring.yaml:1: nodes must be list of tables`},
+		{"ring.yaml", "replicas: 10\n  hash: crc64\n", `ring.yaml:2: unexpected indentation`},
+		{"ring.yaml", "replicas: 10\nreplicas: 20\n", `ring.yaml:2: duplicate key "replicas"`},
+		{"ring.yaml", "nodes:\n  - a\n", `ring.yaml:2: list item must be mapping`},
+		{"ring.yaml", "hash: \"xxhash64\n", `ring.yaml:1: unterminated string`},
+		{"ring.yaml", "nodes:\n\t- name: a\n", `ring.yaml:2: tab in indentation`},
+		{"ring.yaml", "nodes: []\n", `ring.yaml:1: flow collections aren't supported`},
+		{"ring.yaml", "nodes:\n  - {name: a}\n", `ring.yaml:2: flow collections aren't supported`},
+		{"ring.yaml", "nodes:\n  - name: a\n    meta: {rack: r1}\n", `ring.yaml:3: flow collections aren't supported`},
+		{"ring.yaml", "hash: >\n  crc64\n", `ring.yaml:1: block scalars aren't supported`},
+		{"ring.yaml", "hash: \"crc64\n  \"\n", `ring.yaml:1: unterminated string`},
+		{"ring.yaml", "hash: crc\n  64\n", `ring.yaml:2: unexpected indentation`},
+		{"ring.yaml", "nodes:\n  - name: &n a\n", `ring.yaml:2: anchors and aliases aren't supported`},
+		{"ring.yaml", "hash: !!str crc64\n", `ring.yaml:1: tags aren't supported`},
+		{"ring.yaml", "nodes:\n  - name: null\n", `ring.yaml:2: null isn't a string to YAML, quote it`},
+		{"ring.yaml", "nodes:\n  - name: a\n    meta:\n      on: yes\n", `ring.yaml:4: on isn't a string to YAML`},
+		{"ring.yaml", "nodes:\n  - name: a: b\n", `ring.yaml:2: nested mapping must start on next line`},
+		{"ring.yaml", "replicas: 010\n", `ring.yaml:1: replicas must be positive integer, got "010"`},
+		{"ring.yaml", "replicas: '10'\n", `ring.yaml:1: replicas must be positive integer, got "10"`},
+		{"ring.yaml", "hash: \"\\x41\"\n", `ring.yaml:1: unsupported escape \x`},
+		{"ring.yaml", "hash: 'a'b'\n", `ring.yaml:1: invalid string 'a'b'`},
+		{"ring.yaml", "%YAML 1.2\n---\nreplicas: 10\n", `ring.yaml:1: directives aren't supported`},
+		{"ring.yaml", "replicas: 10\n---\nreplicas: 20\n", `ring.yaml:2: multiple documents aren't supported`},
+		{"ring.toml", "replicas = 10\n[[nodes]]\nname = \"a\"\nweight = -1\n", `ring.toml:4: node "a": weight must be positive integer, got "-1"`},
+		{"ring.toml", "replicas = 10\n[[nodes]]\nname = \"a\"\nweight = \"2\"\n", `ring.toml:4: node "a": weight must be positive integer, got "2"`},
+		{"ring.toml", "hash = xxhash64\n", `ring.toml:1: value must be string or integer, got "xxhash64"`},
+		{"ring.toml", "replicas = 1.5\n", `ring.toml:1: value must be string or integer, got "1.5"`},
+		{"ring.toml", "replicas = 1_0\n", `ring.toml:1: replicas must be positive integer, got "1_0"`},
+		{"ring.toml", "hash = \"\"\"xxhash64\"\"\"\n", `ring.toml:1: multi-line strings aren't supported`},
+		{"ring.toml", "hash = 'xx''hash'\n", `ring.toml:1: invalid string 'xx''hash'`},
+		{"ring.toml", "hash = \"\\x41\"\n", `ring.toml:1: unsupported escape \x`},
+		{"ring.toml", "nodes.name = \"a\"\n", `ring.toml:1: only bare keys are supported, got "nodes.name"`},
+		{"ring.toml", "\"hash\" = \"crc64\"\n", `ring.toml:1: only bare keys are supported`},
+		{"ring.toml", "[[\"nodes\"]]\n", `ring.toml:1: invalid table header`},
+		{"ring.toml", "[[nodes]]\nname = \"a\"\n[nodes.meta]\n[nodes.meta]\n", `ring.toml:4: duplicate key "meta"`},
+		{"ring.toml", "nodes = [\"a\"]\n", `ring.toml:1: inline arrays and tables aren't supported`},
+		{"ring.toml", "[[nodes]\n", `ring.toml:1: invalid table header "[[nodes]"`},
+		{"ring.toml", "replicas 10\n", `ring.toml:1: expected key = value, got "replicas 10"`},
+	}
+	for _, tt := range tests {
+		_, err := parseConfigFile(tt.name, []byte(tt.data))
+		if !errors.Is(err, ErrInvalidConfig) || !strings.Contains(err.Error(), tt.msg) {
+			t.Errorf("parseConfigFile(%q) error exp: %v, got %v\n", tt.data, tt.msg, err)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ring.toml")
+	if err := os.WriteFile(path, []byte(testTOML), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig %v\n", err)
+	}
+	if got := c.Members(); !reflect.DeepEqual(got, []string{"cache-1", "cache-2", "cache-3"}) {
+		t.Errorf("members exp: cache-1, cache-2, cache-3, got %v\n", got)
+	}
+	if n, _ := c.NodeInfo("cache-1"); n.Meta[ZoneLabel] != "us-east-1a" {
+		t.Errorf("cache-1 zone exp: us-east-1a, got %v\n", n.Meta)
+	}
+	if cfg := c.Config(); cfg.Hash != "xxhash64" || cfg.Replicas != 10 {
+		t.Errorf("config exp: xxhash64 of 10 replicas, got %+v\n", cfg)
+	}
+
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Errorf("LoadConfig missing file exp: error, got nil\n")
+	}
+}
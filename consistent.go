@@ -2,11 +2,16 @@
 package consistent
 
 import (
-	"fmt"
+	"hash"
 	"hash/crc64"
 	"hash/fnv"
+	"io"
+	"math/rand"
 	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Default constants
@@ -27,57 +32,190 @@ func NewConsistent() *Consistent {
 
 // NewConsistentWithN return consistent with given replica number and defautl hash algo: crc64
 func NewConsistentWithN(replicas int) *Consistent {
-	return NewConsistentWithHash(replicas, crc64h)
+	return NewConsistentWithOptions(WithReplicas(replicas))
 }
 
 // NewConsistentWithHash return consistent with given hash algorithm
 func NewConsistentWithHash(replicas int, fn HashFunc) *Consistent {
+	return NewConsistentWithOptions(WithReplicas(replicas), WithHashFunc(fn))
+}
+
+// NewConsistentWithOptions return consistent configured by given options,
+// unset ones fall back to defaults of NewConsistent
+func NewConsistentWithOptions(opts ...Option) *Consistent {
 	c := &Consistent{}
-	c.node = make(map[string]bool)
-	c.nodesmap = make(map[uint64]string)
-	c.setReplica(replicas)
-	c.setHashFunc(fn)
+	c.setDefaults()
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.ring == nil {
+		c.ring = newSliceRing()
+	}
+	if c.expected > 0 {
+		c.node = make(map[string]int, c.expected)
+		c.grow(c.expected)
+	}
 	return c
 }
 
-// HashFunc provides flexibility to give desired hash algorithm
-type HashFunc func([]byte) uint64
+// setDefaults makes empty consistent of NewConsistent settings, ring is
+// left to options
+func (c *Consistent) setDefaults() {
+	c.node = make(map[string]int)
+	c.capacity = make(map[string]float64)
+	c.info = make(map[string]Node)
+	c.token = make(map[string]string)
+	c.shadow = make(map[uint64][]string)
+	c.dropped = make(map[string]int)
+	c.setReplica(DefaultReplica)
+	c.setHashFunc(crc64h)
+	c.hashName = "crc64"
+	c.placement = AppendPlacement
+}
 
-func fnvh(key []byte) uint64 {
-	// not balanced while compute
-	// pending for verifying
-	cryptor := fnv.New64a()
-	cryptor.Write(key)
-	return cryptor.Sum64()
+// init sets up zero Consistent, decoded into rather than made by
+// constructor, caller must hold write lock
+func (c *Consistent) init() {
+	if c.ring == nil {
+		c.setDefaults()
+		c.ring = newSliceRing()
+	}
 }
 
-func crc64h(key []byte) uint64 {
-	return crc64.Checksum(key, CRC64ECMA128Table)
+// Option configures consistent in NewConsistentWithOptions
+type Option func(*Consistent)
+
+// WithReplicas sets virtual node number per physical node
+func WithReplicas(n int) Option {
+	return func(c *Consistent) { c.setReplica(n) }
+}
+
+// WithHashFunc sets hash algorithm
+func WithHashFunc(fn HashFunc) Option {
+	return func(c *Consistent) { c.setHashFunc(fn) }
+}
+
+// WithVirtualNodeBudget bounds total virtual nodes of nodes added by
+// AddNodeWithCapacity. Once default replicas for every capacity node would
+// exceed n, capacities are normalized to share exactly n points, so memory
+// stays bounded however many nodes join. Each node keeps at least one point.
+func WithVirtualNodeBudget(n int) Option {
+	return func(c *Consistent) { c.budget = n }
+}
+
+// WithSeed moves virtual nodes by seed, so rings of different seeds over
+// same nodes, e.g. of different tenants, have unrelated arcs and hot
+// spots. Seed 0 is the unseeded ring.
+func WithSeed(seed uint64) Option {
+	return func(c *Consistent) { c.seed = seed }
+}
+
+// WithExpectedNodes pre-sizes consistent for n nodes of default weight,
+// saving slice growth and map rehashing while bootstrapping
+func WithExpectedNodes(n int) Option {
+	return func(c *Consistent) { c.expected = n }
+}
+
+// WithParallelHashing hashes virtual nodes of large replica numbers on up
+// to workers goroutines, using ParallelAppendPlacement as placement
+func WithParallelHashing(workers int) Option {
+	return WithPlacement(ParallelAppendPlacement(workers))
+}
+
+// WithKeyNormalizer sets fn to normalize keys before hashing, e.g. case
+// folding, so variants of a key route to the same node
+func WithKeyNormalizer(fn KeyNormalizer) Option {
+	return func(c *Consistent) { c.normalize = fn }
+}
+
+// WithPlacement sets how virtual nodes are placed on the ring
+func WithPlacement(p PlacementFunc) Option {
+	return func(c *Consistent) { c.placement = p }
 }
 
-type suint64 []uint64
+// WithPersistentRing stores virtual nodes in a persistent treap. Mutations
+// copy only the O(log n) path they touch, so Snapshot is O(1) and old
+// snapshots keep their version while writers go on. It suits rings which
+// change often, e.g. nodes joining and leaving between reads, see
+// BenchmarkChurnReads.
+func WithPersistentRing() Option {
+	return func(c *Consistent) { c.ring = &treapRing{} }
+}
 
-func (s suint64) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
-func (s suint64) Len() int           { return len(s) }
-func (s suint64) Less(i, j int) bool { return s[i] < s[j] }
+// HashFunc provides flexibility to give desired hash algorithm. It must
+// not modify or retain passed key.
+type HashFunc func([]byte) uint64
 
-type consistentError struct {
-	Msg string
+// HashFuncFrom adapts hash.Hash64 of newHash to HashFunc. Hashers are
+// pooled and reset, so it's safe for concurrent use and doesn't allocate
+// one per key.
+func HashFuncFrom(newHash func() hash.Hash64) HashFunc {
+	pool := sync.Pool{New: func() interface{} { return newHash() }}
+	return func(key []byte) uint64 {
+		h := pool.Get().(hash.Hash64)
+		h.Reset()
+		h.Write(key)
+		sum := h.Sum64()
+		pool.Put(h)
+		return sum
+	}
 }
 
-func (c consistentError) Error() string {
-	return fmt.Sprintf("Consistent Error, %v\n", c.Msg)
+// not balanced while compute
+// pending for verifying
+var fnvh = HashFuncFrom(fnv.New64a)
+
+func crc64h(key []byte) uint64 {
+	return crc64.Checksum(key, CRC64ECMA128Table)
 }
 
 // Consistent struct
 type Consistent struct {
-	mu       sync.RWMutex
-	count    int
-	node     map[string]bool
-	nodesmap map[uint64]string
-	nodeskey suint64
-	replicas int
-	hashfunc HashFunc
+	mu        sync.RWMutex
+	state     atomic.Value // *RingSnapshot read by GetNode, nil when stale
+	count     int
+	node      map[string]int // physical node to its virtual node number
+	ring      ring
+	replicas  int
+	hashfunc  HashFunc
+	hashName  string        // identifies hashfunc when serialized, "" if custom
+	placement PlacementFunc // derives virtual node hashes
+	seed      uint64        // perturbs virtual node hashes, 0 is none
+	normalize KeyNormalizer
+	capacity  map[string]float64
+	explicit  map[string]bool // nodes of explicit virtual node number, nil if none
+	budget    int             // max virtual nodes shared by capacity nodes, 0 is unbounded
+	expected  int             // nodes to pre-size for
+	cacheSize int             // GetNode results kept per published state
+	lazy      bool            // stage AddNode and RemoveNode, see WithLazyRebuild
+	staged    bool            // ring is in bulk mode holding staged changes
+	tableBits int             // hash prefix bits of lookup table, 0 is none
+	debounce  time.Duration
+	settled   func()      // called once per debounced burst
+	settling  *time.Timer // pending publication of debounced changes
+	changed   time.Time   // last debounced change
+	loads     loadTracker
+	health    healthTracker
+	counters  *lookupCounters // nil unless WithLookupCounters
+	rebuilds  rebuildStats
+	sink      MetricsSink // nil unless WithMetrics
+	logger    eventLogger // nil unless WithLogger
+	audit     *auditLog   // nil unless WithAudit
+	store     Store       // nil unless WithStore
+	saved     uint64      // epoch last saved to store
+	unsaved   bool        // node info changed since last save
+	storeErr  error       // of last failed save, see StoreError
+	wal       io.Writer   // nil unless AppendLog
+	epoch     uint64      // membership changes so far, see Epoch
+	listeners []ChangeListener
+	events    []changeEvent // changes not yet delivered to listeners
+	notifying sync.Mutex    // serializes delivery to listeners
+	notified  ring          // published ring listeners last saw
+	info      map[string]Node
+	token     map[string]string   // name hashed for placement of replaced nodes
+	pins      map[string]string   // key to node overrides
+	shadow    map[uint64][]string // nodes whose point at hash lost a collision
+	dropped   map[string]int      // points per node lost to collisions
 }
 
 func (c *Consistent) setReplica(n int) {
@@ -88,108 +226,540 @@ func (c *Consistent) setReplica(n int) {
 	c.replicas = n
 }
 
+// SetReplicas changes default replica number and rescales virtual nodes of
+// nodes added by weight accordingly, keeping their relative weights, in
+// one lock so readers never see a half rebuilt ring. Nodes given explicit
+// virtual node number by AddNodeWithReplicas or SetVirtualNodes keep it.
+// Config and change log carry it as Explicit.
+func (c *Consistent) SetReplicas(n int) error {
+	if n <= 0 {
+		return ErrInvalidReplicas
+	}
+	c.lock()
+	defer c.unlock()
+	defer c.logStateAfter()()
+	old := c.replicas
+	c.replicas = n
+	if n != old {
+		c.epoch++
+	}
+	for node, vnodes := range c.node {
+		if _, ok := c.capacity[node]; ok || c.explicit[node] {
+			continue
+		}
+		vnodes = (vnodes*n + old/2) / old
+		if vnodes < 1 {
+			vnodes = 1
+		}
+		c.resizeNode(node, vnodes)
+	}
+	c.normalizeCapacity()
+	return nil
+}
+
+// SetHashFunc changes hash algorithm and rehashes virtual nodes of all
+// nodes in one lock, so readers never see a ring mixing two algorithms.
+// Config then has no hash name, so stores and change logs can't restore
+// fn, see SetHashName.
+func (c *Consistent) SetHashFunc(fn HashFunc) error {
+	if fn == nil {
+		return ErrNilHashFunc
+	}
+	c.lock()
+	defer c.unlock()
+	c.rehash(fn, "")
+	return nil
+}
+
+// rehash is SetHashFunc of hash named name, caller must hold write lock
+func (c *Consistent) rehash(fn HashFunc, name string) {
+	defer c.logStateAfter()()
+	c.hashfunc, c.hashName = fn, name
+	c.epoch++
+	c.ring.Reset()
+	c.shadow = make(map[uint64][]string)
+	c.dropped = make(map[string]int)
+	// sorted, so collisions resolve same way on every process
+	nodes := make([]string, 0, len(c.node))
+	for node := range c.node {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	for _, node := range nodes {
+		c.insertPoints(node, c.nodeKeys(node, c.node[node]))
+	}
+}
+
 func (c *Consistent) setHashFunc(fn HashFunc) {
 	c.hashfunc = fn
+	c.hashName = ""
+}
+
+// KeyNormalizer returns canonical form of key which gets hashed
+type KeyNormalizer func(key string) []byte
+
+// FoldKey is KeyNormalizer trimming spaces and lower casing keys
+func FoldKey(key string) []byte {
+	return []byte(strings.ToLower(strings.TrimSpace(key)))
 }
 
-func (c *Consistent) hashKey(key []byte, i int) uint64 {
-	for i > 0 {
-		j := byte(i % 256)
-		i /= 256
-		key = append(key, j)
+// normalized returns key in normalized form
+func (c *Consistent) normalized(key []byte) []byte {
+	if c.normalize == nil {
+		return key
 	}
-	return c.hashfunc(key)
+	return c.normalize(string(key))
 }
 
-// AddNode to consistent
-func (c *Consistent) AddNode(node string) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	if _, ok := c.node[node]; ok {
-		return
+// hashKey hashes lookup key
+func (c *Consistent) hashKey(key []byte) uint64 {
+	return c.hashfunc(c.normalized(key))
+}
+
+// nodeKeys returns hashes of first n virtual nodes of node
+func (c *Consistent) nodeKeys(node string, n int) []uint64 {
+	if t, ok := c.token[node]; ok {
+		node = t
 	}
-	nodeByte := []byte(node)
-	for i := 0; i < c.replicas; i++ {
-		key := c.hashKey(nodeByte, i)
-		c.nodesmap[key] = node
-		c.nodeskey = append(c.nodeskey, key)
+	keys := c.placement([]byte(node), n, c.hashfunc)
+	if c.seed != 0 {
+		for i, h := range keys {
+			keys[i] = mix64(h ^ c.seed)
+		}
 	}
-	sort.Sort(c.nodeskey)
-	c.node[node] = true
-	c.count++
+	return keys
+}
+
+// AddNode to consistent, fails with ErrNodeExists on existing node
+func (c *Consistent) AddNode(node string) error {
+	return c.AddWeightedNode(node, 1)
 }
 
-// AddNodes provides shortcut to add multiple nodes
-func (c *Consistent) AddNodes(nodes []string) {
-	for _, n := range nodes {
-		c.AddNode(n)
+// AddWeightedNode adds node with replica number scaled by weight, so node
+// with weight 2 gets about twice keyspace of node with weight 1.
+// Weight less than 1 is treated as 1.
+func (c *Consistent) AddWeightedNode(node string, weight int) error {
+	if weight < 1 {
+		weight = 1
 	}
+	c.lockStaged()
+	defer c.unlockStaged()
+	return c.addNode(node, c.replicas*weight)
 }
 
-// RemoveNode from consistent
-func (c *Consistent) RemoveNode(node string) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// AddNodeWithReplicas adds node with explicit virtual node number,
+// independent of ring default. Useful for canary nodes which should only
+// get a sliver of traffic. Replicas less than 1 is treated as 1.
+func (c *Consistent) AddNodeWithReplicas(node string, replicas int) error {
+	if replicas < 1 {
+		replicas = 1
+	}
+	c.lockStaged()
+	defer c.unlockStaged()
 	if _, ok := c.node[node]; !ok {
+		// before adding, so change log records it
+		c.setExplicit(node, true)
+	}
+	return c.addNode(node, replicas)
+}
+
+// setExplicit records whether virtual node number of node was given
+// explicitly, rather than as weight times replicas
+func (c *Consistent) setExplicit(node string, explicit bool) {
+	if !explicit {
+		delete(c.explicit, node)
 		return
 	}
-	nodeByte := []byte(node)
-	for i := 0; i < c.replicas; i++ {
-		key := c.hashKey(nodeByte, i)
-		delete(c.nodesmap, key)
-		c.remove(key)
+	if c.explicit == nil {
+		c.explicit = make(map[string]bool)
+	}
+	c.explicit[node] = true
+}
+
+func (c *Consistent) addNode(node string, vnodes int) error {
+	if _, ok := c.node[node]; ok {
+		if c.logger != nil {
+			c.logger.log(logNoOp, "node already exists", "node", node)
+		}
+		return ErrNodeExists
+	}
+	c.insertPoints(node, c.nodeKeys(node, vnodes))
+	c.added(node, vnodes)
+	return nil
+}
+
+// added registers node whose points are on the ring
+func (c *Consistent) added(node string, vnodes int) {
+	c.node[node] = vnodes
+	c.count++
+	if c.sink != nil {
+		c.sink.Count("node.added", 1, "node:"+node)
+	}
+	c.record(nodeAdded, node, 0, vnodes)
+}
+
+// AddNodes provides shortcut to add multiple nodes under one write lock,
+// sorting the ring once. All nodes are tried, first error is returned.
+func (c *Consistent) AddNodes(nodes []string) error {
+	return c.Batch(nodes, nil)
+}
+
+// ReplaceNode renames node old to new keeping exact virtual node hashes of
+// old, so no key moves when a host is renamed or re-IPed. Weight and
+// capacity carry over, node info doesn't.
+func (c *Consistent) ReplaceNode(old, new string) error {
+	c.lock()
+	defer c.unlock()
+	vnodes, ok := c.node[old]
+	if !ok {
+		return ErrNodeNotFound
+	}
+	if _, ok := c.node[new]; ok {
+		return ErrNodeExists
 	}
+	keys := c.nodeKeys(old, vnodes)
+	token := old
+	if t, ok := c.token[old]; ok {
+		token = t
+	}
+	c.renamePoints(old, new, keys)
+	c.node[new] = vnodes
+	delete(c.node, old)
+	delete(c.info, old)
+	delete(c.token, old)
+	if token != new {
+		c.token[new] = token
+	}
+	if capacity, ok := c.capacity[old]; ok {
+		c.capacity[new] = capacity
+		delete(c.capacity, old)
+	}
+	if c.explicit[old] {
+		c.setExplicit(old, false)
+		c.setExplicit(new, true)
+	}
+	if c.sink != nil {
+		c.sink.Count("node.removed", 1, "node:"+old)
+		c.sink.Count("node.added", 1, "node:"+new)
+	}
+	c.record(nodeRemoved, old, vnodes, 0)
+	c.record(nodeAdded, new, 0, vnodes)
+	if _, ok := c.capacity[new]; ok {
+		c.changedInfo(new)
+	}
+	return nil
+}
+
+// RemoveNode from consistent, fails with ErrNodeNotFound on unknown node
+func (c *Consistent) RemoveNode(node string) error {
+	c.lockStaged()
+	defer c.unlockStaged()
+	return c.removeNode(node)
+}
+
+func (c *Consistent) removeNode(node string) error {
+	vnodes, ok := c.node[node]
+	if !ok {
+		if c.logger != nil {
+			c.logger.log(logNoOp, "node not found", "node", node)
+		}
+		return ErrNodeNotFound
+	}
+	c.deletePoints(node, c.nodeKeys(node, vnodes))
 	delete(c.node, node)
 	c.count--
+	delete(c.info, node)
+	delete(c.token, node)
+	delete(c.explicit, node)
+	if _, ok := c.capacity[node]; ok {
+		delete(c.capacity, node)
+		c.normalizeCapacity()
+	}
+	if c.sink != nil {
+		c.sink.Count("node.removed", 1, "node:"+node)
+	}
+	c.record(nodeRemoved, node, vnodes, 0)
+	return nil
+}
+
+// RemoveNodes provides shortcut to remove nodes under one write lock,
+// compacting the ring once. All nodes are tried, first error is returned.
+func (c *Consistent) RemoveNodes(nodes []string) error {
+	return c.Batch(nil, nodes)
+}
+
+// Clone returns an independent copy of consistent, including replicas,
+// hash algorithm and placement. Mutating either one doesn't affect other.
+func (c *Consistent) Clone() *Consistent {
+	c.rlock()
+	defer c.runlock()
+	n := &Consistent{
+		count:     c.count,
+		node:      make(map[string]int, len(c.node)),
+		ring:      c.ring.Clone(),
+		replicas:  c.replicas,
+		hashfunc:  c.hashfunc,
+		hashName:  c.hashName,
+		placement: c.placement,
+		seed:      c.seed,
+		epoch:     c.epoch,
+		sink:      c.sink,
+		logger:    c.logger,
+		normalize: c.normalize,
+		capacity:  make(map[string]float64, len(c.capacity)),
+		budget:    c.budget,
+		cacheSize: c.cacheSize,
+		lazy:      c.lazy,
+		tableBits: c.tableBits,
+		info:      make(map[string]Node, len(c.info)),
+		token:     make(map[string]string, len(c.token)),
+		shadow:    make(map[uint64][]string, len(c.shadow)),
+		dropped:   make(map[string]int, len(c.dropped)),
+	}
+	for k, v := range c.node {
+		n.node[k] = v
+	}
+	for k, v := range c.capacity {
+		n.capacity[k] = v
+	}
+	for k, v := range c.info {
+		n.info[k] = v
+	}
+	for k, v := range c.token {
+		n.token[k] = v
+	}
+	for k, v := range c.shadow {
+		n.shadow[k] = append([]string(nil), v...)
+	}
+	for k, v := range c.dropped {
+		n.dropped[k] = v
+	}
+	for k := range c.explicit {
+		n.setExplicit(k, true)
+	}
+	if c.pins != nil {
+		n.pins = make(map[string]string, len(c.pins))
+		for k, v := range c.pins {
+			n.pins[k] = v
+		}
+	}
+	c.loads.mu.Lock()
+	if c.loads.load != nil {
+		n.loads.load = make(map[string]float64, len(c.loads.load))
+		for k, v := range c.loads.load {
+			n.loads.load[k] = v
+		}
+	}
+	c.loads.mu.Unlock()
+	if c.loads.shrunk != nil {
+		n.loads.shrunk = make(map[string]shrink, len(c.loads.shrunk))
+		for k, v := range c.loads.shrunk {
+			n.loads.shrunk[k] = v
+		}
+	}
+	if c.counters != nil {
+		// clone counts its own lookups
+		n.counters = &lookupCounters{}
+	}
+	return n
 }
 
-// RemoveNodes provides shortcut to remove nodes
-func (c *Consistent) RemoveNodes(nodes []string) {
-	for _, n := range nodes {
-		c.RemoveNode(n)
+// Grow makes room for n more nodes of default weight, so adding them
+// doesn't reallocate the ring
+func (c *Consistent) Grow(n int) {
+	c.lock()
+	defer c.unlock()
+	c.grow(n)
+}
+
+func (c *Consistent) grow(n int) {
+	if g, ok := c.ring.(growRing); ok && n > 0 {
+		g.Grow(n * c.replicas)
 	}
 }
 
-func (c *Consistent) remove(key uint64) {
-	i := c.search(key)
-	c.nodeskey = append(c.nodeskey[:i], c.nodeskey[i+1:]...)
+// Reset removes all nodes at once, cheaper than removing them one by one
+func (c *Consistent) Reset() {
+	c.lock()
+	defer c.unlock()
+	c.removeAll()
+}
+
+// removeAll is Reset, caller must hold write lock
+func (c *Consistent) removeAll() {
+	vnodes := c.node
+	c.reset()
+	for node, n := range vnodes {
+		c.record(nodeRemoved, node, n, 0)
+	}
+}
+
+func (c *Consistent) reset() {
+	c.ring.Reset()
+	c.node = make(map[string]int)
+	c.capacity = make(map[string]float64)
+	c.info = make(map[string]Node)
+	c.token = make(map[string]string)
+	c.shadow = make(map[uint64][]string)
+	c.dropped = make(map[string]int)
+	c.explicit = nil
+	c.count = 0
+	c.loads.mu.Lock()
+	c.loads.load = nil
+	c.loads.mu.Unlock()
+	c.loads.shrunk = nil
 }
 
 // GetNode returns first found node
 func (c *Consistent) GetNode(key string) (string, error) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	if len(c.nodeskey) == 0 {
-		return "", consistentError{Msg: "Empty! No nodes."}
-	}
-	ind := c.searchKey(key)
-	node := c.getNode(ind)
-	return node, nil
+	return c.current().getNode(keyBytes(key))
+}
+
+// GetNodeBytes is GetNode for binary keys, saving conversion to string
+func (c *Consistent) GetNodeBytes(key []byte) (string, error) {
+	return c.current().getNode(key)
+}
+
+// GetNodeWithHash is GetNode also returning hash of key, i.e. ring
+// position the decision was made at, for logging and tracing
+func (c *Consistent) GetNodeWithHash(key string) (node string, hash uint64, err error) {
+	c.rlock()
+	defer c.runlock()
+	hash = c.hashKey([]byte(key))
+	node, err = c.lookup([]byte(key))
+	return node, hash, err
 }
 
-func (c *Consistent) search(key uint64) int {
-	ind := sort.Search(len(c.nodeskey), func(i int) bool { return c.nodeskey[i] >= key })
-	if ind >= len(c.nodeskey) {
-		ind = 0
+// GetNodes resolves many keys in one lock, returns key to node map
+func (c *Consistent) GetNodes(keys []string) (map[string]string, error) {
+	c.rlock()
+	defer c.runlock()
+	if c.ring.Len() == 0 {
+		return map[string]string{}, ErrNoNodes
+	}
+	nodes := make(map[string]string, len(keys))
+	for _, key := range keys {
+		nodes[key], _ = c.lookup([]byte(key))
 	}
-	return ind
+	return nodes, nil
 }
 
-func (c *Consistent) searchKey(key string) int {
-	return c.search(c.hashfunc([]byte(key)))
+// GetNodeByHash returns first found node of an already hashed key,
+// h must come from same hash algorithm as the ring
+func (c *Consistent) GetNodeByHash(h uint64) (string, error) {
+	c.rlock()
+	defer c.runlock()
+	return c.getNode(h)
+}
+
+// RandomNode returns owner of a uniformly random ring position, so nodes
+// are picked proportionally to keyspace they own
+func (c *Consistent) RandomNode() (string, error) {
+	c.rlock()
+	defer c.runlock()
+	return c.getNode(rand.Uint64())
+}
+
+func (c *Consistent) getNode(h uint64) (string, error) {
+	if c.ring.Len() == 0 {
+		return "", ErrNoNodes
+	}
+	return c.ring.Owner(c.ring.Search(h)), nil
 }
 
 // GetNNode returns found distinct nodes with given n
 func (c *Consistent) GetNNode(key string, n int) ([]string, error) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	return c.current().getNNode(keyBytes(key), n)
+}
+
+// GetNNodeAppend is GetNNode appending nodes to dst, so callers can reuse
+// result slice. On error dst is returned as is.
+func (c *Consistent) GetNNodeAppend(dst []string, key string, n int) ([]string, error) {
+	return c.current().GetNNodeAppend(dst, key, n)
+}
+
+// GetNNodeBytes is GetNNode for binary keys, saving conversion to string
+func (c *Consistent) GetNNodeBytes(key []byte, n int) ([]string, error) {
+	return c.current().getNNode(key, n)
+}
+
+// GetNNodeByHash is GetNNode for an already hashed key
+func (c *Consistent) GetNNodeByHash(h uint64, n int) ([]string, error) {
+	c.rlock()
+	defer c.runlock()
+	return c.getNNode(h, n)
+}
+
+func (c *Consistent) getNNode(h uint64, n int) ([]string, error) {
 	if n > c.count {
-		return []string{}, consistentError{Msg: "Query N is greater than total nodes"}
+		return []string{}, ErrNotEnoughNodes
+	}
+	return enoughNodes(distinctNodes(c.ring, c.ring.Search(h), n), n)
+}
+
+// GetUpToNNode is GetNNode returning all distinct nodes instead of error
+// when n is greater than total nodes. It still fails on empty ring.
+func (c *Consistent) GetUpToNNode(key string, n int) ([]string, error) {
+	c.rlock()
+	defer c.runlock()
+	if c.count == 0 {
+		return []string{}, ErrNoNodes
+	}
+	return c.ownersN([]byte(key), n), nil
+}
+
+// distinctNodes walks ring clockwise from ind and collects n distinct nodes,
+// caller must make sure there are at least n nodes on the ring
+func distinctNodes(r ring, ind, n int) []string {
+	return appendDistinct(nil, 0, r, ind, n)
+}
+
+// appendDistinct walks ring clockwise from ind, at most once around, and
+// appends nodes not yet in dst[from:] until it holds n. Rings with node
+// indexes are deduplicated by a bitmap instead of scanning dst.
+func appendDistinct(dst []string, from int, r ring, ind, n int) []string {
+	ir, ok := r.(indexRing)
+	if !ok {
+		seen := dst[from:]
+		return append(dst, collectNodes(r, ind, n-len(seen), func(node string) bool {
+			return !stringInSlice(seen, node)
+		})...)
+	}
+	seeds := dst[from:]
+	var small uint64
+	var large []uint64
+	if slots := ir.NodeSlots(); slots > 64 {
+		large = make([]uint64, (slots+63)/64)
 	}
+	max := r.Len() - 1
+	for i := 0; len(dst)-from < n && i <= max; i++ {
+		id := ir.OwnerIndex(ind)
+		bit := uint64(1) << (id % 64)
+		var set bool
+		if large == nil {
+			set, small = small&bit != 0, small|bit
+		} else {
+			set, large[id/64] = large[id/64]&bit != 0, large[id/64]|bit
+		}
+		if t := r.Owner(ind); !set && !stringInSlice(seeds, t) {
+			dst = append(dst, t)
+		}
+		if ind < max {
+			ind++
+		} else {
+			ind = 0
+		}
+	}
+	return dst
+}
+
+// collectNodes walks ring clockwise from ind, at most once around, and
+// collects up to n distinct nodes accepted by accept, nil accepts all
+func collectNodes(r ring, ind, n int, accept func(string) bool) []string {
 	var nodes []string
-	ind, max := c.searchKey(key), c.replicas*c.count-1
-	for len(nodes) < n {
-		if t := c.getNode(ind); !stringInSlice(nodes, t) {
+	max := r.Len() - 1
+	for i := 0; len(nodes) < n && i <= max; i++ {
+		if t := r.Owner(ind); !stringInSlice(nodes, t) && (accept == nil || accept(t)) {
 			nodes = append(nodes, t)
 		}
 		if ind < max {
@@ -198,6 +768,33 @@ func (c *Consistent) GetNNode(key string, n int) ([]string, error) {
 			ind = 0
 		}
 	}
+	return nodes
+}
+
+// GetNNodeExcluding is GetNNode skipping nodes in exclude, e.g. nodes
+// already tried. Walk goes on past excluded nodes, so result is the same
+// as GetNNode with excluded nodes filtered out and topped up.
+func (c *Consistent) GetNNodeExcluding(key string, n int, exclude []string) ([]string, error) {
+	c.rlock()
+	defer c.runlock()
+	skip := make(map[string]bool, len(exclude))
+	for _, e := range exclude {
+		if _, ok := c.node[e]; ok {
+			skip[e] = true
+		}
+	}
+	if n > c.count-len(skip) {
+		return []string{}, ErrNotEnoughNodes
+	}
+	return enoughNodes(c.collectPinned([]byte(key), n, func(node string) bool { return !skip[node] }), n)
+}
+
+// enoughNodes fails with ErrNotEnoughNodes if walk found less than n
+// nodes, as members shadowed by collisions own no points
+func enoughNodes(nodes []string, n int) ([]string, error) {
+	if len(nodes) < n {
+		return []string{}, ErrNotEnoughNodes
+	}
 	return nodes, nil
 }
 
@@ -210,23 +807,48 @@ func stringInSlice(l []string, x string) bool {
 	return false
 }
 
-func (c *Consistent) getNode(ind int) string {
-	return c.nodesmap[c.nodeskey[ind]]
-}
-
 // Get3Node is shortcut to get 3 Node
 // Becasue 3 replica/sharding is a practical number for performance and robust
 func (c *Consistent) Get3Node(key string) ([]string, error) {
 	return c.GetNNode(key, 3)
 }
 
+// GetPrimaryAndReplicas returns owner of key and next replicas distinct
+// nodes, e.g. to write to primary and read from any replica
+func (c *Consistent) GetPrimaryAndReplicas(key string, replicas int) (primary string, secondaries []string, err error) {
+	c.rlock()
+	defer c.runlock()
+	if c.count == 0 {
+		return "", []string{}, ErrNoNodes
+	}
+	nodes, err := c.lookupN([]byte(key), replicas+1)
+	if err != nil {
+		return "", []string{}, err
+	}
+	return nodes[0], nodes[1:], nil
+}
+
 // HasNode tests exsiting node
 func (c *Consistent) HasNode(node string) bool {
-	_, ok := c.node[node]
-	return ok
+	return c.current().HasNode(node)
+}
+
+// Members returns sorted copy of current physical nodes
+func (c *Consistent) Members() []string {
+	return c.current().Members()
 }
 
 // NodeNumber return currently physical node number
 func (c *Consistent) NodeNumber() int {
-	return c.count
+	return c.current().NodeNumber()
+}
+
+// Epoch returns number of membership changes, adding, removing or
+// reweighting a node counts one, as does change of replicas or hash
+// function. It's carried by Config, so it orders
+// states shipped between processes.
+func (c *Consistent) Epoch() uint64 {
+	c.rlock()
+	defer c.runlock()
+	return c.epoch
 }
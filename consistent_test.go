@@ -1,7 +1,12 @@
 package consistent
 
+import "errors"
 import "fmt"
+import "hash"
+import "hash/crc64"
+import "hash/fnv"
 import "reflect"
+import "strings"
 import "testing"
 
 func TestInit(t *testing.T) {
@@ -81,12 +86,12 @@ func TestConsistentHashing(t *testing.T) {
 	}{
 		{"Abc", 1, []string{"node1"}, nil, "Get 1 Wrong mapping Abc -> node1"},
 		{"xxx", 2, []string{"node1", "node2"}, nil, "Get 2 Wrong mapping xxx -> node1, 2"},
-		{"okbnqeobla;d", 6, []string{}, consistentError{Msg: "Query N is greater than total nodes"},
+		{"okbnqeobla;d", 6, []string{}, ErrNotEnoughNodes,
 			"Get N greater than total node is invalid"},
 	}
 
 	for _, v := range testGetNnode {
-		if node, err := c.GetNNode(v.Key, v.N); err != v.Err || !reflect.DeepEqual(node, v.Exp) {
+		if node, err := c.GetNNode(v.Key, v.N); !errors.Is(err, v.Err) || !reflect.DeepEqual(node, v.Exp) {
 			t.Errorf("GetNNode err: %v, exp: %v, got: %v\n", v.Msg, v.Exp, node)
 		}
 	}
@@ -98,11 +103,11 @@ func TestConsistentHashing(t *testing.T) {
 		Exp error
 		Msg string
 	}{
-		{"okbnqeobla;d", consistentError{Msg: "Empty! No nodes."}, "Empty! No nodes."},
+		{"okbnqeobla;d", ErrNoNodes, "Empty! No nodes."},
 	}
 
 	for _, v := range testEmpty {
-		if _, err := c.GetNode(v.Key); err != v.Exp {
+		if _, err := c.GetNode(v.Key); !errors.Is(err, v.Exp) {
 			t.Errorf("GetNode err: %v, exp: %v, got: %v\n", v.Msg, v.Exp, err)
 		}
 	}
@@ -128,3 +133,495 @@ func BenchmarkGetNNode(b *testing.B) {
 		c.GetNNode(fmt.Sprintf("%v", i), 5)
 	}
 }
+
+func TestWeightedNode(t *testing.T) {
+	c := NewConsistent()
+	c.AddNode("node1")
+	c.AddWeightedNode("node2", 2)
+	c.AddWeightedNode("node3", 0)
+
+	if c.ring.Len() != 4*DefaultReplica {
+		t.Errorf("Wrong virtual nodes, exp: %v, got %v\n", 4*DefaultReplica, c.ring.Len())
+	}
+
+	hits := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		node, _ := c.GetNode(fmt.Sprintf("key%v", i))
+		hits[node]++
+	}
+	if hits["node2"] < 4000 || hits["node1"] > 3000 || hits["node3"] > 3000 {
+		t.Errorf("Weight not respected, got %v\n", hits)
+	}
+
+	if nodes, err := c.GetNNode("Abc", 3); err != nil || len(nodes) != 3 {
+		t.Errorf("GetNNode err: %v, got: %v\n", err, nodes)
+	}
+
+	c.RemoveNode("node2")
+	if c.ring.Len() != 2*DefaultReplica || c.HasNode("node2") {
+		t.Errorf("Wrong virtual nodes after remove, exp: %v, got %v\n", 2*DefaultReplica, c.ring.Len())
+	}
+}
+
+func TestMembers(t *testing.T) {
+	c := NewConsistent()
+	if m := c.Members(); len(m) != 0 {
+		t.Errorf("Wrong Members(), exp: [], got %v\n", m)
+	}
+	c.AddNodes([]string{"node3", "node1", "node2"})
+	c.RemoveNode("node2")
+	if m := c.Members(); !reflect.DeepEqual(m, []string{"node1", "node3"}) {
+		t.Errorf("Wrong Members(), exp: [node1 node3], got %v\n", m)
+	}
+}
+
+func TestGetNodeBytes(t *testing.T) {
+	c := NewConsistent()
+	c.AddNodes([]string{"node1", "node2", "node3", "node4", "node5"})
+
+	for _, key := range []string{"Abc", "xxx", "1111234567", "okbnqeobla;d"} {
+		exp, _ := c.GetNode(key)
+		if node, err := c.GetNodeBytes([]byte(key)); err != nil || node != exp {
+			t.Errorf("GetNodeBytes err: %v, exp: %v, got: %v\n", err, exp, node)
+		}
+		expN, _ := c.GetNNode(key, 3)
+		if nodes, err := c.GetNNodeBytes([]byte(key), 3); err != nil || !reflect.DeepEqual(nodes, expN) {
+			t.Errorf("GetNNodeBytes err: %v, exp: %v, got: %v\n", err, expN, nodes)
+		}
+	}
+}
+
+func TestGetNodeByHash(t *testing.T) {
+	c := NewConsistent()
+	if _, err := c.GetNodeByHash(0); err == nil {
+		t.Errorf("GetNodeByHash should fail on empty ring\n")
+	}
+	c.AddNodes([]string{"node1", "node2", "node3", "node4", "node5"})
+
+	for _, key := range []string{"Abc", "xxx", "1111234567", "okbnqeobla;d"} {
+		h := crc64h([]byte(key))
+		exp, _ := c.GetNode(key)
+		if node, err := c.GetNodeByHash(h); err != nil || node != exp {
+			t.Errorf("GetNodeByHash err: %v, exp: %v, got: %v\n", err, exp, node)
+		}
+		expN, _ := c.GetNNode(key, 3)
+		if nodes, err := c.GetNNodeByHash(h, 3); err != nil || !reflect.DeepEqual(nodes, expN) {
+			t.Errorf("GetNNodeByHash err: %v, exp: %v, got: %v\n", err, expN, nodes)
+		}
+	}
+}
+
+func TestGetNNodeExcluding(t *testing.T) {
+	c := NewConsistent()
+	c.AddNodes([]string{"node1", "node2", "node3", "node4", "node5"})
+
+	all, _ := c.GetNNode("xxx", 5)
+	nodes, err := c.GetNNodeExcluding("xxx", 2, []string{all[0], "node9"})
+	if err != nil || !reflect.DeepEqual(nodes, all[1:3]) {
+		t.Errorf("GetNNodeExcluding err: %v, exp: %v, got: %v\n", err, all[1:3], nodes)
+	}
+
+	if _, err := c.GetNNodeExcluding("xxx", 4, []string{"node1", "node2"}); err == nil {
+		t.Errorf("GetNNodeExcluding should fail when not enough nodes left\n")
+	}
+}
+
+func TestGetUpToNNode(t *testing.T) {
+	c := NewConsistent()
+	if _, err := c.GetUpToNNode("xxx", 2); err == nil {
+		t.Errorf("GetUpToNNode should fail on empty ring\n")
+	}
+	c.AddNodes([]string{"node1", "node2", "node3"})
+
+	exp, _ := c.GetNNode("xxx", 3)
+	if nodes, err := c.GetUpToNNode("xxx", 6); err != nil || !reflect.DeepEqual(nodes, exp) {
+		t.Errorf("GetUpToNNode err: %v, exp: %v, got: %v\n", err, exp, nodes)
+	}
+	if nodes, err := c.GetUpToNNode("xxx", 2); err != nil || !reflect.DeepEqual(nodes, exp[:2]) {
+		t.Errorf("GetUpToNNode err: %v, exp: %v, got: %v\n", err, exp[:2], nodes)
+	}
+}
+
+func TestReset(t *testing.T) {
+	for _, opt := range []Option{WithReplicas(DefaultReplica), WithPersistentRing()} {
+		c := NewConsistentWithOptions(opt)
+		c.AddNodes([]string{"node1", "node2", "node3"})
+		c.AddNodeWithCapacity("node4", 2)
+		c.Reset()
+
+		if c.NodeNumber() != 0 || c.ring.Len() != 0 || len(c.Members()) != 0 || c.Capacity("node4") != 0 {
+			t.Errorf("Reset left nodes, count: %v, points: %v\n", c.NodeNumber(), c.ring.Len())
+		}
+		if _, err := c.GetNode("xxx"); err == nil {
+			t.Errorf("GetNode should fail after Reset\n")
+		}
+
+		c.AddNode("node1")
+		if node, err := c.GetNode("xxx"); err != nil || node != "node1" {
+			t.Errorf("GetNode err: %v, exp: node1, got: %v\n", err, node)
+		}
+	}
+}
+
+func TestClone(t *testing.T) {
+	c := NewConsistentWithN(50)
+	c.AddNodes([]string{"node1", "node2", "node3"})
+	n := c.Clone()
+	n.RemoveNode("node1")
+	n.AddNode("node4")
+
+	if !reflect.DeepEqual(c.Members(), []string{"node1", "node2", "node3"}) {
+		t.Errorf("Clone mutation leaked, got %v\n", c.Members())
+	}
+	if !reflect.DeepEqual(n.Members(), []string{"node2", "node3", "node4"}) {
+		t.Errorf("Wrong clone Members(), got %v\n", n.Members())
+	}
+	if n.ring.Len() != 150 || c.ring.Len() != 150 {
+		t.Errorf("Clone should keep replicas, got %v and %v points\n", n.ring.Len(), c.ring.Len())
+	}
+}
+
+func TestSetHashFunc(t *testing.T) {
+	c := NewConsistent()
+	c.AddNodes([]string{"node1", "node2", "node3"})
+	c.AddWeightedNode("node4", 2)
+
+	if err := c.SetHashFunc(nil); err == nil {
+		t.Errorf("SetHashFunc should fail on nil\n")
+	}
+	if err := c.SetHashFunc(fnvh); err != nil {
+		t.Fatalf("SetHashFunc err: %v\n", err)
+	}
+
+	fresh := NewConsistentWithHash(DefaultReplica, fnvh)
+	fresh.AddNodes([]string{"node1", "node2", "node3"})
+	fresh.AddWeightedNode("node4", 2)
+	if c.ring.Len() != fresh.ring.Len() {
+		t.Errorf("Wrong ring size, exp: %v, got %v\n", fresh.ring.Len(), c.ring.Len())
+	}
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key%v", i)
+		exp, _ := fresh.GetNode(key)
+		if node, _ := c.GetNode(key); node != exp {
+			t.Errorf("Rehashed ring differs from fresh one for %v, exp: %v, got %v\n", key, exp, node)
+		}
+	}
+
+	if err := c.SetHashName("md5"); !errors.Is(err, ErrUnknownHash) {
+		t.Errorf("SetHashName unknown, exp: %v, got %v\n", ErrUnknownHash, err)
+	}
+	epoch := c.Epoch()
+	if err := c.SetHashName("xxhash64"); err != nil || c.Config().Hash != "xxhash64" || c.Epoch() != epoch+1 {
+		t.Errorf("SetHashName exp: xxhash64 at epoch %v, got %v at %v %v\n", epoch+1, c.Config().Hash, c.Epoch(), err)
+	}
+}
+
+func TestGetNodes(t *testing.T) {
+	c := NewConsistent()
+	keys := []string{"Abc", "xxx", "1111234567", "okbnqeobla;d"}
+	if _, err := c.GetNodes(keys); err == nil {
+		t.Errorf("GetNodes should fail on empty ring\n")
+	}
+	c.AddNodes([]string{"node1", "node2", "node3", "node4", "node5"})
+
+	nodes, err := c.GetNodes(keys)
+	if err != nil || len(nodes) != len(keys) {
+		t.Fatalf("GetNodes err: %v, got: %v\n", err, nodes)
+	}
+	for _, key := range keys {
+		if exp, _ := c.GetNode(key); nodes[key] != exp {
+			t.Errorf("GetNodes err for %v, exp: %v, got: %v\n", key, exp, nodes[key])
+		}
+	}
+}
+
+func BenchmarkGetNodes(b *testing.B) {
+	b.ReportAllocs()
+	c := NewConsistent()
+	c.AddNodes([]string{"n1", "n2", "n3", "n4", "n5", "n6", "n7", "n8", "n9", "n10", "n11", "n12"})
+	keys := make([]string, 1000)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("%v", i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.GetNodes(keys)
+	}
+}
+
+func TestMutationErrors(t *testing.T) {
+	c := NewConsistent()
+	testErrors := []struct {
+		Err error
+		Exp error
+		Msg string
+	}{
+		{c.AddNode("node1"), nil, "AddNode node1"},
+		{c.AddNode("node1"), ErrNodeExists, "AddNode existing node1"},
+		{c.AddNodeWithReplicas("node1", 3), ErrNodeExists, "AddNodeWithReplicas existing node1"},
+		{c.AddNodeWithCapacity("node2", 0), ErrInvalidCapacity, "AddNodeWithCapacity zero capacity"},
+		{c.AddNodes([]string{"node2", "node1", "node3"}), ErrNodeExists, "AddNodes with existing node1"},
+		{c.UpdateWeight("node9", 2), ErrNodeNotFound, "UpdateWeight unknown node9"},
+		{c.SetCapacity("node2", 2), ErrNodeNotFound, "SetCapacity of non capacity node2"},
+		{c.RemoveNode("node9"), ErrNodeNotFound, "RemoveNode unknown node9"},
+		{c.RemoveNodes([]string{"node1", "node9", "node2"}), ErrNodeNotFound, "RemoveNodes with unknown node9"},
+		{c.SetReplicas(-1), ErrInvalidReplicas, "SetReplicas negative"},
+		{c.SetHashFunc(nil), ErrNilHashFunc, "SetHashFunc nil"},
+	}
+
+	for _, v := range testErrors {
+		if !errors.Is(v.Err, v.Exp) {
+			t.Errorf("%v err, exp: %v, got: %v\n", v.Msg, v.Exp, v.Err)
+		}
+	}
+	if !reflect.DeepEqual(c.Members(), []string{"node3"}) {
+		t.Errorf("Batch operations should go on after errors, got %v\n", c.Members())
+	}
+}
+
+func TestReplaceNode(t *testing.T) {
+	c := NewConsistent()
+	c.AddNodes([]string{"node1", "node2", "node3"})
+	c.AddWeightedNode("node4", 2)
+	before := map[string]string{}
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key%v", i)
+		before[key], _ = c.GetNode(key)
+	}
+
+	if err := c.ReplaceNode("node9", "node5"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("ReplaceNode err, exp: %v, got: %v\n", ErrNodeNotFound, err)
+	}
+	if err := c.ReplaceNode("node1", "node2"); !errors.Is(err, ErrNodeExists) {
+		t.Errorf("ReplaceNode err, exp: %v, got: %v\n", ErrNodeExists, err)
+	}
+	if err := c.ReplaceNode("node4", "node5"); err != nil {
+		t.Fatalf("ReplaceNode err: %v\n", err)
+	}
+	if err := c.ReplaceNode("node5", "node6"); err != nil {
+		t.Fatalf("ReplaceNode err: %v\n", err)
+	}
+
+	for key, old := range before {
+		exp := old
+		if old == "node4" {
+			exp = "node6"
+		}
+		if node, _ := c.GetNode(key); node != exp {
+			t.Errorf("Key %v moved, exp: %v, got %v\n", key, exp, node)
+		}
+	}
+
+	if err := c.RemoveNode("node6"); err != nil || c.ring.Len() != 3*DefaultReplica {
+		t.Errorf("RemoveNode of replaced node err: %v, points left: %v\n", err, c.ring.Len())
+	}
+}
+
+func TestGetPrimaryAndReplicas(t *testing.T) {
+	c := NewConsistent()
+	if _, _, err := c.GetPrimaryAndReplicas("xxx", 0); !errors.Is(err, ErrNoNodes) {
+		t.Errorf("GetPrimaryAndReplicas err, exp: %v, got: %v\n", ErrNoNodes, err)
+	}
+	c.AddNodes([]string{"node1", "node2", "node3"})
+
+	exp, _ := c.GetNNode("xxx", 3)
+	p, s, err := c.GetPrimaryAndReplicas("xxx", 2)
+	if err != nil || p != exp[0] || !reflect.DeepEqual(s, exp[1:]) {
+		t.Errorf("GetPrimaryAndReplicas err: %v, exp: %v, got: %v %v\n", err, exp, p, s)
+	}
+	if p, s, err := c.GetPrimaryAndReplicas("xxx", 0); err != nil || p != exp[0] || len(s) != 0 {
+		t.Errorf("GetPrimaryAndReplicas err: %v, exp: %v, got: %v %v\n", err, exp[0], p, s)
+	}
+	if _, _, err := c.GetPrimaryAndReplicas("xxx", 3); !errors.Is(err, ErrNotEnoughNodes) {
+		t.Errorf("GetPrimaryAndReplicas err, exp: %v, got: %v\n", ErrNotEnoughNodes, err)
+	}
+}
+
+func TestRandomNode(t *testing.T) {
+	c := NewConsistent()
+	if _, err := c.RandomNode(); !errors.Is(err, ErrNoNodes) {
+		t.Errorf("RandomNode err, exp: %v, got: %v\n", ErrNoNodes, err)
+	}
+	c.AddNode("node1")
+	c.AddWeightedNode("node2", 3)
+
+	hits := map[string]int{}
+	for i := 0; i < 4000; i++ {
+		node, err := c.RandomNode()
+		if err != nil {
+			t.Fatalf("RandomNode err: %v\n", err)
+		}
+		hits[node]++
+	}
+	if hits["node2"] < 2*hits["node1"] {
+		t.Errorf("RandomNode not weighted by ownership, got %v\n", hits)
+	}
+}
+
+func TestGetNodeWithHash(t *testing.T) {
+	c := NewConsistent()
+	c.AddNodes([]string{"node1", "node2", "node3", "node4", "node5"})
+
+	for _, key := range []string{"Abc", "xxx", "1111234567", "okbnqeobla;d"} {
+		exp, _ := c.GetNode(key)
+		node, h, err := c.GetNodeWithHash(key)
+		if err != nil || node != exp || h != crc64h([]byte(key)) {
+			t.Errorf("GetNodeWithHash err: %v, exp: %v, got: %v %v\n", err, exp, node, h)
+		}
+		if byHash, _ := c.GetNodeByHash(h); byHash != node {
+			t.Errorf("GetNodeByHash of returned hash, exp: %v, got: %v\n", node, byHash)
+		}
+	}
+}
+
+func TestKeyNormalizer(t *testing.T) {
+	c := NewConsistentWithOptions(WithKeyNormalizer(FoldKey))
+	for i := 0; i < 5; i++ {
+		c.AddNode(fmt.Sprintf("node%d", i))
+	}
+	for _, key := range []string{"user", "Key-A", "abc"} {
+		exp, _ := c.GetNode(key)
+		for _, variant := range []string{strings.ToUpper(key), " " + key + " "} {
+			got, err := c.GetNode(variant)
+			if err != nil || got != exp {
+				t.Errorf("GetNode(%q) exp: %v, got %v, %v\n", variant, exp, got, err)
+			}
+			nodes, _ := c.GetNNode(variant, 2)
+			if len(nodes) != 2 || nodes[0] != exp {
+				t.Errorf("GetNNode(%q) exp first: %v, got %v\n", variant, exp, nodes)
+			}
+		}
+	}
+	c.PinKey("Pinned", "node3")
+	if got, _ := c.GetNode("PINNED"); got != "node3" {
+		t.Errorf("pinned exp: node3, got %v\n", got)
+	}
+	if got, _ := c.Snapshot().GetNode("pinned "); got != "node3" {
+		t.Errorf("snapshot pinned exp: node3, got %v\n", got)
+	}
+}
+
+func BenchmarkAddNodes(b *testing.B) {
+	b.ReportAllocs()
+	nodes := make([]string, 2000)
+	for i := range nodes {
+		nodes[i] = fmt.Sprintf("node%d", i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewConsistent().AddNodes(nodes)
+	}
+}
+
+func TestGetNodeAllocs(t *testing.T) {
+	c := NewConsistent()
+	c.AddNodes([]string{"node1", "node2", "node3"})
+	c.GetNode("warm up")
+	if allocs := testing.AllocsPerRun(100, func() { c.GetNode("xxx") }); allocs != 0 {
+		t.Errorf("GetNode allocs exp: 0, got %v\n", allocs)
+	}
+}
+
+func TestExpectedNodes(t *testing.T) {
+	c := NewConsistentWithOptions(WithExpectedNodes(10), WithReplicas(20))
+	r := c.ring.(*sliceRing)
+	if cap(r.points) != 200 {
+		t.Errorf("WithExpectedNodes ring capacity exp: 200, got %v\n", cap(r.points))
+	}
+	for i := 0; i < 10; i++ {
+		c.AddNode(fmt.Sprintf("node%d", i))
+	}
+	if cap(r.points) != 200 {
+		t.Errorf("adding expected nodes shouldn't grow ring, got capacity %v\n", cap(r.points))
+	}
+	c.Grow(5)
+	if cap(r.points) != 300 || r.Len() != 200 {
+		t.Errorf("Grow exp: len 200 cap 300, got len %v cap %v\n", r.Len(), cap(r.points))
+	}
+	if node, err := c.GetNode("xxx"); err != nil || !c.HasNode(node) {
+		t.Errorf("GetNode after Grow err: %v, got %v\n", err, node)
+	}
+}
+
+func TestGetNNodeAppend(t *testing.T) {
+	c := NewConsistent()
+	c.AddNodes([]string{"n1", "n2", "n3", "n4", "n5", "n6", "n7", "n8", "n9", "n10", "n11", "n12"})
+	c.PinKey("pinned", "n7")
+	dst := []string{"prefix"}
+	for _, key := range []string{"xxx", "abc", "pinned"} {
+		exp, _ := c.GetNNode(key, 5)
+		got, err := c.GetNNodeAppend(dst[:1], key, 5)
+		if err != nil || !reflect.DeepEqual(got[1:], exp) || got[0] != "prefix" {
+			t.Errorf("GetNNodeAppend(%v) err: %v, exp: prefix + %v, got %v\n", key, err, exp, got)
+		}
+		dst = got
+	}
+	if got, err := c.GetNNodeAppend(dst[:1], "xxx", 13); err != ErrNotEnoughNodes || len(got) != 1 {
+		t.Errorf("GetNNodeAppend exp: ErrNotEnoughNodes and dst, got %v, %v\n", got, err)
+	}
+	big := NewConsistentWithN(10)
+	for i := 0; i < 200; i++ {
+		big.AddNode(fmt.Sprintf("node%d", i))
+	}
+	treap := NewConsistentWithOptions(WithReplicas(10), WithPersistentRing())
+	for i := 0; i < 200; i++ {
+		treap.AddNode(fmt.Sprintf("node%d", i))
+	}
+	for i := 0; i < 100; i++ {
+		exp, _ := treap.GetNNode(fmt.Sprint(i), 70)
+		if got, _ := big.GetNNode(fmt.Sprint(i), 70); !reflect.DeepEqual(got, exp) {
+			t.Errorf("GetNNode with large node table exp: %v, got %v\n", exp, got)
+		}
+	}
+}
+
+func BenchmarkGetNNodeAppend(b *testing.B) {
+	b.ReportAllocs()
+	c := NewConsistent()
+	c.AddNodes([]string{"n1", "n2", "n3", "n4", "n5", "n6", "n7", "n8", "n9", "n10", "n11", "n12"})
+	dst := make([]string, 0, 5)
+	for i := 0; i < b.N; i++ {
+		dst, _ = c.GetNNodeAppend(dst[:0], "xxx", 5)
+	}
+}
+
+func BenchmarkRemoveNodes(b *testing.B) {
+	b.ReportAllocs()
+	nodes := make([]string, 1000)
+	for i := range nodes {
+		nodes[i] = fmt.Sprintf("node%d", i)
+	}
+	rack := nodes[:40]
+	c := NewConsistent()
+	c.AddNodes(nodes)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.RemoveNodes(rack)
+		b.StopTimer()
+		c.AddNodes(rack)
+		b.StartTimer()
+	}
+}
+
+func TestHashFuncFrom(t *testing.T) {
+	fn := HashFuncFrom(func() hash.Hash64 { return crc64.New(CRC64ECMA128Table) })
+	for _, key := range []string{"", "a", "xxx", "node1"} {
+		if exp, got := crc64h([]byte(key)), fn([]byte(key)); got != exp {
+			t.Errorf("HashFuncFrom(%q) exp: %x, got %x\n", key, exp, got)
+		}
+	}
+	h := fnv.New64a()
+	h.Write([]byte("abc"))
+	if got := fnvh([]byte("abc")); got != h.Sum64() {
+		t.Errorf("fnvh exp: %x, got %x\n", h.Sum64(), got)
+	}
+}
+
+func BenchmarkHashFuncFrom(b *testing.B) {
+	b.ReportAllocs()
+	fn := HashFuncFrom(fnv.New64a)
+	key := []byte("xxx")
+	for i := 0; i < b.N; i++ {
+		fn(key)
+	}
+}
@@ -0,0 +1,46 @@
+package consistent
+
+// Constraint tells whether candidate may join already chosen nodes in
+// GetNNodeWithConstraints
+type Constraint func(chosen []Node, candidate Node) bool
+
+// AntiAffinity rejects candidate having same value of label as any chosen
+// node, e.g. AntiAffinity("host") keeps replicas off the same machine.
+// Nodes without the label never conflict.
+func AntiAffinity(label string) Constraint {
+	return func(chosen []Node, candidate Node) bool {
+		v, ok := candidate.Meta[label]
+		if !ok {
+			return true
+		}
+		for _, n := range chosen {
+			if w, ok := n.Meta[label]; ok && w == v {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// GetNNodeWithConstraints is GetNNode walking past nodes which break any
+// of constraints given the nodes chosen so far. It fails with
+// ErrNotEnoughNodes when the ring is exhausted before n nodes are chosen.
+func (c *Consistent) GetNNodeWithConstraints(key string, n int, constraints ...Constraint) ([]string, error) {
+	c.rlock()
+	defer c.runlock()
+	if n > c.count {
+		return []string{}, ErrNotEnoughNodes
+	}
+	var chosen []Node
+	nodes := c.collectPinned([]byte(key), n, func(node string) bool {
+		info, _ := c.nodeInfo(node)
+		for _, fn := range constraints {
+			if !fn(chosen, info) {
+				return false
+			}
+		}
+		chosen = append(chosen, info)
+		return true
+	})
+	return enoughNodes(nodes, n)
+}
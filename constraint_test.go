@@ -0,0 +1,42 @@
+package consistent
+
+import "errors"
+import "fmt"
+import "reflect"
+import "testing"
+
+func TestGetNNodeWithConstraints(t *testing.T) {
+	c := NewConsistent()
+	for i, host := range []string{"h1", "h1", "h2", "h2", "h3", ""} {
+		meta := map[string]string{}
+		if host != "" {
+			meta["host"] = host
+		}
+		c.AddNodeInfo(Node{ID: fmt.Sprintf("node%v", i), Meta: meta})
+	}
+
+	exp, _ := c.GetNNode("xxx", 3)
+	if nodes, err := c.GetNNodeWithConstraints("xxx", 3); err != nil || !reflect.DeepEqual(nodes, exp) {
+		t.Errorf("GetNNodeWithConstraints without constraints err: %v, exp: %v, got: %v\n", err, exp, nodes)
+	}
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key%v", i)
+		nodes, err := c.GetNNodeWithConstraints(key, 4, AntiAffinity("host"))
+		if err != nil || len(nodes) != 4 {
+			t.Fatalf("GetNNodeWithConstraints err: %v, got: %v\n", err, nodes)
+		}
+		hosts := map[string]bool{}
+		for _, n := range nodes {
+			info, _ := c.NodeInfo(n)
+			if h, ok := info.Meta["host"]; ok && hosts[h] {
+				t.Errorf("Nodes share host %v: %v\n", h, nodes)
+			}
+			hosts[info.Meta["host"]] = true
+		}
+	}
+
+	if _, err := c.GetNNodeWithConstraints("xxx", 5, AntiAffinity("host")); !errors.Is(err, ErrNotEnoughNodes) {
+		t.Errorf("GetNNodeWithConstraints err, exp: %v, got: %v\n", ErrNotEnoughNodes, err)
+	}
+}
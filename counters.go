@@ -0,0 +1,72 @@
+package consistent
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// WithLookupCounters counts how often GetNode and GetNNode resolve to each
+// node, see LoadCounters. Counting is a lock-free atomic add per result.
+func WithLookupCounters() Option {
+	return func(c *Consistent) { c.counters = &lookupCounters{} }
+}
+
+// lookupCounters outlives published states, which share its counters
+type lookupCounters struct {
+	mu    sync.Mutex
+	count map[string]*uint64
+}
+
+// slots returns counters of nodes, created as needed, for read-only use
+// by a published state
+func (l *lookupCounters) slots(nodes []string) map[string]*uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.count == nil {
+		l.count = make(map[string]*uint64)
+	}
+	slots := make(map[string]*uint64, len(nodes))
+	for _, node := range nodes {
+		p, ok := l.count[node]
+		if !ok {
+			p = new(uint64)
+			l.count[node] = p
+		}
+		slots[node] = p
+	}
+	return slots
+}
+
+// LoadCounters returns lookups per node since creation or ResetCounters,
+// including nodes removed meanwhile. It's nil without WithLookupCounters.
+func (c *Consistent) LoadCounters() map[string]uint64 {
+	if c.counters == nil {
+		return nil
+	}
+	c.counters.mu.Lock()
+	defer c.counters.mu.Unlock()
+	counts := make(map[string]uint64, len(c.counters.count))
+	for node, p := range c.counters.count {
+		counts[node] = atomic.LoadUint64(p)
+	}
+	return counts
+}
+
+// ResetCounters zeroes lookup counters
+func (c *Consistent) ResetCounters() {
+	if c.counters == nil {
+		return
+	}
+	c.counters.mu.Lock()
+	defer c.counters.mu.Unlock()
+	for _, p := range c.counters.count {
+		atomic.StoreUint64(p, 0)
+	}
+}
+
+// counted adds a lookup of node, noop without WithLookupCounters
+func (s *RingSnapshot) counted(node string) {
+	if p := s.counts[node]; p != nil {
+		atomic.AddUint64(p, 1)
+	}
+}
@@ -0,0 +1,77 @@
+package consistent
+
+import "fmt"
+import "sync"
+import "testing"
+
+func TestLookupCounters(t *testing.T) {
+	if c := NewConsistent(); c.LoadCounters() != nil {
+		t.Errorf("LoadCounters without WithLookupCounters exp: nil, got %v\n", c.LoadCounters())
+	}
+
+	c := NewConsistentWithOptions(WithLookupCounters())
+	c.AddNodes([]string{"node1", "node2", "node3"})
+	exp := make(map[string]uint64)
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key%d", i)
+		node, _ := c.GetNode(key)
+		exp[node]++
+		nodes, _ := c.GetNNode(key, 2)
+		for _, node := range nodes {
+			exp[node]++
+		}
+	}
+	got := c.LoadCounters()
+	for node, n := range exp {
+		if got[node] != n {
+			t.Errorf("%v lookups exp: %v, got %v\n", node, n, got[node])
+		}
+	}
+
+	c.RemoveNode("node1")
+	c.GetNode("key0")
+	c.ResetCounters()
+	for node, n := range c.LoadCounters() {
+		if n != 0 {
+			t.Errorf("%v after ResetCounters exp: 0, got %v\n", node, n)
+		}
+	}
+	node, _ := c.GetNode("key0")
+	if got := c.LoadCounters()[node]; got != 1 {
+		t.Errorf("%v after reset exp: 1, got %v\n", node, got)
+	}
+}
+
+func TestLookupCountersConcurrent(t *testing.T) {
+	c := NewConsistentWithOptions(WithLookupCounters())
+	c.AddNodes([]string{"node1", "node2", "node3"})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				c.GetNode(fmt.Sprint(j))
+			}
+		}()
+	}
+	wg.Wait()
+	var total uint64
+	for _, n := range c.LoadCounters() {
+		total += n
+	}
+	if total != 4000 {
+		t.Errorf("total lookups exp: 4000, got %v\n", total)
+	}
+}
+
+func BenchmarkGetNodeCounters(b *testing.B) {
+	c := NewConsistentWithOptions(WithLookupCounters())
+	c.AddNodes([]string{"node1", "node2", "node3"})
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c.GetNode("xxx")
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package consistent
+
+import "time"
+
+// WithDebounce coalesces AddNode and RemoveNode calls less than window
+// apart. GetNode family, HasNode, Members and NodeNumber keep serving
+// ring from before the burst until window passes without changes, then
+// changes are published at once and settled, if not nil, is called.
+// Other reads and writes apply staged changes right away.
+func WithDebounce(window time.Duration, settled func()) Option {
+	return func(c *Consistent) {
+		c.debounce = window
+		c.settled = settled
+	}
+}
+
+// unlockStaged releases lock of lockStaged, deferring publication of
+// change when debouncing
+func (c *Consistent) unlockStaged() {
+	if c.debounce <= 0 {
+		c.unlock()
+		return
+	}
+	c.changed = time.Now()
+	if c.settling == nil {
+		c.settling = time.AfterFunc(c.debounce, c.settle)
+	}
+	c.mu.Unlock()
+}
+
+// settle publishes debounced changes once window passed since last one
+func (c *Consistent) settle() {
+	c.mu.Lock()
+	if wait := c.debounce - time.Since(c.changed); wait > 0 {
+		c.settling = time.AfterFunc(wait, c.settle)
+		c.mu.Unlock()
+		return
+	}
+	c.settling = nil
+	c.commit()
+	c.unlock()
+	if c.settled != nil {
+		c.settled()
+	}
+}
@@ -0,0 +1,36 @@
+package consistent
+
+import "fmt"
+import "testing"
+import "time"
+
+func TestDebounce(t *testing.T) {
+	settled := make(chan struct{}, 10)
+	c := NewConsistentWithOptions(WithDebounce(50*time.Millisecond, func() { settled <- struct{}{} }))
+	c.AddNode("node0")
+	<-settled
+	if !c.HasNode("node0") {
+		t.Errorf("settled change should be published\n")
+	}
+
+	for i := 1; i < 10; i++ {
+		c.AddNode(fmt.Sprintf("node%d", i))
+		time.Sleep(5 * time.Millisecond)
+	}
+	c.RemoveNode("node0")
+	if c.NodeNumber() != 1 || !c.HasNode("node0") {
+		t.Errorf("burst should not be published yet, got %v nodes\n", c.NodeNumber())
+	}
+	if node, _ := c.GetNode("xxx"); node != "node0" {
+		t.Errorf("GetNode should serve ring before burst, got %v\n", node)
+	}
+	<-settled
+	if c.NodeNumber() != 9 || c.HasNode("node0") {
+		t.Errorf("burst should be published, got %v nodes\n", c.NodeNumber())
+	}
+	select {
+	case <-settled:
+		t.Errorf("burst should settle once\n")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,87 @@
+package consistent
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// debugChanges is number of recent changes shown by Handler
+const debugChanges = 20
+
+// RingStatus is state of consistent rendered by Handler
+type RingStatus struct {
+	Fingerprint string       `json:"fingerprint"`
+	Hash        string       `json:"hash,omitempty"` // empty if custom
+	Replicas    int          `json:"replicas"`
+	Points      int          `json:"points"`
+	Nodes       []NodeStatus `json:"nodes"`
+	Changes     []AuditEntry `json:"changes,omitempty"` // only WithAudit
+}
+
+// NodeStatus is node of RingStatus
+type NodeStatus struct {
+	Node   string  `json:"node"`
+	VNodes int     `json:"vnodes"`
+	Share  float64 `json:"share"` // fraction of keyspace
+	Zone   string  `json:"zone,omitempty"`
+}
+
+// Status returns members with their weights and ownership shares in name
+// order, fingerprint and recent changes of consistent
+func (c *Consistent) Status() RingStatus {
+	c.rlock()
+	defer c.runlock()
+	s := RingStatus{
+		Fingerprint: strconv.FormatUint(c.fingerprint(), 16),
+		Hash:        c.hashName,
+		Replicas:    c.replicas,
+		Points:      c.ring.Len(),
+		Nodes:       make([]NodeStatus, 0, len(c.node)),
+	}
+	shares := arcShares(c.ring)
+	for node, vnodes := range c.node {
+		s.Nodes = append(s.Nodes, NodeStatus{node, vnodes, shares[node], c.zone(node)})
+	}
+	sort.Slice(s.Nodes, func(i, j int) bool { return s.Nodes[i].Node < s.Nodes[j].Node })
+	if c.audit != nil {
+		s.Changes = c.history(debugChanges)
+	}
+	return s
+}
+
+var debugPage = template.Must(template.New("ring").Funcs(template.FuncMap{
+	"percent": func(f float64) float64 { return 100 * f },
+}).Parse(`<!DOCTYPE html>
+<html><head><title>ring</title></head><body>
+<h1>ring {{.Fingerprint}}</h1>
+<p>hash {{or .Hash "custom"}}, {{.Replicas}} replicas, {{.Points}} points</p>
+<table>
+<tr><th>node</th><th>vnodes</th><th>share</th><th>zone</th></tr>
+{{range .Nodes}}<tr><td>{{.Node}}</td><td>{{.VNodes}}</td><td>{{printf "%.2f%%" (percent .Share)}}</td><td>{{.Zone}}</td></tr>
+{{end}}</table>
+{{if .Changes}}<h2>recent changes</h2>
+<table>
+<tr><th>time</th><th>op</th><th>node</th><th>vnodes</th><th>caller</th><th>fingerprint</th></tr>
+{{range .Changes}}<tr><td>{{.Time.Format "2006-01-02T15:04:05.000Z07:00"}}</td><td>{{.Op}}</td><td>{{.Node}}</td><td>{{.VNodes}}</td><td>{{.Caller}}</td><td>{{printf "%x" .After}}</td></tr>
+{{end}}</table>{{end}}
+</body></html>
+`))
+
+// Handler serves Status as HTML page, or as JSON if requested by Accept
+// header or format=json query, e.g. to mount under /debug/ring
+func (c *Consistent) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s := c.Status()
+		if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(s)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		debugPage.Execute(w, s)
+	})
+}
@@ -0,0 +1,38 @@
+package consistent
+
+import "encoding/json"
+import "net/http/httptest"
+import "strings"
+import "testing"
+
+func TestHandler(t *testing.T) {
+	c := NewConsistentWithOptions(WithAudit(10))
+	c.AddNodeInfo(Node{ID: "node1", Meta: map[string]string{ZoneLabel: "z1"}})
+	c.AddNode("node2")
+	h := c.Handler()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/debug/ring?format=json", nil))
+	var s RingStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &s); err != nil {
+		t.Fatalf("json status %v: %s\n", err, w.Body)
+	}
+	if len(s.Nodes) != 2 || s.Nodes[0].Node != "node1" || s.Nodes[0].Zone != "z1" || s.Nodes[1].VNodes != DefaultReplica {
+		t.Errorf("json nodes got %+v\n", s.Nodes)
+	}
+	if s.Hash != "crc64" || s.Points != 2*DefaultReplica || len(s.Changes) != 2 || s.Fingerprint == "" {
+		t.Errorf("json status got %+v\n", s)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/debug/ring", nil))
+	body := w.Body.String()
+	for _, exp := range []string{"<td>node1</td>", "<td>z1</td>", "ring " + s.Fingerprint, "recent changes"} {
+		if !strings.Contains(body, exp) {
+			t.Errorf("html exp: %v, got %v\n", exp, body)
+		}
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("content type exp: text/html, got %v\n", ct)
+	}
+}
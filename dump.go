@@ -0,0 +1,95 @@
+package consistent
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// arcShares returns fraction of hash space owned by each node
+func arcShares(r ring) map[string]float64 {
+	shares := map[string]float64{}
+	l := r.Len()
+	if l == 1 {
+		shares[r.Owner(0)] = 1
+		return shares
+	}
+	for i := 0; i < l; i++ {
+		// wraps around zero for the first point
+		arc := r.Hash(i) - r.Hash((i+l-1)%l)
+		shares[r.Owner(i)] += float64(arc) / math.MaxUint64
+	}
+	return shares
+}
+
+// Dump writes ring summary and one line per node in name order, with
+// virtual nodes, points on the ring and share of keyspace. The format is
+// stable, so dumps can be diffed.
+func (c *Consistent) Dump(w io.Writer) error {
+	c.rlock()
+	defer c.runlock()
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "replicas=%d nodes=%d points=%d\n", c.replicas, c.count, c.ring.Len())
+	nodes := make([]string, 0, len(c.node))
+	for node := range c.node {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	shares := arcShares(c.ring)
+	for _, node := range nodes {
+		vnodes := c.node[node]
+		fmt.Fprintf(&buf, "%s weight=%.2f vnodes=%d points=%d share=%.2f%%\n",
+			node, float64(vnodes)/float64(c.replicas), vnodes, vnodes-c.dropped[node], shares[node]*100)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// String returns Dump of consistent
+func (c *Consistent) String() string {
+	var buf bytes.Buffer
+	c.Dump(&buf)
+	return buf.String()
+}
+
+// NodeOwnership is node's row of OwnershipReport
+type NodeOwnership struct {
+	Node    string  `json:"node"`
+	VNodes  int     `json:"vnodes"`
+	Points  int     `json:"points"`  // vnodes not lost to collisions
+	Arc     uint64  `json:"arc"`     // hash space owned
+	Percent float64 `json:"percent"` // of hash space
+	Zone    string  `json:"zone,omitempty"`
+}
+
+// OwnershipReport returns ownership of nodes in name order. Arcs are
+// summed exactly, so the report is stable for golden tests.
+func (c *Consistent) OwnershipReport() []NodeOwnership {
+	c.rlock()
+	defer c.runlock()
+	arcs := map[string]uint64{}
+	l := c.ring.Len()
+	for i := 0; i < l; i++ {
+		if c.count == 1 {
+			// whole space doesn't fit in uint64
+			arcs[c.ring.Owner(0)] = math.MaxUint64
+			break
+		}
+		arcs[c.ring.Owner(i)] += c.ring.Hash(i) - c.ring.Hash((i+l-1)%l)
+	}
+	report := make([]NodeOwnership, 0, len(c.node))
+	for node, vnodes := range c.node {
+		report = append(report, NodeOwnership{
+			Node:    node,
+			VNodes:  vnodes,
+			Points:  vnodes - c.dropped[node],
+			Arc:     arcs[node],
+			Percent: float64(arcs[node]) / math.MaxUint64 * 100,
+			Zone:    c.zone(node),
+		})
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].Node < report[j].Node })
+	return report
+}
@@ -0,0 +1,62 @@
+package consistent
+
+import "math"
+import "strings"
+import "testing"
+
+func TestDump(t *testing.T) {
+	c := NewConsistentWithN(10)
+	c.AddNode("node2")
+	c.AddWeightedNode("node1", 3)
+
+	lines := strings.Split(strings.TrimSpace(c.String()), "\n")
+	if len(lines) != 3 || lines[0] != "replicas=10 nodes=2 points=40" {
+		t.Fatalf("Wrong dump header, got %q\n", lines)
+	}
+	if !strings.HasPrefix(lines[1], "node1 weight=3.00 vnodes=30 points=30 share=") ||
+		!strings.HasPrefix(lines[2], "node2 weight=1.00 vnodes=10 points=10 share=") {
+		t.Errorf("Wrong dump lines, got %q\n", lines[1:])
+	}
+	if c.String() != c.String() {
+		t.Errorf("Dump is not stable\n")
+	}
+
+	var total float64
+	for _, s := range arcShares(c.ring) {
+		total += s
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("Shares should add up to 1, got %v\n", total)
+	}
+}
+
+func TestOwnershipReport(t *testing.T) {
+	c := NewConsistentWithN(10)
+	if got := c.OwnershipReport(); len(got) != 0 {
+		t.Errorf("OwnershipReport of empty ring exp: none, got %v\n", got)
+	}
+	c.AddNode("node1")
+	if got := c.OwnershipReport(); len(got) != 1 || got[0].Arc != math.MaxUint64 || got[0].Percent != 100 {
+		t.Errorf("OwnershipReport of single node exp: whole ring, got %+v\n", got)
+	}
+
+	c.AddNodeInfo(Node{ID: "node2", Meta: map[string]string{ZoneLabel: "z1"}})
+	c.AddWeightedNode("node0", 2)
+	report := c.OwnershipReport()
+	var arc uint64
+	var percent float64
+	for i, n := range report {
+		if exp := []string{"node0", "node1", "node2"}[i]; n.Node != exp {
+			t.Errorf("report row %v exp: %v, got %v\n", i, exp, n.Node)
+		}
+		arc += n.Arc
+		percent += n.Percent
+	}
+	// arcs sum to 2^64, which wraps to 0
+	if arc != 0 || math.Abs(percent-100) > 1e-9 {
+		t.Errorf("arcs exp: whole ring, got %v %v%%\n", arc, percent)
+	}
+	if report[0].VNodes != 20 || report[0].Points != 20 || report[2].Zone != "z1" {
+		t.Errorf("report got %+v\n", report)
+	}
+}
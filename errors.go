@@ -0,0 +1,20 @@
+package consistent
+
+import "errors"
+
+// Errors returned by consistent, compare them with errors.Is
+var (
+	ErrNoNodes         = errors.New("consistent: no nodes")
+	ErrNotEnoughNodes  = errors.New("consistent: query N is greater than total nodes")
+	ErrNodeExists      = errors.New("consistent: node already exists")
+	ErrNodeNotFound    = errors.New("consistent: node not found")
+	ErrInvalidReplicas = errors.New("consistent: replicas must be positive")
+	ErrInvalidCapacity = errors.New("consistent: capacity must be positive")
+	ErrNilHashFunc     = errors.New("consistent: hash function is nil")
+	ErrUnknownHash     = errors.New("consistent: unknown hash algorithm")
+	ErrInvalidConfig   = errors.New("consistent: invalid config")
+	ErrBadVersion      = errors.New("consistent: unsupported format version")
+	ErrInvalidData     = errors.New("consistent: invalid encoded data")
+	ErrChecksum        = errors.New("consistent: checksum mismatch")
+	ErrPlacement       = errors.New("consistent: points not placed like this consistent places them")
+)
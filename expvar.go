@@ -0,0 +1,35 @@
+package consistent
+
+import "expvar"
+
+// ExpvarStats is value of consistent published by PublishExpvar
+type ExpvarStats struct {
+	Nodes    int                `json:"nodes"`
+	Points   int                `json:"points"`
+	Members  []string           `json:"members"`
+	Shares   map[string]float64 `json:"shares"`
+	Lookups  map[string]uint64  `json:"lookups,omitempty"`
+	Rebuilds uint64             `json:"rebuilds"`
+	Rebuild  float64            `json:"rebuild_seconds"` // total time of rebuilds
+}
+
+// PublishExpvar exposes membership, ownership shares and counters of
+// consistent under name in /debug/vars. Values are computed from published
+// state on each read. Like expvar.Publish, it panics if name is taken.
+func (c *Consistent) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} { return c.expvarStats() }))
+}
+
+func (c *Consistent) expvarStats() ExpvarStats {
+	s := c.current()
+	count, total := c.rebuilds.load()
+	return ExpvarStats{
+		Nodes:    s.count,
+		Points:   s.ring.Len(),
+		Members:  s.members,
+		Shares:   arcShares(s.ring),
+		Lookups:  c.LoadCounters(),
+		Rebuilds: count,
+		Rebuild:  total.Seconds(),
+	}
+}
@@ -0,0 +1,38 @@
+package consistent
+
+import "encoding/json"
+import "expvar"
+import "fmt"
+import "sync/atomic"
+import "testing"
+
+var expvarRuns int64
+
+func TestPublishExpvar(t *testing.T) {
+	c := NewConsistentWithOptions(WithLookupCounters())
+	c.AddNodes([]string{"node1", "node2"})
+	c.GetNode("xxx")
+	// expvar names can't be reused, so -count runs need fresh ones
+	name := fmt.Sprintf("test_ring_%d", atomic.AddInt64(&expvarRuns, 1))
+	c.PublishExpvar(name)
+
+	v := expvar.Get(name)
+	if v == nil {
+		t.Fatalf("PublishExpvar didn't publish %v\n", name)
+	}
+	var stats ExpvarStats
+	if err := json.Unmarshal([]byte(v.String()), &stats); err != nil {
+		t.Fatalf("expvar json %v\n", err)
+	}
+	if stats.Nodes != 2 || stats.Points != 2*DefaultReplica || len(stats.Members) != 2 {
+		t.Errorf("expvar exp: 2 nodes %v points, got %+v\n", 2*DefaultReplica, stats)
+	}
+	if len(stats.Shares) != 2 || stats.Lookups["node1"]+stats.Lookups["node2"] != 1 {
+		t.Errorf("expvar shares and lookups got %v %v\n", stats.Shares, stats.Lookups)
+	}
+
+	c.AddNode("node3")
+	if err := json.Unmarshal([]byte(v.String()), &stats); err != nil || stats.Nodes != 3 {
+		t.Errorf("expvar after AddNode exp: 3 nodes, got %v %v\n", stats.Nodes, err)
+	}
+}
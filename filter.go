@@ -0,0 +1,25 @@
+package consistent
+
+// GetNodeFiltered is GetNode walking past nodes rejected by allow, e.g.
+// nodes marked unhealthy, without mutating the ring. It fails with
+// ErrNoNodes when allow rejects all nodes. allow is called with read lock
+// held, so it must not call consistent.
+func (c *Consistent) GetNodeFiltered(key string, allow func(node string) bool) (string, error) {
+	nodes, err := c.GetNNodeFiltered(key, 1, allow)
+	if err != nil {
+		return "", ErrNoNodes
+	}
+	return nodes[0], nil
+}
+
+// GetNNodeFiltered is GetNNode walking past nodes rejected by allow, so
+// rejected owners are replaced by next nodes on the ring in order. It
+// fails with ErrNotEnoughNodes when less than n nodes are allowed.
+func (c *Consistent) GetNNodeFiltered(key string, n int, allow func(node string) bool) ([]string, error) {
+	c.rlock()
+	defer c.runlock()
+	if n > c.count {
+		return []string{}, ErrNotEnoughNodes
+	}
+	return enoughNodes(c.collectPinned([]byte(key), n, allow), n)
+}
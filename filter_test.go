@@ -0,0 +1,27 @@
+package consistent
+
+import "errors"
+import "reflect"
+import "testing"
+
+func TestGetNodeFiltered(t *testing.T) {
+	c := NewConsistent()
+	c.AddNodes([]string{"node1", "node2", "node3", "node4", "node5"})
+	all, _ := c.GetNNode("xxx", 5)
+	down := map[string]bool{all[0]: true, all[2]: true}
+	allow := func(node string) bool { return !down[node] }
+
+	if node, err := c.GetNodeFiltered("xxx", allow); err != nil || node != all[1] {
+		t.Errorf("GetNodeFiltered err: %v, exp: %v, got: %v\n", err, all[1], node)
+	}
+	exp := []string{all[1], all[3], all[4]}
+	if nodes, err := c.GetNNodeFiltered("xxx", 3, allow); err != nil || !reflect.DeepEqual(nodes, exp) {
+		t.Errorf("GetNNodeFiltered err: %v, exp: %v, got: %v\n", err, exp, nodes)
+	}
+	if _, err := c.GetNNodeFiltered("xxx", 4, allow); !errors.Is(err, ErrNotEnoughNodes) {
+		t.Errorf("GetNNodeFiltered err, exp: %v, got: %v\n", ErrNotEnoughNodes, err)
+	}
+	if _, err := c.GetNodeFiltered("xxx", func(string) bool { return false }); !errors.Is(err, ErrNoNodes) {
+		t.Errorf("GetNodeFiltered err, exp: %v, got: %v\n", ErrNoNodes, err)
+	}
+}
@@ -0,0 +1,49 @@
+package consistent
+
+import "strconv"
+
+// Fingerprint hashes nodes with their virtual node numbers, replicas, hash
+// algorithm and seed, so processes can cheaply check they built the same
+// ring. It doesn't depend on order nodes were added in, and is stable
+// across versions. Custom hash functions, placements and pins aren't
+// covered, all custom hash functions look the same.
+func (c *Consistent) Fingerprint() uint64 {
+	c.rlock()
+	defer c.runlock()
+	return c.fingerprint()
+}
+
+// fingerprint is Fingerprint, caller must hold lock. Node terms are summed
+// to be independent of map order.
+func (c *Consistent) fingerprint() uint64 {
+	return mix64(c.settingsTerm() ^ c.nodesTerm())
+}
+
+// nodesTerm is sum of nodeTerm of all nodes
+func (c *Consistent) nodesTerm() uint64 {
+	var sum uint64
+	buf := make([]byte, 0, 64)
+	for node, vnodes := range c.node {
+		sum += nodeTerm(buf, node, vnodes)
+	}
+	return sum
+}
+
+// nodeTerm is fingerprint term of node, buf is scratch space
+func nodeTerm(buf []byte, node string, vnodes int) uint64 {
+	buf = append(buf[:0], node...)
+	buf = append(buf, 0)
+	buf = strconv.AppendInt(buf, int64(vnodes), 10)
+	return mix64(XXHash64(buf))
+}
+
+// settingsTerm is fingerprint term of replicas, hash and seed
+func (c *Consistent) settingsTerm() uint64 {
+	buf := make([]byte, 0, 64)
+	buf = strconv.AppendInt(buf, int64(c.replicas), 10)
+	buf = append(buf, 0)
+	buf = append(buf, c.hashName...)
+	buf = append(buf, 0)
+	buf = strconv.AppendUint(buf, c.seed, 10)
+	return XXHash64(buf)
+}
@@ -0,0 +1,39 @@
+package consistent
+
+import "testing"
+
+func TestFingerprint(t *testing.T) {
+	build := func(nodes []string, opts ...Option) *Consistent {
+		c := NewConsistentWithOptions(opts...)
+		for _, node := range nodes {
+			c.AddNode(node)
+		}
+		return c
+	}
+	base := build([]string{"node1", "node2", "node3"}).Fingerprint()
+	if got := build([]string{"node3", "node1", "node2"}).Fingerprint(); got != base {
+		t.Errorf("fingerprint of reordered nodes exp: %x, got %x\n", base, got)
+	}
+	// golden value, changing it breaks mixed version deployments
+	if base != 0x3d63ac30cb8afdf9 {
+		t.Errorf("fingerprint exp: %x, got %x\n", 0x3d63ac30cb8afdf9, base)
+	}
+
+	weighted := build([]string{"node1", "node2"})
+	weighted.AddWeightedNode("node3", 2)
+	tests := []struct {
+		name string
+		c    *Consistent
+	}{
+		{"node", build([]string{"node1", "node2"})},
+		{"weight", weighted},
+		{"replicas", build([]string{"node1", "node2", "node3"}, WithReplicas(50))},
+		{"hash", build([]string{"node1", "node2", "node3"}, WithHashFunc(XXHash64))},
+		{"seed", build([]string{"node1", "node2", "node3"}, WithSeed(1))},
+	}
+	for _, tt := range tests {
+		if got := tt.c.Fingerprint(); got == base {
+			t.Errorf("fingerprint with other %v exp: not %x, got %x\n", tt.name, base, got)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+//go:build go1.18
+
+package consistent
+
+import "sync"
+
+// KeyFunc returns identity of a node value, which is hashed onto the ring
+type KeyFunc[T any] func(T) []byte
+
+// ConsistentOf is consistent storing rich node values, e.g. structs with
+// address and TLS config, and returning them from lookups directly
+type ConsistentOf[T any] struct {
+	mu    sync.RWMutex
+	c     *Consistent
+	key   KeyFunc[T]
+	nodes map[string]T
+}
+
+// NewConsistentOf returns ConsistentOf identifying nodes by key and
+// configured by given options
+func NewConsistentOf[T any](key KeyFunc[T], opts ...Option) *ConsistentOf[T] {
+	return &ConsistentOf[T]{
+		c:     NewConsistentWithOptions(opts...),
+		key:   key,
+		nodes: make(map[string]T),
+	}
+}
+
+// AddNode to consistent, fails with ErrNodeExists on existing node
+func (g *ConsistentOf[T]) AddNode(node T) error {
+	return g.AddWeightedNode(node, 1)
+}
+
+// AddWeightedNode adds node with replica number scaled by weight
+func (g *ConsistentOf[T]) AddWeightedNode(node T, weight int) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	id := string(g.key(node))
+	if err := g.c.AddWeightedNode(id, weight); err != nil {
+		return err
+	}
+	g.nodes[id] = node
+	return nil
+}
+
+// RemoveNode from consistent
+func (g *ConsistentOf[T]) RemoveNode(node T) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	id := string(g.key(node))
+	if err := g.c.RemoveNode(id); err != nil {
+		return err
+	}
+	delete(g.nodes, id)
+	return nil
+}
+
+// GetNode returns first found node
+func (g *ConsistentOf[T]) GetNode(key string) (T, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	id, err := g.c.GetNode(key)
+	return g.nodes[id], err
+}
+
+// GetNNode returns found distinct nodes with given n
+func (g *ConsistentOf[T]) GetNNode(key string, n int) ([]T, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	ids, err := g.c.GetNNode(key, n)
+	if err != nil {
+		return []T{}, err
+	}
+	nodes := make([]T, len(ids))
+	for i, id := range ids {
+		nodes[i] = g.nodes[id]
+	}
+	return nodes, nil
+}
+
+// HasNode tests exsiting node
+func (g *ConsistentOf[T]) HasNode(node T) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	_, ok := g.nodes[string(g.key(node))]
+	return ok
+}
+
+// Members returns nodes sorted by their key
+func (g *ConsistentOf[T]) Members() []T {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	ids := g.c.Members()
+	nodes := make([]T, len(ids))
+	for i, id := range ids {
+		nodes[i] = g.nodes[id]
+	}
+	return nodes
+}
+
+// NodeNumber return currently physical node number
+func (g *ConsistentOf[T]) NodeNumber() int {
+	return g.c.NodeNumber()
+}
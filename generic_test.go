@@ -0,0 +1,40 @@
+//go:build go1.18
+
+package consistent
+
+import "testing"
+
+type testServer struct {
+	Addr string
+	Port int
+}
+
+func TestConsistentOf(t *testing.T) {
+	c := NewConsistentOf(func(s testServer) []byte { return []byte(s.Addr) })
+	plain := NewConsistent()
+	for _, addr := range []string{"node1", "node2", "node3", "node4", "node5"} {
+		c.AddNode(testServer{Addr: addr, Port: 8080})
+		plain.AddNode(addr)
+	}
+
+	for _, key := range []string{"Abc", "xxx", "1111234567", "okbnqeobla;d"} {
+		exp, _ := plain.GetNode(key)
+		if s, err := c.GetNode(key); err != nil || s.Addr != exp || s.Port != 8080 {
+			t.Errorf("GetNode err: %v, exp: %v, got: %v\n", err, exp, s)
+		}
+	}
+
+	c.RemoveNode(testServer{Addr: "node1"})
+	if c.HasNode(testServer{Addr: "node1"}) || c.NodeNumber() != 4 {
+		t.Errorf("RemoveNode didn't remove node1\n")
+	}
+	if nodes, err := c.GetNNode("xxx", 4); err != nil || len(nodes) != 4 || nodes[0].Port != 8080 {
+		t.Errorf("GetNNode err: %v, got: %v\n", err, nodes)
+	}
+	if m := c.Members(); len(m) != 4 || m[0].Addr != "node2" {
+		t.Errorf("Wrong Members(), got %v\n", m)
+	}
+	if _, err := c.GetNNode("xxx", 5); err == nil {
+		t.Errorf("GetNNode should fail when n is greater than total nodes\n")
+	}
+}
@@ -0,0 +1,66 @@
+package consistent
+
+import "bytes"
+import "encoding/gob"
+import "errors"
+import "testing"
+
+func init() {
+	RegisterHash("test/murmur3+1", func(key []byte) uint64 { return Murmur3(key) + 1 })
+}
+
+func TestGob(t *testing.T) {
+	type placement struct {
+		Name string
+		Ring *Consistent
+	}
+	c := NewConsistentWithOptions(WithReplicas(20), WithHashName("test/murmur3+1"))
+	c.AddNodes([]string{"node1", "node2", "node3"})
+	c.ReplaceNode("node1", "node4")
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(placement{"cache", c}); err != nil {
+		t.Fatalf("gob encode %v\n", err)
+	}
+	var got placement
+	if err := gob.NewDecoder(&buf).Decode(&got); err != nil {
+		t.Fatalf("gob decode %v\n", err)
+	}
+	if got.Name != "cache" || got.Ring.Config().Hash != "test/murmur3+1" || got.Ring.Fingerprint() != c.Fingerprint() {
+		t.Errorf("gob decoded exp: %+v, got %v %+v\n", c.Config(), got.Name, got.Ring.Config())
+	}
+	sameLookups(t, c, got.Ring)
+	// new nodes hash the same after decoding
+	c.AddNode("node5")
+	got.Ring.AddNode("node5")
+	sameLookups(t, c, got.Ring)
+}
+
+func TestRegisterHash(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   HashFunc
+		err  bool
+	}{
+		{"crc64", Murmur3, true},
+		{"test/murmur3+1", Murmur3, true},
+		{"test/nil", nil, true},
+		{"test/wyhash", WyHash, false},
+	}
+	defer func() {
+		registeredMu.Lock()
+		delete(registered, "test/wyhash")
+		registeredMu.Unlock()
+	}()
+	for _, tt := range tests {
+		if err := RegisterHash(tt.name, tt.fn); (err != nil) != tt.err {
+			t.Errorf("RegisterHash(%v) exp error: %v, got %v\n", tt.name, tt.err, err)
+		}
+	}
+	defer func() {
+		if err, _ := recover().(error); !errors.Is(err, ErrUnknownHash) {
+			t.Errorf("WithHashName of unknown hash exp panic: %v, got %v\n", ErrUnknownHash, err)
+		}
+	}()
+	WithHashName("test/unknown")
+}
@@ -0,0 +1,87 @@
+package consistent
+
+import (
+	"sort"
+	"time"
+)
+
+// hashFuncs are built-in hash algorithms by name
+var hashFuncs = map[string]HashFunc{
+	"crc64":    crc64h,
+	"fnv64a":   fnvh,
+	"xxhash64": XXHash64,
+	"murmur3":  Murmur3,
+	"wyhash":   WyHash,
+}
+
+// HashBenchResult is evaluation of one hash algorithm by HashBench
+type HashBenchResult struct {
+	Name       string
+	NsPerKey   float64 // time to hash one key of corpus
+	PeakToMean float64 // keys of busiest node over average, 1 is perfect
+}
+
+// hashBenchTolerance is how much worse than best peak-to-mean a hash may
+// balance and still win by speed
+const hashBenchTolerance = 1.05
+
+// HashBench evaluates built-in hash algorithms on sample keys spread over
+// nodes with replicas virtual nodes each. Results are best first: hashes
+// balancing within 5% of the best peak-to-mean, fastest first, then the
+// rest by balance.
+func HashBench(keys, nodes []string, replicas int) []HashBenchResult {
+	results := make([]HashBenchResult, 0, len(hashFuncs))
+	for name, fn := range hashFuncs {
+		results = append(results, hashBench(name, fn, keys, nodes, replicas))
+	}
+	best := 0.0
+	for i, r := range results {
+		if i == 0 || r.PeakToMean < best {
+			best = r.PeakToMean
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		aok, bok := a.PeakToMean <= best*hashBenchTolerance, b.PeakToMean <= best*hashBenchTolerance
+		switch {
+		case aok != bok:
+			return aok
+		case aok && a.NsPerKey != b.NsPerKey:
+			return a.NsPerKey < b.NsPerKey
+		case a.PeakToMean != b.PeakToMean:
+			return a.PeakToMean < b.PeakToMean
+		}
+		return a.Name < b.Name
+	})
+	return results
+}
+
+func hashBench(name string, fn HashFunc, keys, nodes []string, replicas int) HashBenchResult {
+	r := HashBenchResult{Name: name}
+	if len(keys) == 0 || len(nodes) == 0 {
+		return r
+	}
+	bs := make([][]byte, len(keys))
+	for i, k := range keys {
+		bs[i] = []byte(k)
+	}
+	start := time.Now()
+	for _, b := range bs {
+		fn(b)
+	}
+	r.NsPerKey = float64(time.Since(start).Nanoseconds()) / float64(len(keys))
+
+	c := NewConsistentWithHash(replicas, fn)
+	c.AddNodes(nodes)
+	counts := make(map[string]int, len(nodes))
+	peak := 0
+	for _, k := range keys {
+		node, _ := c.GetNode(k)
+		counts[node]++
+		if counts[node] > peak {
+			peak = counts[node]
+		}
+	}
+	r.PeakToMean = float64(peak) * float64(len(nodes)) / float64(len(keys))
+	return r
+}
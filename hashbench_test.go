@@ -0,0 +1,31 @@
+package consistent
+
+import "fmt"
+import "testing"
+
+func TestHashBench(t *testing.T) {
+	keys := make([]string, 5000)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("user:%d", i)
+	}
+	nodes := []string{"node1", "node2", "node3", "node4", "node5"}
+	results := HashBench(keys, nodes, DefaultReplica)
+	if len(results) != len(hashFuncs) {
+		t.Fatalf("HashBench exp: %v results, got %v\n", len(hashFuncs), len(results))
+	}
+	best := results[0].PeakToMean
+	for _, r := range results {
+		if r.PeakToMean < 1 || r.NsPerKey <= 0 {
+			t.Errorf("%v got invalid result %+v\n", r.Name, r)
+		}
+		if r.PeakToMean < best {
+			best = r.PeakToMean
+		}
+	}
+	if results[0].PeakToMean > best*hashBenchTolerance {
+		t.Errorf("best candidate %+v should balance within tolerance of %v\n", results[0], best)
+	}
+	if r := HashBench(nil, nodes, DefaultReplica); len(r) != len(hashFuncs) || r[0].PeakToMean != 0 {
+		t.Errorf("HashBench of no keys exp zero results, got %+v\n", r)
+	}
+}
@@ -0,0 +1,50 @@
+package consistent
+
+import "sync"
+
+type healthTracker struct {
+	mu    sync.RWMutex
+	score map[string]float64
+}
+
+// ReportHealth sets score of node used by GetBestOfN, lower is healthier.
+// Score is whatever callers track, typically EWMA of latency or error
+// rate, and replaces earlier one.
+func (c *Consistent) ReportHealth(node string, score float64) {
+	c.health.mu.Lock()
+	defer c.health.mu.Unlock()
+	if c.health.score == nil {
+		c.health.score = make(map[string]float64)
+	}
+	c.health.score[node] = score
+}
+
+// GetBestOfN returns healthiest of first n distinct owners of key, by
+// ReportHealth scores. Ties, including nodes not reported yet which score
+// 0, go to the owner coming first. n is capped at number of nodes, so
+// degraded owners are avoided while keys still stick to few nodes.
+func (c *Consistent) GetBestOfN(key string, n int) (string, error) {
+	s := c.current()
+	if s.count == 0 {
+		return "", ErrNoNodes
+	}
+	if n < 1 {
+		n = 1
+	}
+	if n > s.count {
+		n = s.count
+	}
+	var buf [8]string
+	nodes := s.appendOwners(buf[:0], keyBytes(key), n)
+	c.health.mu.RLock()
+	defer c.health.mu.RUnlock()
+	best := nodes[0]
+	score := c.health.score[best]
+	for _, node := range nodes[1:] {
+		if sc := c.health.score[node]; sc < score {
+			best, score = node, sc
+		}
+	}
+	s.counted(best)
+	return best, nil
+}
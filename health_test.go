@@ -0,0 +1,33 @@
+package consistent
+
+import "testing"
+
+func TestGetBestOfN(t *testing.T) {
+	c := NewConsistent()
+	if _, err := c.GetBestOfN("xxx", 2); err != ErrNoNodes {
+		t.Errorf("GetBestOfN on empty ring exp: %v, got %v\n", ErrNoNodes, err)
+	}
+	c.AddNodes([]string{"node1", "node2", "node3"})
+	owners, _ := c.GetNNode("xxx", 3)
+
+	tests := []struct {
+		n      int
+		scores map[string]float64
+		exp    string
+	}{
+		{2, nil, owners[0]},
+		{0, map[string]float64{owners[0]: 5}, owners[0]},
+		{2, map[string]float64{owners[0]: 5, owners[1]: 1}, owners[1]},
+		{2, map[string]float64{owners[0]: 5, owners[1]: 1, owners[2]: 0.5}, owners[1]},
+		{3, map[string]float64{owners[0]: 5, owners[1]: 1, owners[2]: 0.5}, owners[2]},
+		{10, map[string]float64{owners[0]: 5, owners[1]: 1, owners[2]: 0.5}, owners[2]},
+	}
+	for _, tt := range tests {
+		for _, node := range owners {
+			c.ReportHealth(node, tt.scores[node])
+		}
+		if got, err := c.GetBestOfN("xxx", tt.n); err != nil || got != tt.exp {
+			t.Errorf("GetBestOfN(%v) with %v exp: %v, got %v %v\n", tt.n, tt.scores, tt.exp, got, err)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+//go:build highwayhash
+
+package consistent
+
+import "github.com/minio/highwayhash"
+
+// HighwayHash returns HashFunc of 64-bit HighwayHash keyed by 256-bit key,
+// of github.com/minio/highwayhash which uses AVX2, SSE4.1 or NEON where
+// available. It's keyed like SipHash and faster on large keys such as URLs
+// and object paths. Build with -tags highwayhash to use it.
+func HighwayHash(key [32]byte) HashFunc {
+	return func(msg []byte) uint64 {
+		return highwayhash.Sum64(msg, key[:])
+	}
+}
@@ -0,0 +1,33 @@
+//go:build highwayhash
+
+package consistent
+
+import "fmt"
+import "strings"
+import "testing"
+
+func TestHighwayHash(t *testing.T) {
+	fn := HighwayHash([32]byte{1, 2, 3})
+	if fn([]byte("xxx")) != fn([]byte("xxx")) {
+		t.Errorf("HighwayHash should be deterministic\n")
+	}
+	if HighwayHash([32]byte{})([]byte("xxx")) == fn([]byte("xxx")) {
+		t.Errorf("HighwayHash should depend on key\n")
+	}
+	c := NewConsistentWithHash(DefaultReplica, fn)
+	c.AddNodes([]string{"node1", "node2", "node3"})
+	for i := 0; i < 100; i++ {
+		if _, err := c.GetNode(fmt.Sprintf("/bucket/object/%d", i)); err != nil {
+			t.Errorf("GetNode err: %v\n", err)
+		}
+	}
+}
+
+func BenchmarkHighwayHashURL(b *testing.B) {
+	fn := HighwayHash([32]byte{})
+	key := []byte("https://example.com/" + strings.Repeat("path/", 40))
+	b.SetBytes(int64(len(key)))
+	for i := 0; i < b.N; i++ {
+		fn(key)
+	}
+}
@@ -0,0 +1,76 @@
+package consistent
+
+import "math"
+
+// ArcHistogram is distribution of contiguous arc sizes of nodes. Points
+// next to each other owned by one node make one arc.
+type ArcHistogram struct {
+	Width  float64          // bucket width as fraction of keyspace
+	Counts map[string][]int // arcs per bucket by node
+}
+
+// ArcHistogram returns histogram of arc sizes in buckets of equal width up
+// to the largest arc, e.g. to compare smoothness of replica numbers
+func (c *Consistent) ArcHistogram(buckets int) ArcHistogram {
+	c.rlock()
+	defer c.runlock()
+	if buckets < 1 {
+		buckets = 1
+	}
+	h := ArcHistogram{Counts: make(map[string][]int, c.count)}
+	arcs, owners := contiguousArcs(c.ring)
+	max := 0.0
+	for _, a := range arcs {
+		if a > max {
+			max = a
+		}
+	}
+	h.Width = max / float64(buckets)
+	for i, a := range arcs {
+		counts, ok := h.Counts[owners[i]]
+		if !ok {
+			counts = make([]int, buckets)
+			h.Counts[owners[i]] = counts
+		}
+		b := buckets - 1
+		if h.Width > 0 && a < max {
+			b = int(a / h.Width)
+		}
+		counts[b]++
+	}
+	return h
+}
+
+// contiguousArcs returns sizes of arcs as fraction of keyspace and their
+// owners, merging neighbor points of one node
+func contiguousArcs(r ring) ([]float64, []string) {
+	l := r.Len()
+	if l == 0 {
+		return nil, nil
+	}
+	// start walk at a point whose predecessor has other owner
+	start := -1
+	for i := 0; i < l; i++ {
+		if r.Owner(i) != r.Owner((i+l-1)%l) {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return []float64{1}, []string{r.Owner(0)}
+	}
+	var arcs []float64
+	var owners []string
+	for k := 0; k < l; k++ {
+		i := (start + k) % l
+		// wraps around zero for the first point
+		arc := float64(r.Hash(i)-r.Hash((i+l-1)%l)) / math.MaxUint64
+		if k > 0 && r.Owner(i) == owners[len(owners)-1] {
+			arcs[len(arcs)-1] += arc
+			continue
+		}
+		arcs = append(arcs, arc)
+		owners = append(owners, r.Owner(i))
+	}
+	return arcs, owners
+}
@@ -0,0 +1,44 @@
+package consistent
+
+import "math"
+import "testing"
+
+func TestArcHistogram(t *testing.T) {
+	r := newSliceRing()
+	q := uint64(math.MaxUint64 / 8)
+	// arcs: a 2q (wrapping), b q, a 3q, b 2q merged of two points
+	r.Insert("a", []uint64{q, 5 * q})
+	r.Insert("b", []uint64{2 * q, 6 * q, 7 * q})
+	arcs, owners := contiguousArcs(r)
+	exp := []float64{2.0 / 8, 1.0 / 8, 3.0 / 8, 2.0 / 8}
+	if len(arcs) != len(exp) {
+		t.Fatalf("contiguousArcs exp: %v, got %v %v\n", exp, arcs, owners)
+	}
+	for i := range exp {
+		if math.Abs(arcs[i]-exp[i]) > 1e-9 {
+			t.Errorf("arc %v of %v exp: %v, got %v\n", i, owners[i], exp[i], arcs[i])
+		}
+	}
+
+	c := NewConsistent()
+	c.AddNodes([]string{"node1", "node2", "node3"})
+	h := c.ArcHistogram(10)
+	total := 0
+	for node, counts := range h.Counts {
+		if len(counts) != 10 {
+			t.Errorf("%v exp: 10 buckets, got %v\n", node, len(counts))
+		}
+		for _, n := range counts {
+			total += n
+		}
+	}
+	if len(h.Counts) != 3 || total == 0 || h.Width <= 0 {
+		t.Errorf("ArcHistogram got %+v\n", h)
+	}
+
+	single := NewConsistent()
+	single.AddNode("node1")
+	if h := single.ArcHistogram(4); h.Counts["node1"][3] != 1 || math.Abs(h.Width-0.25) > 1e-9 {
+		t.Errorf("single node exp one full arc, got %+v\n", h)
+	}
+}
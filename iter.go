@@ -0,0 +1,31 @@
+//go:build go1.23
+
+package consistent
+
+import "iter"
+
+// All iterates virtual nodes in ring order as hash, physical node pairs.
+// It reads snapshot of consistent taken at start, so loop body may mutate
+// consistent, changes aren't seen by iteration.
+func (c *Consistent) All() iter.Seq2[uint64, string] {
+	return func(yield func(uint64, string) bool) {
+		s := c.current()
+		for i := 0; i < s.ring.Len(); i++ {
+			if !yield(s.ring.Hash(i), s.ring.Owner(i)) {
+				return
+			}
+		}
+	}
+}
+
+// Nodes iterates physical nodes in name order. Like All, it reads
+// snapshot taken at start, so loop body may mutate consistent.
+func (c *Consistent) Nodes() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for _, node := range c.current().members {
+			if !yield(node) {
+				return
+			}
+		}
+	}
+}
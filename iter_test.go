@@ -0,0 +1,61 @@
+//go:build go1.23
+
+package consistent
+
+import "sort"
+import "reflect"
+import "testing"
+
+func TestIterators(t *testing.T) {
+	c := NewConsistentWithN(10)
+	c.AddNodes([]string{"node1", "node2", "node3"})
+
+	var last uint64
+	points := 0
+	for h, node := range c.All() {
+		if points > 0 && h <= last {
+			t.Errorf("All() not in ring order, %v after %v\n", h, last)
+		}
+		if exp := c.ring.Owner(points); node != exp {
+			t.Errorf("Wrong owner of point %v, exp: %v, got %v\n", points, exp, node)
+		}
+		last = h
+		points++
+	}
+	if points != 30 {
+		t.Errorf("Wrong All() length, exp: 30, got %v\n", points)
+	}
+
+	var nodes []string
+	for node := range c.Nodes() {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	if !reflect.DeepEqual(nodes, c.Members()) {
+		t.Errorf("Wrong Nodes(), exp: %v, got %v\n", c.Members(), nodes)
+	}
+
+	for range c.All() {
+		break
+	}
+	c.AddNode("node4")
+
+	// loop body may mutate, iteration keeps its snapshot
+	points = 0
+	for _, node := range c.All() {
+		c.RemoveNode(node)
+		points++
+	}
+	if points != 40 || c.NodeNumber() != 0 {
+		t.Errorf("All() removing nodes exp: 40 points, no nodes, got %v %v\n", points, c.NodeNumber())
+	}
+	c.AddNodes([]string{"node1", "node2"})
+	nodes = nil
+	for node := range c.Nodes() {
+		c.AddNode(node + "x")
+		nodes = append(nodes, node)
+	}
+	if exp := []string{"node1", "node2"}; !reflect.DeepEqual(nodes, exp) || c.NodeNumber() != 4 {
+		t.Errorf("Nodes() adding nodes exp: %v, 4 nodes, got %v %v\n", exp, nodes, c.NodeNumber())
+	}
+}
@@ -0,0 +1,38 @@
+package consistent
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// jsonVersion is format version written by MarshalJSON
+const jsonVersion = 1
+
+type jsonConfig struct {
+	Version int `json:"version"`
+	Config
+}
+
+// MarshalJSON encodes Config of consistent with format version. Ring
+// with custom hash function encodes no hash name, see UnmarshalJSON.
+func (c *Consistent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonConfig{jsonVersion, c.Config()})
+}
+
+// UnmarshalJSON replaces state of consistent by decoded Config. Without
+// hash name the hash function of consistent is kept, so rings of custom
+// hash are decoded into consistent configured with it. Zero Consistent
+// starts from NewConsistent defaults.
+func (c *Consistent) UnmarshalJSON(data []byte) error {
+	var cfg jsonConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return err
+	}
+	if cfg.Version != jsonVersion {
+		return fmt.Errorf("%w: %d", ErrBadVersion, cfg.Version)
+	}
+	c.lock()
+	defer c.unlock()
+	c.init()
+	return c.setConfig(cfg.Config, nil)
+}
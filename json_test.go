@@ -0,0 +1,78 @@
+package consistent
+
+import "encoding/json"
+import "errors"
+import "fmt"
+import "reflect"
+import "strings"
+import "testing"
+
+func TestJSON(t *testing.T) {
+	c := NewConsistentWithOptions(WithReplicas(20), WithHashName("xxhash64"), WithSeed(7))
+	c.AddNodes([]string{"node1", "node2"})
+	c.AddWeightedNode("node3", 3)
+	c.AddNodeWithCapacity("node4", 2)
+	c.AddNodeInfo(Node{ID: "node5", Address: "10.0.0.5:11211", Meta: map[string]string{ZoneLabel: "z1"}})
+	c.ReplaceNode("node1", "node6")
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("MarshalJSON %v\n", err)
+	}
+
+	var got Consistent
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("UnmarshalJSON %v\n", err)
+	}
+	if !reflect.DeepEqual(got.Config(), c.Config()) || got.Fingerprint() != c.Fingerprint() {
+		t.Errorf("decoded config exp: %+v, got %+v\n", c.Config(), got.Config())
+	}
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprint(i)
+		exp, _ := c.GetNode(key)
+		if node, _ := got.GetNode(key); node != exp {
+			t.Fatalf("decoded ring GetNode(%v) exp: %v, got %v\n", key, exp, node)
+		}
+	}
+}
+
+func TestJSONCustomHash(t *testing.T) {
+	custom := func(key []byte) uint64 { return Murmur3(key) + 1 }
+	c := NewConsistentWithHash(10, custom)
+	c.AddNodes([]string{"node1", "node2"})
+	data, _ := json.Marshal(c)
+	if strings.Contains(string(data), `"hash"`) {
+		t.Errorf("custom hash exp: no name, got %s\n", data)
+	}
+	got := NewConsistentWithHash(10, custom)
+	if err := json.Unmarshal(data, got); err != nil || got.Fingerprint() != c.Fingerprint() {
+		t.Errorf("decoded custom hash ring got %v %v\n", got.Config(), err)
+	}
+	exp, _ := c.GetNode("xxx")
+	if node, _ := got.GetNode("xxx"); node != exp {
+		t.Errorf("custom hash GetNode exp: %v, got %v\n", exp, node)
+	}
+}
+
+func TestJSONErrors(t *testing.T) {
+	tests := []struct {
+		data string
+		err  error
+	}{
+		{`{"version":2,"replicas":10}`, ErrBadVersion},
+		{`{"version":1,"replicas":10,"hash":"md5"}`, ErrUnknownHash},
+		{`{"version":1,"replicas":0}`, ErrInvalidReplicas},
+		{`{"version":1,"replicas":10,"nodes":[{"node":"a","vnodes":10},{"node":"a","vnodes":10}]}`, ErrInvalidConfig},
+		{`{"version":1,"replicas":10,"nodes":[{"node":"a"}]}`, ErrInvalidConfig},
+		{`{"version":1,"replicas":10,"nodes":[{"vnodes":1}]}`, ErrInvalidConfig},
+	}
+	for _, tt := range tests {
+		c := NewConsistent()
+		c.AddNode("node1")
+		if err := json.Unmarshal([]byte(tt.data), c); !errors.Is(err, tt.err) {
+			t.Errorf("UnmarshalJSON(%s) exp: %v, got %v\n", tt.data, tt.err, err)
+		}
+		if !c.HasNode("node1") {
+			t.Errorf("UnmarshalJSON(%s) error exp: ring kept, got %v\n", tt.data, c.Members())
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package consistent
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ExportKetamaServers writes nodes of consistent in libketama server list
+// format, "name<TAB>weight" per line in name order, for consumers
+// configured from such file. Weight is virtual node number, libketama
+// only takes weights relative to their total, so they carry over exactly.
+//
+// The list keeps membership and weights of consumers in sync, not keys:
+// libketama places its MD5 points by its own rules, so it routes keys
+// differently from this ring. Node info, tokens and capacities aren't
+// listed, see ImportKetamaServers.
+func (c *Consistent) ExportKetamaServers(w io.Writer) error {
+	c.rlock()
+	cfg := c.config()
+	c.runlock()
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# libketama servers, epoch %d\n", cfg.Epoch)
+	for _, n := range cfg.Nodes {
+		if strings.ContainsAny(n.Node, " \t\r\n") || strings.HasPrefix(n.Node, "#") {
+			return fmt.Errorf("%w: node %q isn't a word", ErrInvalidConfig, n.Node)
+		}
+		fmt.Fprintf(bw, "%s\t%d\n", n.Node, n.VNodes)
+	}
+	return bw.Flush()
+}
+
+// ImportKetamaServers changes nodes of consistent to those of libketama
+// server list like UpdateConfig, weight taken as virtual node number.
+// Nodes already on the ring keep their info, token and capacity, so list
+// of ExportKetamaServers gives the same ring back. List doesn't tell
+// weights from explicit virtual node numbers, so new nodes are Explicit
+// unless weight is multiple of replicas. Errors wrap ErrInvalidConfig.
+func (c *Consistent) ImportKetamaServers(r io.Reader) error {
+	nodes, err := parseKetamaServers(r)
+	if err != nil {
+		return err
+	}
+	c.lock()
+	defer c.unlock()
+	c.init()
+	cfg := c.config()
+	known := make(map[string]NodeConfig, len(cfg.Nodes))
+	for _, n := range cfg.Nodes {
+		known[n.Node] = n
+	}
+	for i, n := range nodes {
+		if k, ok := known[n.Node]; ok {
+			k.VNodes = n.VNodes
+			nodes[i] = k
+		} else {
+			nodes[i].Explicit = n.VNodes%cfg.Replicas != 0
+		}
+	}
+	cfg.Nodes, cfg.Epoch = nodes, 0
+	return c.updateConfig(cfg)
+}
+
+// parseKetamaServers reads "name weight" lines, skipping blanks and
+// # comments
+func parseKetamaServers(r io.Reader) ([]NodeConfig, error) {
+	var nodes []NodeConfig
+	seen := make(map[string]int)
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%w: ketama servers line %d: expected name and weight, got %q",
+				ErrInvalidConfig, line, text)
+		}
+		weight, err := strconv.Atoi(fields[1])
+		if err != nil || weight <= 0 {
+			return nil, fmt.Errorf("%w: ketama servers line %d: weight must be positive integer, got %q",
+				ErrInvalidConfig, line, fields[1])
+		}
+		if prev, ok := seen[fields[0]]; ok {
+			return nil, fmt.Errorf("%w: ketama servers line %d: %q already listed at line %d",
+				ErrInvalidConfig, line, fields[0], prev)
+		}
+		seen[fields[0]] = line
+		nodes = append(nodes, NodeConfig{Node: fields[0], VNodes: weight})
+	}
+	return nodes, s.Err()
+}
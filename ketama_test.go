@@ -0,0 +1,87 @@
+package consistent
+
+import "bytes"
+import "errors"
+import "fmt"
+import "reflect"
+import "strings"
+import "testing"
+
+func TestExportKetamaServers(t *testing.T) {
+	c := NewConsistentWithOptions(WithReplicas(10))
+	c.AddNodes([]string{"10.0.0.2:11211", "10.0.0.1:11211"})
+	c.UpdateWeight("10.0.0.2:11211", 3)
+	c.AddNodeInfo(Node{ID: "cache-3", Address: "10.0.0.3:11211"})
+	var buf bytes.Buffer
+	if err := c.ExportKetamaServers(&buf); err != nil {
+		t.Fatalf("ExportKetamaServers %v\n", err)
+	}
+	exp := "# libketama servers, epoch 4\n" +
+		"10.0.0.1:11211\t10\n" +
+		"10.0.0.2:11211\t30\n" +
+		"cache-3\t10\n"
+	if buf.String() != exp {
+		t.Errorf("servers exp: %q, got %q\n", exp, buf.String())
+	}
+
+	c.AddNode("cache 4")
+	if err := c.ExportKetamaServers(&buf); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("name with space exp: %v, got %v\n", ErrInvalidConfig, err)
+	}
+}
+
+func TestImportKetamaServers(t *testing.T) {
+	c := NewConsistentWithOptions(WithReplicas(10))
+	c.AddNodes([]string{"10.0.0.1:11211", "10.0.0.2:11211", "10.0.0.3:11211"})
+	c.SetVirtualNodes("10.0.0.2:11211", 25)
+	c.AddNodeInfo(Node{ID: "cache-4", Address: "10.0.0.4:11211", Meta: map[string]string{ZoneLabel: "z1"}})
+	var buf bytes.Buffer
+	c.ExportKetamaServers(&buf)
+	data := buf.String()
+
+	// same ring takes its list back as is
+	fp := c.Fingerprint()
+	if err := c.ImportKetamaServers(strings.NewReader(data)); err != nil || c.Fingerprint() != fp {
+		t.Errorf("reimport exp: same ring, got %+v %v\n", c.Config(), err)
+	}
+	if n, _ := c.NodeInfo("cache-4"); n.Address != "10.0.0.4:11211" || n.Meta[ZoneLabel] != "z1" {
+		t.Errorf("reimported info exp: kept, got %+v\n", n)
+	}
+	c.RemoveNode("cache-4")
+	buf.Reset()
+	c.ExportKetamaServers(&buf)
+
+	got := NewConsistentWithOptions(WithReplicas(10))
+	got.AddNode("10.0.0.9:11211")
+	if err := got.ImportKetamaServers(&buf); err != nil {
+		t.Fatalf("ImportKetamaServers %v\n", err)
+	}
+	if !reflect.DeepEqual(got.Config().Nodes, c.Config().Nodes) || got.Fingerprint() != c.Fingerprint() {
+		t.Errorf("imported nodes exp: %+v, got %+v\n", c.Config().Nodes, got.Config().Nodes)
+	}
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprint(i)
+		exp, _ := c.GetNode(key)
+		if node, _ := got.GetNode(key); node != exp {
+			t.Fatalf("imported GetNode(%v) exp: %v, got %v\n", key, exp, node)
+		}
+	}
+
+	tests := []struct {
+		data string
+		msg  string
+	}{
+		{"10.0.0.1:11211\n", "line 1: expected name and weight"},
+		{"# servers\n\n10.0.0.1:11211 0\n", `line 3: weight must be positive integer, got "0"`},
+		{"10.0.0.1:11211 1\n10.0.0.1:11211 2\n", `line 2: "10.0.0.1:11211" already listed at line 1`},
+	}
+	for _, tt := range tests {
+		err := got.ImportKetamaServers(strings.NewReader(tt.data))
+		if !errors.Is(err, ErrInvalidConfig) || !strings.Contains(err.Error(), tt.msg) {
+			t.Errorf("ImportKetamaServers(%q) exp: %v, got %v\n", tt.data, tt.msg, err)
+		}
+	}
+	if got.NodeNumber() != 3 {
+		t.Errorf("failed import exp: ring kept, got %v\n", got.Members())
+	}
+}
@@ -0,0 +1,35 @@
+package consistent
+
+// WithLazyRebuild makes AddNode and RemoveNode only stage their points,
+// sorted ring is rebuilt once on next read, other write or Flush. Bursts of
+// membership changes then cost one rebuild. Rings other than the default
+// apply changes right away.
+func WithLazyRebuild() Option {
+	return func(c *Consistent) { c.lazy = true }
+}
+
+// Flush applies staged changes of WithLazyRebuild
+func (c *Consistent) Flush() {
+	c.lock()
+	defer c.unlock()
+}
+
+// lockStaged takes write lock for a change which may be staged
+func (c *Consistent) lockStaged() {
+	c.mu.Lock()
+	if !(c.lazy || c.debounce > 0) || c.staged {
+		return
+	}
+	if b, ok := c.ring.(bulkRing); ok {
+		b.Begin()
+		c.staged = true
+	}
+}
+
+// commit applies staged changes, caller must hold write lock
+func (c *Consistent) commit() {
+	if c.staged {
+		c.ring.(bulkRing).Commit()
+		c.staged = false
+	}
+}
@@ -0,0 +1,38 @@
+package consistent
+
+import "fmt"
+import "reflect"
+import "testing"
+
+func TestLazyRebuild(t *testing.T) {
+	c := NewConsistentWithOptions(WithLazyRebuild())
+	plain := NewConsistent()
+	for i := 0; i < 20; i++ {
+		c.AddNode(fmt.Sprintf("node%d", i))
+		plain.AddNode(fmt.Sprintf("node%d", i))
+	}
+	c.RemoveNode("node3")
+	plain.RemoveNode("node3")
+	if !c.staged || c.ring.Len() != 0 {
+		t.Errorf("changes should be staged, got staged: %v, ring len: %v\n", c.staged, c.ring.Len())
+	}
+	for i := 0; i < 100; i++ {
+		exp, _ := plain.GetNNode(fmt.Sprint(i), 3)
+		if got, _ := c.GetNNode(fmt.Sprint(i), 3); !reflect.DeepEqual(got, exp) {
+			t.Errorf("GetNNode exp: %v, got %v\n", exp, got)
+		}
+	}
+	if c.staged {
+		t.Errorf("read should apply staged changes\n")
+	}
+
+	c.AddNode("node3")
+	plain.AddNode("node3")
+	c.Flush()
+	if c.staged || c.ring.Len() != plain.ring.Len() {
+		t.Errorf("Flush exp ring len: %v, got staged: %v, len: %v\n", plain.ring.Len(), c.staged, c.ring.Len())
+	}
+	if !reflect.DeepEqual(c.OwnedRanges("node3"), plain.OwnedRanges("node3")) {
+		t.Errorf("OwnedRanges differ after Flush\n")
+	}
+}
@@ -0,0 +1,151 @@
+package consistent
+
+import "math"
+
+// ChangeListener is notified of committed changes of consistent, see
+// OnChange
+type ChangeListener interface {
+	NodeAdded(node string)
+	NodeRemoved(node string)
+	WeightChanged(node string, vnodes int)
+	// OwnershipChanged reports fraction of keyspace which changed owner
+	OwnershipChanged(movedFraction float64)
+}
+
+// OnChange registers listener called after each committed mutation, in
+// order of changes, on goroutine which made it. Node callbacks come first,
+// then OwnershipChanged if any key moved. Changes staged by
+// WithLazyRebuild or WithDebounce are reported once applied. Listener
+// must not modify consistent, and readers see the new ring before it's
+// called.
+func (c *Consistent) OnChange(listener ChangeListener) {
+	c.notifying.Lock()
+	defer c.notifying.Unlock()
+	if c.notified == nil {
+		c.notified = c.current().ring
+	}
+	c.mu.Lock()
+	c.listeners = append(c.listeners, listener)
+	c.mu.Unlock()
+}
+
+type changeKind int
+
+const (
+	nodeAdded changeKind = iota
+	nodeRemoved
+	weightChanged
+)
+
+type changeEvent struct {
+	kind   changeKind
+	node   string
+	vnodes int
+}
+
+// record counts, logs, audits and appends change to change log and queues
+// it for listeners, caller must hold write lock. prev is virtual node
+// number before change, 0 on add.
+func (c *Consistent) record(kind changeKind, node string, prev, vnodes int) {
+	c.epoch++
+	if c.logger != nil {
+		switch kind {
+		case nodeAdded:
+			c.logger.log(logChange, "node added", "node", node, "vnodes", vnodes)
+		case nodeRemoved:
+			c.logger.log(logChange, "node removed", "node", node)
+		case weightChanged:
+			c.logger.log(logChange, "node weight changed", "node", node, "vnodes", vnodes)
+		}
+	}
+	if c.audit != nil {
+		c.audited(kind, node, prev, vnodes)
+	}
+	if c.wal != nil {
+		c.logged(kind, node, vnodes)
+	}
+	if len(c.listeners) > 0 {
+		c.events = append(c.events, changeEvent{kind, node, vnodes})
+	}
+}
+
+// notify delivers queued changes and keyspace moved since last delivery
+func (c *Consistent) notify() {
+	c.notifying.Lock()
+	defer c.notifying.Unlock()
+	c.mu.Lock()
+	events, listeners := c.events, c.listeners
+	c.events = nil
+	c.mu.Unlock()
+	// concurrent writer may have delivered our changes already
+	r := c.current().ring
+	moved := movedFraction(c.notified, r)
+	c.notified = r
+	for _, l := range listeners {
+		for _, e := range events {
+			switch e.kind {
+			case nodeAdded:
+				l.NodeAdded(e.node)
+			case nodeRemoved:
+				l.NodeRemoved(e.node)
+			case weightChanged:
+				l.WeightChanged(e.node, e.vnodes)
+			}
+		}
+		if moved > 0 {
+			l.OwnershipChanged(moved)
+		}
+	}
+}
+
+// movedFraction returns fraction of keyspace owned by different nodes in
+// rings a and b, walking boundaries of both at once
+func movedFraction(a, b ring) float64 {
+	la, lb := a.Len(), b.Len()
+	if la == 0 || lb == 0 {
+		if la == lb {
+			return 0
+		}
+		return 1
+	}
+	last := a.Hash(la - 1)
+	if h := b.Hash(lb - 1); h > last {
+		last = h
+	}
+	var moved uint64
+	var full bool
+	prev := last
+	for i, j := 0, 0; i < la || j < lb; {
+		var x uint64
+		switch {
+		case j == lb:
+			x = a.Hash(i)
+		case i == la:
+			x = b.Hash(j)
+		default:
+			x = a.Hash(i)
+			if h := b.Hash(j); h < x {
+				x = h
+			}
+		}
+		// keys in (prev, x] belong to first points at or after x
+		if a.Owner(i%la) != b.Owner(j%lb) {
+			if x == prev {
+				// single boundary, the arc is whole ring
+				full = true
+			}
+			moved += x - prev
+		}
+		for i < la && a.Hash(i) == x {
+			i++
+		}
+		for j < lb && b.Hash(j) == x {
+			j++
+		}
+		prev = x
+	}
+	if full {
+		return 1
+	}
+	return float64(moved) / math.MaxUint64
+}
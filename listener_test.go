@@ -0,0 +1,82 @@
+package consistent
+
+import "math"
+import "reflect"
+import "testing"
+
+type recordingListener struct {
+	events []string
+	moved  []float64
+}
+
+func (l *recordingListener) NodeAdded(node string)   { l.events = append(l.events, "+"+node) }
+func (l *recordingListener) NodeRemoved(node string) { l.events = append(l.events, "-"+node) }
+func (l *recordingListener) WeightChanged(node string, vnodes int) {
+	l.events = append(l.events, "~"+node)
+}
+func (l *recordingListener) OwnershipChanged(moved float64) { l.moved = append(l.moved, moved) }
+
+func TestOnChange(t *testing.T) {
+	c := NewConsistent()
+	c.AddNode("node1")
+	l := &recordingListener{}
+	c.OnChange(l)
+
+	c.AddNodes([]string{"node2", "node3"})
+	share := arcShares(c.ring)["node3"]
+	c.RemoveNode("node3")
+	c.UpdateWeight("node2", 2)
+	c.RemoveNode("node3")
+	exp := []string{"+node2", "+node3", "-node3", "~node2"}
+	if !reflect.DeepEqual(l.events, exp) {
+		t.Errorf("events exp: %v, got %v\n", exp, l.events)
+	}
+	if len(l.moved) != 3 {
+		t.Fatalf("OwnershipChanged exp: 3 calls, got %v\n", l.moved)
+	}
+	if math.Abs(l.moved[1]-share) > 1e-9 {
+		t.Errorf("removing node3 moved exp: %v, got %v\n", share, l.moved[1])
+	}
+
+	lazy := NewConsistentWithOptions(WithLazyRebuild())
+	ll := &recordingListener{}
+	lazy.OnChange(ll)
+	lazy.AddNode("node1")
+	lazy.AddNode("node2")
+	if len(ll.events) != 0 {
+		t.Errorf("staged changes exp: no events, got %v\n", ll.events)
+	}
+	lazy.Flush()
+	if !reflect.DeepEqual(ll.events, []string{"+node1", "+node2"}) || !reflect.DeepEqual(ll.moved, []float64{1}) {
+		t.Errorf("flushed changes got %v %v\n", ll.events, ll.moved)
+	}
+}
+
+func TestMovedFraction(t *testing.T) {
+	q := uint64(math.MaxUint64 / 4)
+	ring := func(points map[uint64]string) ring {
+		r := newSliceRing()
+		for h, node := range points {
+			r.Insert(node, []uint64{h})
+		}
+		return r
+	}
+	tests := []struct {
+		a, b map[uint64]string
+		exp  float64
+	}{
+		{map[uint64]string{}, map[uint64]string{}, 0},
+		{map[uint64]string{}, map[uint64]string{q: "a"}, 1},
+		{map[uint64]string{q: "a"}, map[uint64]string{q: "b"}, 1},
+		{map[uint64]string{q: "a", 3 * q: "b"}, map[uint64]string{q: "a", 3 * q: "b"}, 0},
+		// c takes (q, 2q] from b
+		{map[uint64]string{q: "a", 3 * q: "b"}, map[uint64]string{q: "a", 2 * q: "c", 3 * q: "b"}, 0.25},
+		// b takes wrapping arc (3q, q] from a
+		{map[uint64]string{q: "a", 3 * q: "b"}, map[uint64]string{3 * q: "b"}, 0.5},
+	}
+	for _, tt := range tests {
+		if got := movedFraction(ring(tt.a), ring(tt.b)); math.Abs(got-tt.exp) > 1e-9 {
+			t.Errorf("movedFraction(%v, %v) exp: %v, got %v\n", tt.a, tt.b, tt.exp, got)
+		}
+	}
+}
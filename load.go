@@ -0,0 +1,188 @@
+package consistent
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// loadSmoothing is EWMA factor of reported loads, kept across Rebalance
+// ticks. Lower keeps more history so a single spike doesn't count as a hot
+// node.
+const loadSmoothing = 0.3
+
+// hotLoadRatio is how far above mean a node's load must be to shed points
+const hotLoadRatio = 1.1
+
+type loadTracker struct {
+	mu    sync.Mutex
+	load  map[string]float64 // smoothed over all reports
+	fresh map[string]bool    // reported since last Rebalance
+
+	// nodes resized by Rebalance, guarded by write lock of consistent
+	shrunk map[string]shrink
+}
+
+// shrink is size of node before Rebalance shed its points and size it
+// was left at, a different size means node was resized since
+type shrink struct {
+	base, size int
+}
+
+// ReportLoad records observed load (requests, bytes, cpu, ... any unit
+// comparable between nodes) of node, smoothed with its earlier reports
+func (c *Consistent) ReportLoad(node string, load float64) {
+	c.loads.mu.Lock()
+	defer c.loads.mu.Unlock()
+	if c.loads.load == nil {
+		c.loads.load = make(map[string]float64)
+	}
+	if c.loads.fresh == nil {
+		c.loads.fresh = make(map[string]bool)
+	}
+	c.loads.fresh[node] = true
+	if old, ok := c.loads.load[node]; ok {
+		load = old + loadSmoothing*(load-old)
+	}
+	c.loads.load[node] = load
+}
+
+// Rebalance removes virtual nodes from nodes whose smoothed load is above
+// mean, proportional to their excess, then gives half of shed points back
+// to shrunk nodes at or below mean or not reported, at most budget points
+// moved in total. Only nodes reported since the previous Rebalance take
+// part, with loads smoothed over earlier ticks too, so a node sheds points
+// when it stays hot and, once reports stop, the ring converges back to
+// configured sizes. Each node keeps at least one point. It returns number
+// of removed and restored virtual nodes.
+func (c *Consistent) Rebalance(budget int) int {
+	c.lock()
+	defer c.unlock()
+	c.loads.mu.Lock()
+	loads := make(map[string]float64, len(c.loads.fresh))
+	for node := range c.loads.fresh {
+		loads[node] = c.loads.load[node]
+	}
+	for node := range c.loads.load {
+		if _, ok := c.node[node]; !ok {
+			// removed, its history is stale
+			delete(c.loads.load, node)
+		}
+	}
+	c.loads.fresh = nil
+	c.loads.mu.Unlock()
+
+	for node, s := range c.loads.shrunk {
+		if c.node[node] != s.size {
+			// removed or resized by hand, its size is base now
+			delete(c.loads.shrunk, node)
+		}
+	}
+	var sum float64
+	for node, load := range loads {
+		if _, ok := c.node[node]; !ok {
+			delete(loads, node)
+			continue
+		}
+		sum += load
+	}
+	var mean float64
+	if sum > 0 {
+		mean = sum / float64(len(loads))
+	}
+	moved := 0
+	// hottest first, they get the budget first
+	for _, node := range byLoad(loads) {
+		ratio := loads[node] / mean
+		if mean == 0 || ratio < hotLoadRatio {
+			continue
+		}
+		vnodes := c.node[node]
+		shed := int(float64(vnodes)*(1-1/ratio) + 0.5)
+		if shed > vnodes-1 {
+			shed = vnodes - 1
+		}
+		if shed > budget-moved {
+			shed = budget - moved
+		}
+		if shed <= 0 {
+			continue
+		}
+		c.shrinkNode(node, vnodes-shed)
+		moved += shed
+	}
+	shrunk := make([]string, 0, len(c.loads.shrunk))
+	for node := range c.loads.shrunk {
+		if load, ok := loads[node]; !ok || load <= mean {
+			shrunk = append(shrunk, node)
+		}
+	}
+	sort.Strings(shrunk)
+	for _, node := range shrunk {
+		s := c.loads.shrunk[node]
+		grow := (s.base - s.size + 1) / 2
+		if grow > budget-moved {
+			grow = budget - moved
+		}
+		if grow <= 0 {
+			continue
+		}
+		c.shrinkNode(node, s.size+grow)
+		moved += grow
+	}
+	return moved
+}
+
+// shrinkNode resizes node for Rebalance, keeping its size before first
+// shedding to restore later
+func (c *Consistent) shrinkNode(node string, vnodes int) {
+	s, ok := c.loads.shrunk[node]
+	if !ok {
+		s.base = c.node[node]
+	}
+	c.resizeNode(node, vnodes)
+	if vnodes >= s.base {
+		delete(c.loads.shrunk, node)
+		return
+	}
+	if c.loads.shrunk == nil {
+		c.loads.shrunk = make(map[string]shrink)
+	}
+	s.size = vnodes
+	c.loads.shrunk[node] = s
+}
+
+// StartRebalance runs Rebalance with budget every interval until returned
+// stop function is called
+func (c *Consistent) StartRebalance(interval time.Duration, budget int) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				c.Rebalance(budget)
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// byLoad returns nodes of loads ordered by load descending, then name
+func byLoad(loads map[string]float64) []string {
+	nodes := make([]string, 0, len(loads))
+	for node := range loads {
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if loads[nodes[i]] != loads[nodes[j]] {
+			return loads[nodes[i]] > loads[nodes[j]]
+		}
+		return nodes[i] < nodes[j]
+	})
+	return nodes
+}
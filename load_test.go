@@ -0,0 +1,127 @@
+package consistent
+
+import "reflect"
+import "testing"
+import "time"
+
+func TestRebalance(t *testing.T) {
+	c := NewConsistent()
+	c.AddNodes([]string{"node1", "node2", "node3", "node4"})
+
+	if c.Rebalance(50) != 0 {
+		t.Errorf("Rebalance without load shouldn't move\n")
+	}
+
+	report := func() {
+		for i := 0; i < 5; i++ {
+			c.ReportLoad("node1", 300)
+			c.ReportLoad("node2", 100)
+			c.ReportLoad("node3", 100)
+			c.ReportLoad("node4", 100)
+		}
+		c.ReportLoad("node5", 1000)
+	}
+	report()
+
+	if moved := c.Rebalance(10); moved != 10 {
+		t.Errorf("Rebalance should use whole budget, exp: 10, got %v\n", moved)
+	}
+	if c.node["node1"] != DefaultReplica-10 || c.node["node2"] != DefaultReplica {
+		t.Errorf("Wrong node shed points, got %v\n", c.node)
+	}
+
+	report()
+	moved := c.Rebalance(1000)
+	if moved <= 0 || moved >= DefaultReplica-10 || c.node["node1"] != DefaultReplica-10-moved {
+		t.Errorf("Rebalance should shed part of hot node, got %v %v\n", moved, c.node)
+	}
+}
+
+func TestRebalanceRecovers(t *testing.T) {
+	c := NewConsistent()
+	c.AddNodes([]string{"node1", "node2", "node3"})
+	c.AddWeightedNode("node4", 2)
+
+	// burst on node1 and node4, then idle ticks
+	c.ReportLoad("node1", 1000)
+	c.ReportLoad("node2", 100)
+	c.ReportLoad("node3", 100)
+	c.ReportLoad("node4", 1000)
+	if moved := c.Rebalance(1000); moved == 0 || c.node["node1"] >= DefaultReplica || c.node["node4"] >= 2*DefaultReplica {
+		t.Fatalf("burst exp: hot nodes shrunk, got %v %v\n", moved, c.node)
+	}
+	shrunk := c.node["node1"]
+	if c.Rebalance(5) != 5 || c.node["node1"] <= shrunk {
+		t.Errorf("idle tick exp: node1 regrows, got %v\n", c.node)
+	}
+	for i := 0; i < 100; i++ {
+		if c.Rebalance(5) == 0 {
+			break
+		}
+	}
+	exp := map[string]int{"node1": DefaultReplica, "node2": DefaultReplica, "node3": DefaultReplica, "node4": 2 * DefaultReplica}
+	if !reflect.DeepEqual(c.node, exp) {
+		t.Errorf("idle ticks exp: %v, got %v\n", exp, c.node)
+	}
+	if moved := c.Rebalance(1000); moved != 0 {
+		t.Errorf("converged ring exp: no moves, got %v\n", moved)
+	}
+
+	// hand resize is kept as new size
+	c.ReportLoad("node1", 1000)
+	c.ReportLoad("node2", 100)
+	c.Rebalance(1000)
+	c.UpdateWeight("node1", 3)
+	vnodes := c.node["node1"]
+	c.Rebalance(1000)
+	if c.node["node1"] != vnodes {
+		t.Errorf("resized node exp: %v, got %v\n", vnodes, c.node["node1"])
+	}
+}
+
+func TestStartRebalance(t *testing.T) {
+	c := NewConsistent()
+	c.AddNodes([]string{"node1", "node2"})
+	c.ReportLoad("node1", 10)
+	c.ReportLoad("node2", 1)
+
+	epoch := c.Epoch()
+	stop := c.StartRebalance(time.Millisecond, 1)
+	deadline := time.Now().Add(5 * time.Second)
+	for c.Epoch() == epoch && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	stop()
+	stop()
+
+	if c.Epoch() == epoch {
+		t.Errorf("Background rebalance didn't run\n")
+	}
+}
+
+func TestRebalanceSmoothsTicks(t *testing.T) {
+	c := NewConsistent()
+	c.AddNodes([]string{"node1", "node2", "node3"})
+	report := func(load float64) {
+		c.ReportLoad("node1", load)
+		c.ReportLoad("node2", 100)
+		c.ReportLoad("node3", 100)
+	}
+	for i := 0; i < 5; i++ {
+		report(100)
+		c.Rebalance(1000)
+	}
+	// one tick spike is damped by loads of earlier ticks
+	report(150)
+	if moved := c.Rebalance(1000); moved != 0 {
+		t.Errorf("spike exp: no moves, got %v %v\n", moved, c.node)
+	}
+	// staying hot sheds
+	for i := 0; i < 5 && c.node["node1"] == DefaultReplica; i++ {
+		report(150)
+		c.Rebalance(1000)
+	}
+	if c.node["node1"] >= DefaultReplica {
+		t.Errorf("persistent load exp: node1 shrunk, got %v\n", c.node)
+	}
+}
@@ -0,0 +1,17 @@
+package consistent
+
+// logEvent is category of logged event, see WithLogger
+type logEvent int
+
+const (
+	logChange logEvent = iota
+	logRebuild
+	logCollision
+	logNoOp
+	logStore
+)
+
+// eventLogger logs events of consistent with key value args
+type eventLogger interface {
+	log(ev logEvent, msg string, args ...interface{})
+}
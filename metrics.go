@@ -0,0 +1,179 @@
+package consistent
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MetricsSink receives metrics of consistent, see WithMetrics. Tags are
+// "key:value" pairs. Calls may happen under write lock of consistent, so
+// sinks must not block nor call back into it.
+type MetricsSink interface {
+	Count(name string, delta int64, tags ...string)
+	Gauge(name string, value float64, tags ...string)
+	Timing(name string, d time.Duration, tags ...string)
+}
+
+// WithMetrics reports to sink counts "node.added" and "node.removed"
+// tagged by node and timing "rebuild" of publishing state after changes.
+// Lookups per node are reported by StartMetrics.
+func WithMetrics(sink MetricsSink) Option {
+	return func(c *Consistent) { c.sink = sink }
+}
+
+// StartMetrics reports gauges "nodes" and "points", and with
+// WithLookupCounters count "lookups" per node since last report, to sink
+// of WithMetrics every interval until returned stop function is called
+func (c *Consistent) StartMetrics(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		last := make(map[string]uint64)
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				c.reportMetrics(last)
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// reportMetrics reports current gauges and lookups since last, which it
+// updates
+func (c *Consistent) reportMetrics(last map[string]uint64) {
+	if c.sink == nil {
+		return
+	}
+	s := c.current()
+	c.sink.Gauge("nodes", float64(s.count))
+	c.sink.Gauge("points", float64(s.ring.Len()))
+	for node, n := range c.LoadCounters() {
+		// counters went back on ResetCounters
+		delta := n
+		if n >= last[node] {
+			delta = n - last[node]
+		}
+		if delta > 0 {
+			c.sink.Count("lookups", int64(delta), "node:"+node)
+		}
+		last[node] = n
+	}
+}
+
+// statsdQueue is number of lines StatsdSink holds while writing, more are
+// dropped
+const statsdQueue = 1024
+
+// StatsdSink is MetricsSink writing statsd lines, one per write, so w is
+// typically a UDP net.Conn. Lines are queued and written by own goroutine,
+// not under lock of consistent, and dropped when queue is full. With
+// dogstatsd tags use its tag extension, otherwise their values are
+// appended to metric name, characters of the line protocol replaced by _.
+type StatsdSink struct {
+	prefix    string
+	dogstatsd bool
+	mu        sync.RWMutex // guards closed against sends
+	closed    bool
+	lines     chan []byte
+	done      chan struct{}
+}
+
+// NewStatsdSink returns sink writing to w metrics named prefix.name, until
+// Close
+func NewStatsdSink(w io.Writer, prefix string, dogstatsd bool) *StatsdSink {
+	s := &StatsdSink{prefix: prefix, dogstatsd: dogstatsd,
+		lines: make(chan []byte, statsdQueue), done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		for line := range s.lines {
+			// statsd is fire and forget
+			w.Write(line)
+		}
+	}()
+	return s
+}
+
+// Close writes queued lines and stops sink, later metrics are dropped
+func (s *StatsdSink) Close() error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.lines)
+	}
+	s.mu.Unlock()
+	<-s.done
+	return nil
+}
+
+// Count implements MetricsSink
+func (s *StatsdSink) Count(name string, delta int64, tags ...string) {
+	s.emit(name, strconv.FormatInt(delta, 10), "c", tags)
+}
+
+// Gauge implements MetricsSink
+func (s *StatsdSink) Gauge(name string, value float64, tags ...string) {
+	s.emit(name, strconv.FormatFloat(value, 'f', -1, 64), "g", tags)
+}
+
+// Timing implements MetricsSink, in milliseconds
+func (s *StatsdSink) Timing(name string, d time.Duration, tags ...string) {
+	ms := float64(d) / float64(time.Millisecond)
+	s.emit(name, strconv.FormatFloat(ms, 'f', -1, 64), "ms", tags)
+}
+
+// statsdName and statsdTag replace characters of statsd line protocol in
+// tag values of metric names and dogstatsd tags, such as : and . of node
+// addresses
+var (
+	statsdName = strings.NewReplacer(":", "_", ".", "_", "|", "_", "@", "_", "\n", "_")
+	statsdTag  = strings.NewReplacer("|", "_", ",", "_", "#", "_", "\n", "_")
+)
+
+func (s *StatsdSink) emit(name, value, typ string, tags []string) {
+	var buf bytes.Buffer
+	if s.prefix != "" {
+		buf.WriteString(s.prefix)
+		buf.WriteByte('.')
+	}
+	buf.WriteString(name)
+	if !s.dogstatsd {
+		for _, tag := range tags {
+			if i := strings.IndexByte(tag, ':'); i >= 0 {
+				tag = tag[i+1:]
+			}
+			buf.WriteByte('.')
+			statsdName.WriteString(&buf, tag)
+		}
+	}
+	buf.WriteByte(':')
+	buf.WriteString(value)
+	buf.WriteByte('|')
+	buf.WriteString(typ)
+	if s.dogstatsd && len(tags) > 0 {
+		buf.WriteString("|#")
+		for i, tag := range tags {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			statsdTag.WriteString(&buf, tag)
+		}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.lines <- buf.Bytes():
+	default:
+	}
+}
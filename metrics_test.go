@@ -0,0 +1,114 @@
+package consistent
+
+import "bytes"
+import "reflect"
+import "sort"
+import "strings"
+import "testing"
+import "time"
+
+// lineWriter records each write as a statsd packet
+type lineWriter struct {
+	lines []string
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.lines = append(w.lines, string(p))
+	return len(p), nil
+}
+
+func TestStatsdSink(t *testing.T) {
+	tests := []struct {
+		dogstatsd bool
+		exp       []string
+	}{
+		{false, []string{"app.lookups.node1:3|c", "app.node.added.10_0_0_1_6379:1|c", "app.odd.a_b,c_d:1|c",
+			"app.nodes:2|g", "app.rebuild:1.5|ms"}},
+		{true, []string{"app.lookups:3|c|#node:node1", "app.node.added:1|c|#node:10.0.0.1:6379", "app.odd:1|c|#node:a_b_c@d",
+			"app.nodes:2|g", "app.rebuild:1.5|ms"}},
+	}
+	for _, tt := range tests {
+		w := &lineWriter{}
+		s := NewStatsdSink(w, "app", tt.dogstatsd)
+		s.Count("lookups", 3, "node:node1")
+		s.Count("node.added", 1, "node:10.0.0.1:6379")
+		s.Count("odd", 1, "node:a|b,c@d")
+		s.Gauge("nodes", 2)
+		s.Timing("rebuild", 1500*time.Microsecond)
+		s.Close()
+		s.Gauge("nodes", 3)
+		if !reflect.DeepEqual(w.lines, tt.exp) {
+			t.Errorf("dogstatsd %v exp: %q, got %q\n", tt.dogstatsd, tt.exp, w.lines)
+		}
+	}
+}
+
+// blockedWriter blocks writes until unblocked
+type blockedWriter struct {
+	unblock chan struct{}
+	n       int
+}
+
+func (w *blockedWriter) Write(p []byte) (int, error) {
+	<-w.unblock
+	w.n++
+	return len(p), nil
+}
+
+func TestStatsdSinkQueue(t *testing.T) {
+	w := &blockedWriter{unblock: make(chan struct{})}
+	s := NewStatsdSink(w, "", false)
+	c := NewConsistentWithOptions(WithMetrics(s))
+	// writes must not hold lock of consistent
+	c.AddNode("node1")
+	for i := 0; i < 2*statsdQueue; i++ {
+		s.Count("lookups", 1, "node:node1")
+	}
+	close(w.unblock)
+	s.Close()
+	if w.n == 0 || w.n > statsdQueue+1 {
+		t.Errorf("written lines exp: 1 to %v, got %v\n", statsdQueue+1, w.n)
+	}
+}
+
+func TestWithMetrics(t *testing.T) {
+	w := &lineWriter{}
+	s := NewStatsdSink(w, "", false)
+	c := NewConsistentWithOptions(WithMetrics(s), WithLookupCounters())
+	c.AddNodes([]string{"node1", "node2"})
+	c.RemoveNode("node2")
+	node, _ := c.GetNode("xxx")
+	c.GetNode("xxx")
+	last := make(map[string]uint64)
+	c.reportMetrics(last)
+	c.reportMetrics(last)
+	s.Close()
+
+	var got []string
+	for _, line := range w.lines {
+		if !strings.HasPrefix(line, "rebuild:") {
+			got = append(got, line)
+		}
+	}
+	exp := []string{
+		"node.added.node1:1|c", "node.added.node2:1|c", "node.removed.node2:1|c",
+		"nodes:1|g", "points:100|g", "lookups." + node + ":2|c",
+		"nodes:1|g", "points:100|g",
+	}
+	sort.Strings(got[:3])
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("metrics exp: %q, got %q\n", exp, got)
+	}
+	if len(got) == len(w.lines) {
+		t.Errorf("exp rebuild timing, got %q\n", w.lines)
+	}
+}
+
+func TestStartMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	c := NewConsistentWithOptions(WithMetrics(NewStatsdSink(&buf, "", false)))
+	stop := c.StartMetrics(time.Millisecond)
+	stop()
+	stop()
+	c.sink.(*StatsdSink).Close()
+}
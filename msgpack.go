@@ -0,0 +1,415 @@
+package consistent
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// msgpackVersion is written as "v" of msgpack encoding. New keys may be
+// added without changing it, decoders skip keys they don't know, so it's
+// only bumped on incompatible changes.
+const msgpackVersion = 1
+
+// MarshalMsgpack encodes Config of consistent as msgpack map of string
+// keys, implementing Marshaler of github.com/vmihailenco/msgpack
+func (c *Consistent) MarshalMsgpack() ([]byte, error) {
+	return c.MarshalMsg(nil)
+}
+
+// MarshalMsg is MarshalMsgpack appending to b, implementing msgp.Marshaler
+// of github.com/tinylib/msgp
+func (c *Consistent) MarshalMsg(b []byte) ([]byte, error) {
+	cfg := c.Config()
+	e := msgpackEncoder{buf: b}
+	e.mapLen(4 + boolInt(cfg.Seed != 0) + boolInt(cfg.Epoch != 0))
+	e.string("v")
+	e.uint(msgpackVersion)
+	e.string("replicas")
+	e.uint(uint64(cfg.Replicas))
+	e.string("hash")
+	e.string(cfg.Hash)
+	if cfg.Seed != 0 {
+		e.string("seed")
+		e.uint(cfg.Seed)
+	}
+	if cfg.Epoch != 0 {
+		e.string("epoch")
+		e.uint(cfg.Epoch)
+	}
+	e.string("nodes")
+	e.arrayLen(len(cfg.Nodes))
+	for _, n := range cfg.Nodes {
+		e.mapLen(2 + boolInt(n.Capacity != 0) + boolInt(n.Token != "") + boolInt(n.Address != "") + boolInt(n.Meta != nil) + boolInt(n.Explicit))
+		e.string("node")
+		e.string(n.Node)
+		e.string("vnodes")
+		e.uint(uint64(n.VNodes))
+		if n.Capacity != 0 {
+			e.string("capacity")
+			e.float(n.Capacity)
+		}
+		if n.Token != "" {
+			e.string("token")
+			e.string(n.Token)
+		}
+		if n.Address != "" {
+			e.string("address")
+			e.string(n.Address)
+		}
+		if n.Explicit {
+			e.string("explicit")
+			e.buf = append(e.buf, 0xc3)
+		}
+		if n.Meta != nil {
+			keys := make([]string, 0, len(n.Meta))
+			for k := range n.Meta {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			e.string("meta")
+			e.mapLen(len(keys))
+			for _, k := range keys {
+				e.string(k)
+				e.string(n.Meta[k])
+			}
+		}
+	}
+	return e.buf, nil
+}
+
+// UnmarshalMsgpack replaces state of consistent by msgpack of
+// MarshalMsgpack, like UnmarshalJSON. Unknown keys are skipped.
+func (c *Consistent) UnmarshalMsgpack(data []byte) error {
+	cfg, rest, err := decodeMsgpack(data)
+	if err == nil && len(rest) > 0 {
+		err = fmt.Errorf("%w: trailing data", ErrInvalidData)
+	}
+	if err != nil {
+		return err
+	}
+	return c.applyMsgpack(cfg)
+}
+
+// UnmarshalMsg is UnmarshalMsgpack returning bytes left after the
+// encoding, implementing msgp.Unmarshaler of github.com/tinylib/msgp
+func (c *Consistent) UnmarshalMsg(b []byte) ([]byte, error) {
+	cfg, rest, err := decodeMsgpack(b)
+	if err != nil {
+		return b, err
+	}
+	return rest, c.applyMsgpack(cfg)
+}
+
+func (c *Consistent) applyMsgpack(cfg Config) error {
+	c.lock()
+	defer c.unlock()
+	c.init()
+	return c.setConfig(cfg, nil)
+}
+
+func decodeMsgpack(b []byte) (Config, []byte, error) {
+	d := msgpackDecoder{buf: b}
+	var cfg Config
+	version := uint64(0)
+	for k := d.mapLen(); k > 0 && d.err == nil; k-- {
+		switch d.string() {
+		case "v":
+			version = d.uint()
+		case "replicas":
+			cfg.Replicas = int(d.uint())
+		case "hash":
+			cfg.Hash = d.string()
+		case "seed":
+			cfg.Seed = d.uint()
+		case "epoch":
+			cfg.Epoch = d.uint()
+		case "nodes":
+			cfg.Nodes = make([]NodeConfig, d.arrayLen())
+			for i := range cfg.Nodes {
+				cfg.Nodes[i] = d.node()
+			}
+		default:
+			d.skip()
+		}
+	}
+	if d.err != nil {
+		return cfg, nil, d.err
+	}
+	if version != msgpackVersion {
+		return cfg, nil, fmt.Errorf("%w: %d", ErrBadVersion, version)
+	}
+	return cfg, d.buf, nil
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// msgpackEncoder appends msgpack values in their shortest formats
+type msgpackEncoder struct {
+	buf []byte
+}
+
+func (e *msgpackEncoder) head(fix, max int, n int, codes [3]byte) {
+	switch {
+	case n < max:
+		e.buf = append(e.buf, byte(fix|n))
+	case n <= math.MaxUint8 && codes[0] != 0:
+		e.buf = append(e.buf, codes[0], byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, codes[1], byte(n>>8), byte(n))
+	default:
+		e.buf = append(e.buf, codes[2], 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(e.buf[len(e.buf)-4:], uint32(n))
+	}
+}
+
+func (e *msgpackEncoder) mapLen(n int) {
+	e.head(0x80, 16, n, [3]byte{0, 0xde, 0xdf})
+}
+
+func (e *msgpackEncoder) arrayLen(n int) {
+	e.head(0x90, 16, n, [3]byte{0, 0xdc, 0xdd})
+}
+
+func (e *msgpackEncoder) string(s string) {
+	e.head(0xa0, 32, len(s), [3]byte{0xd9, 0xda, 0xdb})
+	e.buf = append(e.buf, s...)
+}
+
+func (e *msgpackEncoder) uint(v uint64) {
+	switch {
+	case v < 0x80:
+		e.buf = append(e.buf, byte(v))
+	case v <= math.MaxUint8:
+		e.buf = append(e.buf, 0xcc, byte(v))
+	case v <= math.MaxUint16:
+		e.buf = append(e.buf, 0xcd, byte(v>>8), byte(v))
+	case v <= math.MaxUint32:
+		e.buf = append(e.buf, 0xce, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(e.buf[len(e.buf)-4:], uint32(v))
+	default:
+		e.buf = append(e.buf, 0xcf, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(e.buf[len(e.buf)-8:], v)
+	}
+}
+
+func (e *msgpackEncoder) float(f float64) {
+	e.buf = append(e.buf, 0xcb, 0, 0, 0, 0, 0, 0, 0, 0)
+	binary.BigEndian.PutUint64(e.buf[len(e.buf)-8:], math.Float64bits(f))
+}
+
+// msgpackDecoder reads msgpack values, keeping first error
+type msgpackDecoder struct {
+	buf []byte
+	err error
+}
+
+func (d *msgpackDecoder) fail(msg string) {
+	if d.err == nil {
+		d.err = fmt.Errorf("%w: msgpack %s", ErrInvalidData, msg)
+	}
+	d.buf = nil
+}
+
+// next consumes n bytes
+func (d *msgpackDecoder) next(n int) []byte {
+	if n < 0 || len(d.buf) < n {
+		d.fail("truncated")
+		return make([]byte, 8)
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+// bigEndian reads unsigned integer of n bytes
+func (d *msgpackDecoder) bigEndian(n int) uint64 {
+	v := uint64(0)
+	for _, x := range d.next(n)[:n] {
+		v = v<<8 | uint64(x)
+	}
+	return v
+}
+
+// size reads length of n bytes
+func (d *msgpackDecoder) size(n int) int {
+	return int(d.bigEndian(n))
+}
+
+// length reads header of kind "map", "array" or "str"
+func (d *msgpackDecoder) length(kind string) int {
+	c := d.next(1)[0]
+	var n int
+	switch {
+	case kind == "map" && c&0xf0 == 0x80, kind == "array" && c&0xf0 == 0x90:
+		n = int(c & 0x0f)
+	case kind == "str" && c&0xe0 == 0xa0:
+		n = int(c & 0x1f)
+	case kind == "str" && c == 0xd9:
+		n = d.size(1)
+	case kind == "map" && c == 0xde, kind == "array" && c == 0xdc, kind == "str" && c == 0xda:
+		n = d.size(2)
+	case kind == "map" && c == 0xdf, kind == "array" && c == 0xdd, kind == "str" && c == 0xdb:
+		n = d.size(4)
+	default:
+		d.fail(fmt.Sprintf("expected %s, got 0x%02x", kind, c))
+		return 0
+	}
+	// every element takes a byte at least
+	if n > len(d.buf) {
+		d.fail("length exceeds data")
+		return 0
+	}
+	return n
+}
+
+func (d *msgpackDecoder) mapLen() int   { return d.length("map") }
+func (d *msgpackDecoder) arrayLen() int { return d.length("array") }
+
+func (d *msgpackDecoder) string() string {
+	return string(d.next(d.length("str")))
+}
+
+func (d *msgpackDecoder) uint() uint64 {
+	c := d.next(1)[0]
+	switch {
+	case c < 0x80:
+		return uint64(c)
+	case c == 0xcc, c == 0xcd, c == 0xce, c == 0xcf:
+		return d.bigEndian(1 << (c - 0xcc))
+	case c == 0xd0, c == 0xd1, c == 0xd2, c == 0xd3:
+		// signed formats of non-negative values
+		v := int64(d.bigEndian(1 << (c - 0xd0)))
+		if shift := 64 - 8*(1<<(c-0xd0)); shift > 0 {
+			v = v << shift >> shift
+		}
+		if v >= 0 {
+			return uint64(v)
+		}
+	}
+	d.fail(fmt.Sprintf("expected unsigned integer, got 0x%02x", c))
+	return 0
+}
+
+func (d *msgpackDecoder) bool() bool {
+	switch c := d.next(1)[0]; c {
+	case 0xc2:
+		return false
+	case 0xc3:
+		return true
+	default:
+		d.fail(fmt.Sprintf("expected bool, got 0x%02x", c))
+		return false
+	}
+}
+
+// float reads float or, as other encoders may write whole numbers,
+// unsigned integer
+func (d *msgpackDecoder) float() float64 {
+	if len(d.buf) > 0 {
+		switch d.buf[0] {
+		case 0xca:
+			d.next(1)
+			return float64(math.Float32frombits(uint32(d.bigEndian(4))))
+		case 0xcb:
+			d.next(1)
+			return math.Float64frombits(d.bigEndian(8))
+		}
+	}
+	return float64(d.uint())
+}
+
+func (d *msgpackDecoder) node() NodeConfig {
+	var n NodeConfig
+	for k := d.mapLen(); k > 0 && d.err == nil; k-- {
+		switch d.string() {
+		case "node":
+			n.Node = d.string()
+		case "vnodes":
+			n.VNodes = int(d.uint())
+		case "capacity":
+			n.Capacity = d.float()
+		case "token":
+			n.Token = d.string()
+		case "address":
+			n.Address = d.string()
+		case "explicit":
+			n.Explicit = d.bool()
+		case "meta":
+			n.Meta = make(map[string]string)
+			for m := d.mapLen(); m > 0 && d.err == nil; m-- {
+				key := d.string()
+				n.Meta[key] = d.string()
+			}
+		default:
+			d.skip()
+		}
+	}
+	return n
+}
+
+// skip consumes one value of any type
+func (d *msgpackDecoder) skip() {
+	c := d.next(1)[0]
+	switch {
+	case c < 0x80, c >= 0xe0, c == 0xc0, c == 0xc2, c == 0xc3:
+	case c&0xf0 == 0x80:
+		d.skipN(2 * int(c&0x0f))
+	case c&0xf0 == 0x90:
+		d.skipN(int(c & 0x0f))
+	case c&0xe0 == 0xa0:
+		d.next(int(c & 0x1f))
+	case c == 0xc4, c == 0xd9:
+		d.next(d.size(1))
+	case c == 0xc5, c == 0xda:
+		d.next(d.size(2))
+	case c == 0xc6, c == 0xdb:
+		d.next(d.size(4))
+	case c == 0xc7:
+		n := d.size(1)
+		d.next(1 + n)
+	case c == 0xc8:
+		n := d.size(2)
+		d.next(1 + n)
+	case c == 0xc9:
+		n := d.size(4)
+		d.next(1 + n)
+	case c == 0xca, c == 0xce, c == 0xd2:
+		d.next(4)
+	case c == 0xcb, c == 0xcf, c == 0xd3:
+		d.next(8)
+	case c == 0xcc, c == 0xd0:
+		d.next(1)
+	case c == 0xcd, c == 0xd1:
+		d.next(2)
+	case c >= 0xd4 && c <= 0xd8:
+		// fixext 1, 2, 4, 8, 16 with type byte
+		d.next(1 + 1<<(c-0xd4))
+	case c == 0xdc:
+		d.skipN(d.size(2))
+	case c == 0xdd:
+		d.skipN(d.size(4))
+	case c == 0xde:
+		d.skipN(2 * d.size(2))
+	case c == 0xdf:
+		d.skipN(2 * d.size(4))
+	default:
+		d.fail(fmt.Sprintf("unknown format 0x%02x", c))
+	}
+}
+
+func (d *msgpackDecoder) skipN(n int) {
+	if n > len(d.buf) {
+		d.fail("length exceeds data")
+		return
+	}
+	for ; n > 0 && d.err == nil; n-- {
+		d.skip()
+	}
+}
@@ -0,0 +1,180 @@
+package consistent
+
+import "bytes"
+import "errors"
+import "fmt"
+import "reflect"
+import "testing"
+
+func TestMsgpack(t *testing.T) {
+	c := NewConsistentWithOptions(WithReplicas(20), WithHashName("xxhash64"), WithSeed(1<<40))
+	c.AddNodes([]string{"node1", "node2"})
+	c.AddNodeWithCapacity("node3", 2.5)
+	c.AddNodeInfo(Node{ID: "node4", Address: "10.0.0.4:11211", Meta: map[string]string{ZoneLabel: "z1", "rack": "r2"}})
+	c.ReplaceNode("node1", "node5")
+	data, err := c.MarshalMsgpack()
+	if err != nil {
+		t.Fatalf("MarshalMsgpack %v\n", err)
+	}
+
+	var got Consistent
+	if err := got.UnmarshalMsgpack(data); err != nil {
+		t.Fatalf("UnmarshalMsgpack %v\n", err)
+	}
+	if !reflect.DeepEqual(got.Config(), c.Config()) || got.Fingerprint() != c.Fingerprint() {
+		t.Errorf("decoded config exp: %+v, got %+v\n", c.Config(), got.Config())
+	}
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprint(i)
+		exp, _ := c.GetNode(key)
+		if node, _ := got.GetNode(key); node != exp {
+			t.Fatalf("decoded ring GetNode(%v) exp: %v, got %v\n", key, exp, node)
+		}
+	}
+	if again, _ := got.MarshalMsgpack(); !bytes.Equal(again, data) {
+		t.Errorf("re-encoded exp: %x, got %x\n", data, again)
+	}
+
+	// msgp style appends to given buffer and returns the rest
+	buf, _ := c.MarshalMsg([]byte("head"))
+	rest, err := got.UnmarshalMsg(append(buf[4:], "tail"...))
+	if err != nil || string(rest) != "tail" {
+		t.Errorf("UnmarshalMsg rest exp: tail, got %q %v\n", rest, err)
+	}
+}
+
+func TestMsgpackVersions(t *testing.T) {
+	exp, _ := NewConsistentFromConfig(Config{Replicas: 10, Hash: "crc64", Nodes: []NodeConfig{
+		{Node: "a", VNodes: 10},
+		{Node: "b", VNodes: 20, Capacity: 2, Meta: map[string]string{"k": "v"}},
+	}})
+
+	// oldest writer: only the keys required since version 1
+	var old msgpackEncoder
+	old.mapLen(3)
+	old.string("v")
+	old.uint(1)
+	old.string("replicas")
+	old.uint(10)
+	old.string("nodes")
+	old.arrayLen(2)
+	old.mapLen(2)
+	old.string("node")
+	old.string("a")
+	old.string("vnodes")
+	old.uint(10)
+	old.mapLen(4)
+	old.string("node")
+	old.string("b")
+	old.string("vnodes")
+	old.uint(20)
+	old.string("capacity")
+	old.buf = append(old.buf, 0x02) // whole number as integer
+	old.string("meta")
+	old.mapLen(1)
+	old.string("k")
+	old.string("v")
+
+	// newer writer: keys unknown to this version of every type
+	var newer msgpackEncoder
+	newer.mapLen(6)
+	newer.string("zones")
+	newer.mapLen(1)
+	newer.string("z1")
+	newer.buf = append(newer.buf, 0x92, 0xc0, 0xc3) // [nil, true]
+	newer.string("v")
+	newer.uint(1)
+	newer.string("replicas")
+	newer.buf = append(newer.buf, 0xd0, 10) // int8
+	newer.string("hash")
+	newer.string("crc64")
+	newer.string("checksum")
+	newer.buf = append(newer.buf, 0xc4, 2, 0xbe, 0xef) // bin8
+	newer.string("nodes")
+	newer.arrayLen(2)
+	newer.mapLen(4)
+	newer.string("pinned")
+	newer.buf = append(newer.buf, 0xd6, 0xff, 1, 2, 3, 4) // timestamp ext
+	newer.string("node")
+	newer.string("a")
+	newer.string("vnodes")
+	newer.uint(10)
+	newer.string("weight")
+	newer.buf = append(newer.buf, 0xca, 0x3f, 0x80, 0, 0) // float32
+	newer.mapLen(5)
+	newer.string("node")
+	newer.string("b")
+	newer.string("vnodes")
+	newer.uint(20)
+	newer.string("capacity")
+	newer.buf = append(newer.buf, 0xca, 0x40, 0, 0, 0) // float32 2
+	newer.string("meta")
+	newer.mapLen(1)
+	newer.string("k")
+	newer.string("v")
+	newer.string("offset")
+	newer.buf = append(newer.buf, 0xff) // negative fixint
+
+	current, _ := exp.MarshalMsgpack()
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"current", current},
+		{"old", old.buf},
+		{"newer", newer.buf},
+	}
+	for _, tt := range tests {
+		var got Consistent
+		if err := got.UnmarshalMsgpack(tt.data); err != nil {
+			t.Errorf("%s UnmarshalMsgpack %v\n", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got.Config(), exp.Config()) {
+			t.Errorf("%s decoded config exp: %+v, got %+v\n", tt.name, exp.Config(), got.Config())
+		}
+		if data, _ := got.MarshalMsgpack(); !bytes.Equal(data, current) {
+			t.Errorf("%s re-encoded exp: %x, got %x\n", tt.name, current, data)
+		}
+	}
+}
+
+func TestMsgpackErrors(t *testing.T) {
+	c := NewConsistentWithOptions(WithReplicas(10))
+	c.AddNodes([]string{"node1", "node2"})
+	data, _ := c.MarshalMsgpack()
+	version := func(v ...uint64) []byte {
+		var e msgpackEncoder
+		e.mapLen(1 + len(v))
+		for _, v := range v {
+			e.string("v")
+			e.uint(v)
+		}
+		e.string("replicas")
+		e.uint(10)
+		return e.buf
+	}
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"version", version(2), ErrBadVersion},
+		{"no version", version(), ErrBadVersion},
+		{"truncated", data[:len(data)-3], ErrInvalidData},
+		{"trailing", append(append([]byte{}, data...), 0xc0), ErrInvalidData},
+		{"not map", []byte{0x91, 0x01}, ErrInvalidData},
+		{"big length", []byte{0xdf, 0xff, 0xff, 0xff, 0xff}, ErrInvalidData},
+		{"empty", nil, ErrInvalidData},
+	}
+	for _, tt := range tests {
+		got := NewConsistent()
+		got.AddNode("node3")
+		if err := got.UnmarshalMsgpack(tt.data); !errors.Is(err, tt.err) {
+			t.Errorf("%s UnmarshalMsgpack exp: %v, got %v\n", tt.name, tt.err, err)
+		}
+		if !got.HasNode("node3") {
+			t.Errorf("%s error exp: ring kept, got %v\n", tt.name, got.Members())
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package consistent
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+const (
+	murmurC1 uint64 = 0x87c37b91114253d5
+	murmurC2 uint64 = 0x4cf5ad432745937f
+)
+
+// Murmur3 is first half of MurmurHash3 x64_128 with seed 0, as used for
+// tokens by Cassandra's Murmur3Partitioner (token is int64 of it) and by
+// Guava and mmh3 hash64. Cassandra sign-extends tail bytes, so keys whose
+// last len%16 bytes have high bit set hash differently there.
+func Murmur3(key []byte) uint64 {
+	h1, _ := murmur3x64(key, 0)
+	return h1
+}
+
+// murmur3x64 is MurmurHash3 x64_128 returning both halves
+func murmur3x64(key []byte, seed uint64) (uint64, uint64) {
+	n := len(key)
+	h1, h2 := seed, seed
+	for ; len(key) >= 16; key = key[16:] {
+		k1 := binary.LittleEndian.Uint64(key)
+		k2 := binary.LittleEndian.Uint64(key[8:])
+		h1 ^= murmurK1(k1)
+		h1 = bits.RotateLeft64(h1, 27) + h2
+		h1 = h1*5 + 0x52dce729
+		h2 ^= murmurK2(k2)
+		h2 = bits.RotateLeft64(h2, 31) + h1
+		h2 = h2*5 + 0x38495ab5
+	}
+	var k1, k2 uint64
+	for i := len(key) - 1; i >= 8; i-- {
+		k2 = k2<<8 | uint64(key[i])
+	}
+	if len(key) > 8 {
+		h2 ^= murmurK2(k2)
+	}
+	tail := key
+	if len(tail) > 8 {
+		tail = tail[:8]
+	}
+	for i := len(tail) - 1; i >= 0; i-- {
+		k1 = k1<<8 | uint64(tail[i])
+	}
+	if len(key) > 0 {
+		h1 ^= murmurK1(k1)
+	}
+	h1 ^= uint64(n)
+	h2 ^= uint64(n)
+	h1 += h2
+	h2 += h1
+	h1, h2 = mix64(h1), mix64(h2)
+	h1 += h2
+	h2 += h1
+	return h1, h2
+}
+
+func murmurK1(k uint64) uint64 {
+	return bits.RotateLeft64(k*murmurC1, 31) * murmurC2
+}
+
+func murmurK2(k uint64) uint64 {
+	return bits.RotateLeft64(k*murmurC2, 33) * murmurC1
+}
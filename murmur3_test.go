@@ -0,0 +1,25 @@
+package consistent
+
+import "testing"
+
+// vectors shared with Guava Hashing.murmur3_128 and Python mmh3.hash64
+func TestMurmur3(t *testing.T) {
+	cases := []struct {
+		in     string
+		h1, h2 uint64
+	}{
+		{"", 0, 0},
+		{"The quick brown fox jumps over the lazy dog", 0xe34bbc7bbc071b6c, 0x7a433ca9c49a9347},
+		// mmh3 signed (-2129773440516405919, 9128664383759220103)
+		{"foo", 0xe271865701f54561, 0x7eaf87e42bba7d87},
+	}
+	for _, cs := range cases {
+		h1, h2 := murmur3x64([]byte(cs.in), 0)
+		if h1 != cs.h1 || h2 != cs.h2 {
+			t.Errorf("murmur3x64(%q) exp: %x %x, got %x %x\n", cs.in, cs.h1, cs.h2, h1, h2)
+		}
+		if got := Murmur3([]byte(cs.in)); got != cs.h1 {
+			t.Errorf("Murmur3(%q) exp: %x, got %x\n", cs.in, cs.h1, got)
+		}
+	}
+}
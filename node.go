@@ -0,0 +1,60 @@
+package consistent
+
+// Node describes a physical node, ID is what gets hashed onto the ring
+type Node struct {
+	ID      string
+	Address string
+	Meta    map[string]string // labels such as zone, host
+}
+
+func (n Node) copy() Node {
+	if n.Meta != nil {
+		meta := make(map[string]string, len(n.Meta))
+		for k, v := range n.Meta {
+			meta[k] = v
+		}
+		n.Meta = meta
+	}
+	return n
+}
+
+// AddNodeInfo adds node n.ID with default replicas and keeps n for
+// GetNodeInfo. Info of an existing node is replaced, its placement stays.
+func (c *Consistent) AddNodeInfo(n Node) {
+	c.lock()
+	defer c.unlock()
+	if _, ok := c.node[n.ID]; !ok {
+		c.addNode(n.ID, c.replicas)
+	}
+	c.info[n.ID] = n.copy()
+	c.changedInfo(n.ID)
+}
+
+// NodeInfo returns info of node, node added without info only has ID set
+func (c *Consistent) NodeInfo(node string) (Node, bool) {
+	c.rlock()
+	defer c.runlock()
+	return c.nodeInfo(node)
+}
+
+func (c *Consistent) nodeInfo(node string) (Node, bool) {
+	if _, ok := c.node[node]; !ok {
+		return Node{}, false
+	}
+	if n, ok := c.info[node]; ok {
+		return n.copy(), true
+	}
+	return Node{ID: node}, true
+}
+
+// GetNodeInfo is GetNode returning info of found node
+func (c *Consistent) GetNodeInfo(key string) (Node, error) {
+	c.rlock()
+	defer c.runlock()
+	node, err := c.lookup([]byte(key))
+	if err != nil {
+		return Node{}, err
+	}
+	n, _ := c.nodeInfo(node)
+	return n, nil
+}
@@ -0,0 +1,33 @@
+package consistent
+
+import "testing"
+
+func TestNodeInfo(t *testing.T) {
+	c := NewConsistent()
+	if _, err := c.GetNodeInfo("xxx"); err == nil {
+		t.Errorf("GetNodeInfo should fail on empty ring\n")
+	}
+
+	meta := map[string]string{"zone": "us-east-1a"}
+	c.AddNodeInfo(Node{ID: "node1", Address: "10.0.0.1:50051", Meta: meta})
+	meta["zone"] = "changed"
+	n, err := c.GetNodeInfo("xxx")
+	if err != nil || n.Address != "10.0.0.1:50051" || n.Meta["zone"] != "us-east-1a" {
+		t.Errorf("GetNodeInfo err: %v, got: %v\n", err, n)
+	}
+
+	c.AddNode("node2")
+	if n, ok := c.NodeInfo("node2"); !ok || n.ID != "node2" || n.Address != "" {
+		t.Errorf("Wrong NodeInfo of plain node, got: %v\n", n)
+	}
+
+	c.AddNodeInfo(Node{ID: "node1", Address: "10.0.0.2:50051"})
+	if n, _ := c.NodeInfo("node1"); n.Address != "10.0.0.2:50051" || c.ring.Len() != 2*DefaultReplica {
+		t.Errorf("AddNodeInfo should replace info only, got: %v\n", n)
+	}
+
+	c.RemoveNode("node1")
+	if _, ok := c.NodeInfo("node1"); ok {
+		t.Errorf("NodeInfo of removed node should be gone\n")
+	}
+}
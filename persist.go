@@ -0,0 +1,97 @@
+package consistent
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"sort"
+)
+
+// snapshotVersion is format version written by SaveSnapshot
+const snapshotVersion = 1
+
+var snapshotMagic = [3]byte{'C', 'H', 'S'}
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// SaveSnapshot writes exact state of consistent to w: settings, nodes
+// with tokens and info, virtual nodes as placed and pins, followed by
+// CRC-32C checksum. LoadSnapshot restores it after restart without
+// rehashing, keeping manual overrides which service discovery doesn't
+// know of.
+func (c *Consistent) SaveSnapshot(w io.Writer) error {
+	c.rlock()
+	e := encoder{}
+	e.buf = append(e.buf, snapshotMagic[:]...)
+	e.buf = append(e.buf, snapshotVersion)
+	ring := c.appendBinary(nil, true)
+	e.uvarint(uint64(len(ring)))
+	e.buf = append(e.buf, ring...)
+	keys := make([]string, 0, len(c.pins))
+	for k := range c.pins {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	e.uvarint(uint64(len(keys)))
+	for _, k := range keys {
+		e.string(k)
+		e.string(c.pins[k])
+	}
+	c.runlock()
+	var sum [4]byte
+	binary.LittleEndian.PutUint32(sum[:], crc32.Checksum(e.buf, castagnoli))
+	e.buf = append(e.buf, sum[:]...)
+	_, err := w.Write(e.buf)
+	return err
+}
+
+// LoadSnapshot replaces state of consistent, pins included, by snapshot
+// of SaveSnapshot read from r till EOF. Corrupted snapshot fails with
+// ErrChecksum and leaves consistent as is, so does snapshot of points
+// consistent places elsewhere, with ErrPlacement, see MarshalBinary.
+func (c *Consistent) LoadSnapshot(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if len(data) < len(snapshotMagic)+1+4 || string(data[:len(snapshotMagic)]) != string(snapshotMagic[:]) {
+		return fmt.Errorf("%w: bad snapshot magic", ErrInvalidData)
+	}
+	body, sum := data[:len(data)-4], binary.LittleEndian.Uint32(data[len(data)-4:])
+	if crc32.Checksum(body, castagnoli) != sum {
+		return ErrChecksum
+	}
+	if v := data[len(snapshotMagic)]; v != snapshotVersion {
+		return fmt.Errorf("%w: %d", ErrBadVersion, v)
+	}
+	d := decoder{buf: body[len(snapshotMagic)+1:]}
+	n := d.count()
+	ring := d.buf[:n]
+	d.buf = d.buf[n:]
+	pins := make(map[string]string)
+	for k := d.count(); k > 0 && d.err == nil; k-- {
+		key := d.string()
+		pins[key] = d.string()
+	}
+	if err := d.done(); err != nil {
+		return err
+	}
+	cfg, placed, err := decodeBinary(ring)
+	if err != nil {
+		return err
+	}
+	c.lock()
+	defer c.unlock()
+	c.init()
+	// log state with its pins
+	defer c.logStateAfter()()
+	if err := c.setConfig(cfg, placed); err != nil {
+		return err
+	}
+	c.pins = nil
+	if len(pins) > 0 {
+		c.pins = pins
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package consistent
+
+import "bytes"
+import "errors"
+import "fmt"
+import "reflect"
+import "testing"
+
+func TestSnapshotSaveLoad(t *testing.T) {
+	c := NewConsistentWithOptions(WithReplicas(20), WithHashName("xxhash64"))
+	c.AddNodes([]string{"node1", "node2", "node3"})
+	c.AddNodeInfo(Node{ID: "node4", Address: "10.0.0.4:11211", Meta: map[string]string{ZoneLabel: "z1"}})
+	c.ReplaceNode("node1", "node5")
+	c.UpdateWeight("node2", 2)
+	c.PinKey("user:1", "node3")
+	c.PinKey("user:2", "node4")
+	c.RemoveNode("node4") // pin kept for return of node4
+
+	var buf bytes.Buffer
+	if err := c.SaveSnapshot(&buf); err != nil {
+		t.Fatalf("SaveSnapshot %v\n", err)
+	}
+	got := NewConsistent()
+	got.AddNode("other")
+	got.PinKey("user:3", "other")
+	if err := got.LoadSnapshot(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("LoadSnapshot %v\n", err)
+	}
+	if !reflect.DeepEqual(got.Config(), c.Config()) || got.Fingerprint() != c.Fingerprint() {
+		t.Errorf("restored config exp: %+v, got %+v\n", c.Config(), got.Config())
+	}
+	if !reflect.DeepEqual(got.Pins(), c.Pins()) {
+		t.Errorf("restored pins exp: %v, got %v\n", c.Pins(), got.Pins())
+	}
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprint("user:", i)
+		exp, _ := c.GetNode(key)
+		if node, _ := got.GetNode(key); node != exp {
+			t.Fatalf("restored GetNode(%v) exp: %v, got %v\n", key, exp, node)
+		}
+	}
+	if node, _ := got.GetNode("user:1"); node != "node3" {
+		t.Errorf("pinned GetNode exp: node3, got %v\n", node)
+	}
+	var again bytes.Buffer
+	got.SaveSnapshot(&again)
+	if !bytes.Equal(again.Bytes(), buf.Bytes()) {
+		t.Errorf("saved restored snapshot exp: same bytes, got %x\n", again.Bytes())
+	}
+}
+
+func TestSnapshotPlacement(t *testing.T) {
+	c := NewConsistentWithOptions(WithPlacement(DoubleHashPlacement), WithReplicas(20))
+	c.AddNodes([]string{"node1", "node2", "node3"})
+	var buf bytes.Buffer
+	c.SaveSnapshot(&buf)
+
+	other := NewConsistent()
+	other.AddNode("other")
+	if err := other.LoadSnapshot(bytes.NewReader(buf.Bytes())); !errors.Is(err, ErrPlacement) {
+		t.Errorf("LoadSnapshot of other placement exp: %v, got %v\n", ErrPlacement, err)
+	}
+	if members := other.Members(); !reflect.DeepEqual(members, []string{"other"}) {
+		t.Errorf("failed LoadSnapshot exp: consistent kept, got %v\n", members)
+	}
+
+	got := NewConsistentWithOptions(WithPlacement(DoubleHashPlacement))
+	if err := got.LoadSnapshot(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("LoadSnapshot %v\n", err)
+	}
+	// changes after load find points of loaded nodes
+	for _, cc := range []*Consistent{c, got} {
+		cc.RemoveNode("node1")
+		cc.SetVirtualNodes("node2", 5)
+		cc.AddNode("node4")
+	}
+	if got.Fingerprint() != c.Fingerprint() || got.ring.Len() != c.ring.Len() {
+		t.Errorf("changed after load exp: %v points, got %v\n", c.ring.Len(), got.ring.Len())
+	}
+	sameLookups(t, c, got)
+}
+
+func TestSnapshotLoadErrors(t *testing.T) {
+	c := NewConsistent()
+	c.AddNodes([]string{"node1", "node2"})
+	c.PinKey("key", "node1")
+	var buf bytes.Buffer
+	c.SaveSnapshot(&buf)
+	data := buf.Bytes()
+	flip := func(i int) []byte {
+		b := append([]byte{}, data...)
+		b[i] ^= 1
+		return b
+	}
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"ring flipped", flip(len(data) / 2), ErrChecksum},
+		{"checksum flipped", flip(len(data) - 1), ErrChecksum},
+		{"truncated", data[:len(data)-1], ErrChecksum},
+		{"magic", flip(0), ErrInvalidData},
+		{"empty", nil, ErrInvalidData},
+	}
+	for _, tt := range tests {
+		got := NewConsistent()
+		got.AddNode("node3")
+		if err := got.LoadSnapshot(bytes.NewReader(tt.data)); !errors.Is(err, tt.err) {
+			t.Errorf("%s LoadSnapshot exp: %v, got %v\n", tt.name, tt.err, err)
+		}
+		if !got.HasNode("node3") {
+			t.Errorf("%s error exp: ring kept, got %v\n", tt.name, got.Members())
+		}
+	}
+}
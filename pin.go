@@ -0,0 +1,96 @@
+package consistent
+
+// PinKey forces key to node in GetNode and GetNNode family lookups,
+// ahead of the ring. Pins survive topology changes: while pinned node is
+// not on the ring, key falls back to ring lookup. Key is pinned in its
+// normalized form, see WithKeyNormalizer.
+func (c *Consistent) PinKey(key, node string) error {
+	c.lock()
+	defer c.unlock()
+	if _, ok := c.node[node]; !ok {
+		return ErrNodeNotFound
+	}
+	if c.pins == nil {
+		c.pins = make(map[string]string)
+	}
+	key = string(c.normalized([]byte(key)))
+	c.pins[key] = node
+	c.loggedPin(key, node)
+	return nil
+}
+
+// UnpinKey removes pin of key
+func (c *Consistent) UnpinKey(key string) {
+	c.lock()
+	defer c.unlock()
+	key = string(c.normalized([]byte(key)))
+	if _, ok := c.pins[key]; ok {
+		delete(c.pins, key)
+		c.loggedPin(key, "")
+	}
+}
+
+// Pins returns copy of pinned key to node table
+func (c *Consistent) Pins() map[string]string {
+	c.rlock()
+	defer c.runlock()
+	pins := make(map[string]string, len(c.pins))
+	for k, v := range c.pins {
+		pins[k] = v
+	}
+	return pins
+}
+
+// pinned returns node key is pinned to, if it's on the ring
+func (c *Consistent) pinned(key []byte) (string, bool) {
+	node, ok := c.pins[string(c.normalized(key))]
+	if !ok {
+		return "", false
+	}
+	_, ok = c.node[node]
+	return node, ok
+}
+
+// lookup is GetNode consulting pins first
+func (c *Consistent) lookup(key []byte) (string, error) {
+	if node, ok := c.pinned(key); ok {
+		return node, nil
+	}
+	return c.getNode(c.hashKey(key))
+}
+
+// lookupN is GetNNode with pinned node first, topped up from the ring
+func (c *Consistent) lookupN(key []byte, n int) ([]string, error) {
+	if n > c.count {
+		return []string{}, ErrNotEnoughNodes
+	}
+	return enoughNodes(c.ownersN(key, n), n)
+}
+
+// ownersN is lookupN returning nodes found even if less than n
+func (c *Consistent) ownersN(key []byte, n int) []string {
+	ind := c.ring.Search(c.hashKey(key))
+	if node, ok := c.pinned(key); ok && n > 0 {
+		return pinnedNodes(c.ring, ind, node, n)
+	}
+	return distinctNodes(c.ring, ind, n)
+}
+
+// collectPinned is collectNodes from position of key, offering node key
+// is pinned to first like lookupN, so every walk of the ring starts where
+// GetNode and GetNNode do
+func (c *Consistent) collectPinned(key []byte, n int, accept func(string) bool) []string {
+	var nodes []string
+	if pin, ok := c.pinned(key); ok && n > 0 && (accept == nil || accept(pin)) {
+		nodes = append(nodes, pin)
+	}
+	ind := c.ring.Search(c.hashKey(key))
+	return append(nodes, collectNodes(c.ring, ind, n-len(nodes), func(node string) bool {
+		return !stringInSlice(nodes, node) && (accept == nil || accept(node))
+	})...)
+}
+
+// pinnedNodes returns pin followed by n-1 other distinct nodes from ind
+func pinnedNodes(r ring, ind int, pin string, n int) []string {
+	return appendDistinct([]string{pin}, 0, r, ind, n)
+}
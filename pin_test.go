@@ -0,0 +1,127 @@
+package consistent
+
+import "errors"
+import "fmt"
+import "reflect"
+import "testing"
+
+func TestPinKey(t *testing.T) {
+	c := NewConsistent()
+	c.AddNodes([]string{"node1", "node2", "node3", "node4", "node5"})
+	owner, _ := c.GetNode("Abc")
+	pin := "node3"
+	if owner == pin {
+		pin = "node4"
+	}
+
+	if err := c.PinKey("Abc", "node9"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("PinKey err, exp: %v, got: %v\n", ErrNodeNotFound, err)
+	}
+	if err := c.PinKey("Abc", pin); err != nil {
+		t.Fatalf("PinKey err: %v\n", err)
+	}
+	if node, _ := c.GetNode("Abc"); node != pin {
+		t.Errorf("Pinned GetNode, exp: %v, got: %v\n", pin, node)
+	}
+	nodes, err := c.GetNNode("Abc", 3)
+	if err != nil || len(nodes) != 3 || nodes[0] != pin || stringInSlice(nodes[1:], pin) {
+		t.Errorf("Pinned GetNNode err: %v, got: %v\n", err, nodes)
+	}
+	if m, _ := c.GetNodes([]string{"Abc"}); m["Abc"] != pin {
+		t.Errorf("Pinned GetNodes, exp: %v, got: %v\n", pin, m["Abc"])
+	}
+	if node, _ := c.Snapshot().GetNode("Abc"); node != pin {
+		t.Errorf("Pinned snapshot GetNode, exp: %v, got: %v\n", pin, node)
+	}
+	if !reflect.DeepEqual(c.Pins(), map[string]string{"Abc": pin}) {
+		t.Errorf("Wrong Pins(), got %v\n", c.Pins())
+	}
+
+	// pin survives node leaving and coming back
+	c.RemoveNode(pin)
+	if node, _ := c.GetNode("Abc"); node != owner {
+		t.Errorf("GetNode should fall back to ring, exp: %v, got: %v\n", owner, node)
+	}
+	c.AddNode(pin)
+	if node, _ := c.GetNode("Abc"); node != pin {
+		t.Errorf("Pin should apply again, exp: %v, got: %v\n", pin, node)
+	}
+
+	c.UnpinKey("Abc")
+	if node, _ := c.GetNode("Abc"); node != owner || len(c.Pins()) != 0 {
+		t.Errorf("Unpinned GetNode, exp: %v, got: %v\n", owner, node)
+	}
+}
+
+func TestPinKeyLookups(t *testing.T) {
+	c := NewConsistent()
+	for i, node := range []string{"node1", "node2", "node3", "node4", "node5"} {
+		c.AddNodeInfo(Node{ID: node, Meta: map[string]string{ZoneLabel: fmt.Sprint("z", i)}})
+	}
+	key := "hot"
+	owners, _ := c.GetNNode(key, 5)
+	pin := owners[2]
+	c.PinKey(key, pin)
+
+	first := func(nodes []string, err error) string {
+		if err != nil || len(nodes) == 0 {
+			return fmt.Sprint(err)
+		}
+		for i, node := range nodes {
+			if stringInSlice(nodes[i+1:], node) {
+				return fmt.Sprint("duplicate ", nodes)
+			}
+		}
+		return nodes[0]
+	}
+	node := func(node string, err error) string {
+		if err != nil {
+			return err.Error()
+		}
+		return node
+	}
+	primary, _, err := c.GetPrimaryAndReplicas(key, 2)
+	withHash, _, _ := c.GetNodeWithHash(key)
+	info, _ := c.GetNodeInfo(key)
+	nodes, _ := c.GetNodes([]string{key})
+	quorum, _, _ := c.GetQuorum(key, 3)
+	zoneQuorum, _, _ := c.GetZoneQuorum(key, 3)
+	all := func(string) bool { return true }
+	tests := []struct {
+		api string
+		got string
+	}{
+		{"GetNode", node(c.GetNode(key))},
+		{"GetNodeBytes", node(c.GetNodeBytes([]byte(key)))},
+		{"GetNodeWithHash", withHash},
+		{"GetNodeInfo", info.ID},
+		{"GetNodes", nodes[key]},
+		{"GetNodeFiltered", node(c.GetNodeFiltered(key, all))},
+		{"GetBestOfN", node(c.GetBestOfN(key, 1))},
+		{"GetNNode", first(c.GetNNode(key, 3))},
+		{"GetNNodeAppend", first(c.GetNNodeAppend(nil, key, 3))},
+		{"GetNNodeBytes", first(c.GetNNodeBytes([]byte(key), 3))},
+		{"Get3Node", first(c.Get3Node(key))},
+		{"GetUpToNNode", first(c.GetUpToNNode(key, 9))},
+		{"GetPrimaryAndReplicas", node(primary, err)},
+		{"GetNNodeExcluding", first(c.GetNNodeExcluding(key, 3, []string{owners[0]}))},
+		{"GetNNodeWithConstraints", first(c.GetNNodeWithConstraints(key, 3, AntiAffinity(ZoneLabel)))},
+		{"GetNNodeFiltered", first(c.GetNNodeFiltered(key, 3, all))},
+		{"GetNNodePerZone", first(c.GetNNodePerZone(key, 3))},
+		{"GetNNodeByLocality", first(c.GetNNodeByLocality(key, 3, func(Node) int { return 0 }))},
+		{"GetQuorum", first(quorum, nil)},
+		{"GetZoneQuorum", first(zoneQuorum, nil)},
+		{"Snapshot.GetNode", node(c.Snapshot().GetNode(key))},
+		{"Snapshot.GetNNode", first(c.Snapshot().GetNNode(key, 3))},
+	}
+	for _, tt := range tests {
+		if tt.got != pin {
+			t.Errorf("pinned %v exp: %v, got %v\n", tt.api, pin, tt.got)
+		}
+	}
+
+	got, err := c.GetNNodeExcluding(key, 3, []string{pin})
+	if exp := []string{owners[0], owners[1], owners[3]}; err != nil || !reflect.DeepEqual(got, exp) {
+		t.Errorf("excluded pin exp: %v, got %v %v\n", exp, got, err)
+	}
+}
@@ -0,0 +1,154 @@
+package consistent
+
+import (
+	"strconv"
+	"sync"
+)
+
+// parallelMinKeys is fewest virtual nodes worth a worker of their own
+const parallelMinKeys = 256
+
+// keyBufPool holds scratch buffers virtual node keys are derived in
+var keyBufPool = sync.Pool{New: func() interface{} { return new([]byte) }}
+
+// getKeyBuf returns pooled buffer holding node with room for index bytes
+func getKeyBuf(node []byte) *[]byte {
+	bp := keyBufPool.Get().(*[]byte)
+	if cap(*bp) < len(node)+8 {
+		*bp = make([]byte, 0, len(node)+8)
+	}
+	*bp = append((*bp)[:0], node...)
+	return bp
+}
+
+// PlacementFunc returns ring hashes of the first n virtual nodes of node.
+// Result for n must be a prefix of result for any larger n, so changing
+// replica number of a node only adds or removes the difference.
+type PlacementFunc func(node []byte, n int, fn HashFunc) []uint64
+
+// AppendPlacement hashes node name with little endian bytes of virtual node
+// index appended, index 0 appends nothing. It's the default and original
+// placement, keep it to stay compatible with existing rings. Keys are
+// those of AppendIndexKey.
+func AppendPlacement(node []byte, n int, fn HashFunc) []uint64 {
+	keys := make([]uint64, n)
+	bp := getKeyBuf(node)
+	for i := range keys {
+		keys[i] = appendHashKey(fn, *bp, i)
+	}
+	keyBufPool.Put(bp)
+	return keys
+}
+
+func appendHashKey(fn HashFunc, key []byte, i int) uint64 {
+	for i > 0 {
+		j := byte(i % 256)
+		i /= 256
+		key = append(key, j)
+	}
+	return fn(key)
+}
+
+// VNodeKeyFunc returns key hashed for i-th virtual node of node
+type VNodeKeyFunc func(node string, i int) []byte
+
+// WithVNodeKeyFunc derives virtual node keys by fn, see KeyPlacement
+func WithVNodeKeyFunc(fn VNodeKeyFunc) Option {
+	return WithPlacement(KeyPlacement(fn))
+}
+
+// KeyPlacement returns placement hashing keys of fn
+func KeyPlacement(fn VNodeKeyFunc) PlacementFunc {
+	return func(node []byte, n int, hash HashFunc) []uint64 {
+		keys := make([]uint64, n)
+		name := string(node)
+		for i := range keys {
+			keys[i] = hash(fn(name, i))
+		}
+		return keys
+	}
+}
+
+// AppendIndexKey is key of AppendPlacement: node followed by index in
+// base 256, least significant byte first, no bytes for index 0. E.g. 1 is
+// node+"\x01", 256 is node+"\x00\x01".
+func AppendIndexKey(node string, i int) []byte {
+	key := []byte(node)
+	for i > 0 {
+		key = append(key, byte(i%256))
+		i /= 256
+	}
+	return key
+}
+
+// SeparatorKey returns VNodeKeyFunc of node, sep and decimal index, e.g.
+// "node#3" for "#", easy to reproduce in other languages
+func SeparatorKey(sep string) VNodeKeyFunc {
+	return func(node string, i int) []byte {
+		return strconv.AppendInt([]byte(node+sep), int64(i), 10)
+	}
+}
+
+// ParallelAppendPlacement returns placement equal to AppendPlacement which
+// hashes virtual nodes on up to workers goroutines, for large replica
+// numbers. Hash function must be safe for concurrent use.
+func ParallelAppendPlacement(workers int) PlacementFunc {
+	return func(node []byte, n int, fn HashFunc) []uint64 {
+		w := workers
+		if max := n / parallelMinKeys; w > max {
+			w = max
+		}
+		if w <= 1 {
+			return AppendPlacement(node, n, fn)
+		}
+		keys := make([]uint64, n)
+		chunk := (n + w - 1) / w
+		var wg sync.WaitGroup
+		for lo := 0; lo < n; lo += chunk {
+			hi := lo + chunk
+			if hi > n {
+				hi = n
+			}
+			wg.Add(1)
+			go func(lo, hi int) {
+				defer wg.Done()
+				// own buffer, appendHashKey appends index bytes to it
+				bp := getKeyBuf(node)
+				for i := lo; i < hi; i++ {
+					keys[i] = appendHashKey(fn, *bp, i)
+				}
+				keyBufPool.Put(bp)
+			}(lo, hi)
+		}
+		wg.Wait()
+		return keys
+	}
+}
+
+// doubleHashSeed seeds second hash of DoubleHashPlacement
+const doubleHashSeed = 0x9e3779b97f4a7c15
+
+// DoubleHashPlacement places i-th virtual node at h1 + i*h2, where h1 is fn
+// of node name and h2 is WyHashSeeded of node name under a seed of its
+// own, made odd, so the two hashes are independent. Points spread over the
+// whole ring even when fn clusters similar inputs, which appending index
+// bytes produces for some hash functions.
+func DoubleHashPlacement(node []byte, n int, fn HashFunc) []uint64 {
+	keys := make([]uint64, n)
+	h1 := fn(node)
+	h2 := wyhash(node, doubleHashSeed) | 1
+	for i := range keys {
+		keys[i] = h1 + uint64(i)*h2
+	}
+	return keys
+}
+
+// mix64 is the finalizer of murmur3, scrambling bits of h
+func mix64(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
@@ -0,0 +1,217 @@
+package consistent
+
+import "fmt"
+import "math"
+import "reflect"
+import "sort"
+import "testing"
+
+func TestPlacementPrefix(t *testing.T) {
+	for _, p := range []PlacementFunc{AppendPlacement, DoubleHashPlacement, ParallelAppendPlacement(4)} {
+		short, long := p([]byte("node1"), 10, crc64h), p([]byte("node1"), 300, crc64h)
+		if !reflect.DeepEqual(short, long[:10]) {
+			t.Errorf("Placement is not prefix stable, exp: %v, got %v\n", long[:10], short)
+		}
+	}
+}
+
+func TestDoubleHashPlacement(t *testing.T) {
+	c := NewConsistentWithOptions(WithPlacement(DoubleHashPlacement))
+	c.AddNodes([]string{"node1", "node2", "node3"})
+	if c.ring.Len() != 3*DefaultReplica {
+		t.Errorf("Wrong virtual nodes, exp: %v, got %v\n", 3*DefaultReplica, c.ring.Len())
+	}
+
+	// fnv clusters appended index bytes, double hashing shouldn't
+	c = NewConsistentWithOptions(WithHashFunc(fnvh), WithPlacement(DoubleHashPlacement))
+	c.AddNodes([]string{"node1", "node2", "node3"})
+	hits := map[string]int{}
+	for i := 0; i < 3000; i++ {
+		node, _ := c.GetNode(fmt.Sprintf("key%v", i))
+		hits[node]++
+	}
+	for node, n := range hits {
+		if n < 500 {
+			t.Errorf("Unbalanced placement, %v got %v of 3000 keys\n", node, n)
+		}
+	}
+
+	c.RemoveNode("node2")
+	if c.ring.Len() != 2*DefaultReplica {
+		t.Errorf("Wrong virtual nodes, exp: %v, got %v\n", 2*DefaultReplica, c.ring.Len())
+	}
+}
+
+// maxShare returns largest keyspace share of nodes placed by p, relative
+// to even share
+func maxShare(p PlacementFunc, fn HashFunc, nodes, vnodes int) float64 {
+	var hashes []uint64
+	owner := map[uint64]int{}
+	for i := 0; i < nodes; i++ {
+		for _, h := range p([]byte(fmt.Sprintf("node%d", i)), vnodes, fn) {
+			hashes = append(hashes, h)
+			owner[h] = i
+		}
+	}
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+	share := make([]float64, nodes)
+	for i, h := range hashes {
+		// arc up to h, wrapping around
+		share[owner[h]] += float64(h - hashes[(i+len(hashes)-1)%len(hashes)])
+	}
+	max := 0.0
+	for _, s := range share {
+		max = math.Max(max, s)
+	}
+	return max / (math.Pow(2, 64) / float64(nodes))
+}
+
+func TestDoubleHashSpread(t *testing.T) {
+	hashes := []struct {
+		name string
+		fn   HashFunc
+	}{
+		{"xxhash64", XXHash64},
+		{"crc64", crc64h},
+		{"fnv", fnvh},
+	}
+	for _, h := range hashes {
+		appended, double := maxShare(AppendPlacement, h.fn, 20, 100), maxShare(DoubleHashPlacement, h.fn, 20, 100)
+		if double > 1.3 || double > math.Max(appended, 1.1)*1.1 {
+			t.Errorf("%s max share exp: about %.3f of default placement, got %.3f\n", h.name, appended, double)
+		}
+	}
+
+	// second hash doesn't derive from fn, nodes of equal fn hash still
+	// get points of their own
+	same := func([]byte) uint64 { return 42 }
+	a, b := DoubleHashPlacement([]byte("node1"), 10, same), DoubleHashPlacement([]byte("node2"), 10, same)
+	for i := 1; i < 10; i++ {
+		if a[i] == b[i] {
+			t.Errorf("point %v of nodes of equal hash exp: differ, got %x\n", i, a[i])
+		}
+	}
+}
+
+func TestParallelAppendPlacement(t *testing.T) {
+	for _, n := range []int{1, 100, 1000, 70000} {
+		exp := AppendPlacement([]byte("node1"), n, crc64h)
+		if got := ParallelAppendPlacement(8)([]byte("node1"), n, crc64h); !reflect.DeepEqual(got, exp) {
+			t.Errorf("ParallelAppendPlacement differs from AppendPlacement for n=%v\n", n)
+		}
+	}
+	c := NewConsistentWithOptions(WithParallelHashing(4), WithReplicas(1000))
+	plain := NewConsistentWithN(1000)
+	for i := 0; i < 3; i++ {
+		c.AddNode(fmt.Sprintf("node%d", i))
+		plain.AddNode(fmt.Sprintf("node%d", i))
+	}
+	for i := 0; i < 100; i++ {
+		exp, _ := plain.GetNode(fmt.Sprint(i))
+		if got, _ := c.GetNode(fmt.Sprint(i)); got != exp {
+			t.Errorf("WithParallelHashing GetNode exp: %v, got %v\n", exp, got)
+		}
+	}
+}
+
+func BenchmarkParallelAppendPlacement(b *testing.B) {
+	p := ParallelAppendPlacement(4)
+	for i := 0; i < b.N; i++ {
+		p([]byte("node1"), 10000, crc64h)
+	}
+}
+
+func BenchmarkAppendPlacementAllocs(b *testing.B) {
+	b.ReportAllocs()
+	node := []byte("node-with-a-longer-name.example.com:8080")
+	for i := 0; i < b.N; i++ {
+		AppendPlacement(node, 1000, crc64h)
+	}
+}
+
+// TestPlacementStability pins owners of built-in hashes, a failure means
+// upgrading would move keys of existing rings
+func TestPlacementStability(t *testing.T) {
+	keys := []string{"user1", "user2", "user3", "session:42", "cart/7"}
+	cases := []struct {
+		name string
+		fn   HashFunc
+		exp  []string
+	}{
+		{"crc64", crc64h, []string{"node1", "node2", "node1", "node2", "node4"}},
+		{"XXHash64", XXHash64, []string{"node1", "node1", "node2", "node4", "node1"}},
+		{"Murmur3", Murmur3, []string{"node4", "node3", "node3", "node1", "node2"}},
+		{"WyHash", WyHash, []string{"node2", "node3", "node2", "node2", "node3"}},
+	}
+	for _, cs := range cases {
+		c := NewConsistentWithHash(DefaultReplica, cs.fn)
+		c.AddNodes([]string{"node1", "node2", "node3", "node4"})
+		for i, key := range keys {
+			if got, _ := c.GetNode(key); got != cs.exp[i] {
+				t.Errorf("%v placement of %v drifted, exp: %v, got %v\n", cs.name, key, cs.exp[i], got)
+			}
+		}
+	}
+}
+
+func TestWithSeed(t *testing.T) {
+	nodes := []string{"node1", "node2", "node3", "node4"}
+	plain, zero := NewConsistent(), NewConsistentWithOptions(WithSeed(0))
+	a, b, again := NewConsistentWithOptions(WithSeed(1)), NewConsistentWithOptions(WithSeed(2)), NewConsistentWithOptions(WithSeed(1))
+	for _, c := range []*Consistent{plain, zero, a, b, again} {
+		c.AddNodes(nodes)
+	}
+	same := 0
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprint(i)
+		np, _ := plain.GetNode(key)
+		nz, _ := zero.GetNode(key)
+		na, _ := a.GetNode(key)
+		nb, _ := b.GetNode(key)
+		n1, _ := again.GetNode(key)
+		if np != nz || na != n1 {
+			t.Errorf("placement should depend only on seed, got %v %v and %v %v\n", np, nz, na, n1)
+		}
+		if na == nb {
+			same++
+		}
+	}
+	// unrelated rings of 4 nodes agree on about a quarter of keys
+	if same > 400 {
+		t.Errorf("seeded rings should be uncorrelated, %v of 1000 keys agree\n", same)
+	}
+	a.RemoveNode("node2")
+	if a.ring.Len() != 3*DefaultReplica {
+		t.Errorf("RemoveNode of seeded ring exp points: %v, got %v\n", 3*DefaultReplica, a.ring.Len())
+	}
+}
+
+func TestVNodeKeyFunc(t *testing.T) {
+	cases := []struct {
+		fn  VNodeKeyFunc
+		i   int
+		exp string
+	}{
+		{AppendIndexKey, 0, "node"},
+		{AppendIndexKey, 1, "node\x01"},
+		{AppendIndexKey, 256, "node\x00\x01"},
+		{SeparatorKey("#"), 0, "node#0"},
+		{SeparatorKey("-"), 42, "node-42"},
+	}
+	for _, cs := range cases {
+		if got := string(cs.fn("node", cs.i)); got != cs.exp {
+			t.Errorf("key of %v exp: %q, got %q\n", cs.i, cs.exp, got)
+		}
+	}
+	exp := AppendPlacement([]byte("node1"), 300, crc64h)
+	if got := KeyPlacement(AppendIndexKey)([]byte("node1"), 300, crc64h); !reflect.DeepEqual(got, exp) {
+		t.Errorf("KeyPlacement(AppendIndexKey) should match AppendPlacement\n")
+	}
+	c := NewConsistentWithOptions(WithVNodeKeyFunc(SeparatorKey("#")), WithReplicas(3))
+	c.AddNode("node1")
+	for _, k := range []string{"node1#0", "node1#1", "node1#2"} {
+		if _, ok := c.ring.Lookup(crc64h([]byte(k))); !ok {
+			t.Errorf("virtual node %v should be on ring\n", k)
+		}
+	}
+}
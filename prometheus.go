@@ -0,0 +1,75 @@
+//go:build prometheus
+
+package consistent
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// CollectorOpts configures Collector. Metrics are named
+// <Namespace>_<Subsystem>_<name>, Subsystem defaults to "ring".
+type CollectorOpts struct {
+	Namespace   string
+	Subsystem   string
+	ConstLabels prometheus.Labels // e.g. ring name when exporting several
+	NodeLabel   string            // label of per-node metrics, default "node"
+}
+
+// Collector is prometheus.Collector of consistent, reading its published
+// state on scrape. Build with -tags prometheus to use it.
+//
+// Metrics are nodes, points, ownership_share per node, lookups_total per
+// node (only with WithLookupCounters) and rebuild_duration_seconds.
+type Collector struct {
+	c        *Consistent
+	nodes    *prometheus.Desc
+	points   *prometheus.Desc
+	share    *prometheus.Desc
+	lookups  *prometheus.Desc
+	rebuilds *prometheus.Desc
+}
+
+// NewCollector returns collector of c, register it with
+// prometheus.MustRegister
+func NewCollector(c *Consistent, opts CollectorOpts) *Collector {
+	if opts.Subsystem == "" {
+		opts.Subsystem = "ring"
+	}
+	if opts.NodeLabel == "" {
+		opts.NodeLabel = "node"
+	}
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		fq := prometheus.BuildFQName(opts.Namespace, opts.Subsystem, name)
+		return prometheus.NewDesc(fq, help, labels, opts.ConstLabels)
+	}
+	return &Collector{
+		c:        c,
+		nodes:    desc("nodes", "Number of physical nodes."),
+		points:   desc("points", "Number of virtual nodes on the ring."),
+		share:    desc("ownership_share", "Fraction of keyspace owned by node.", opts.NodeLabel),
+		lookups:  desc("lookups_total", "Lookups resolved to node.", opts.NodeLabel),
+		rebuilds: desc("rebuild_duration_seconds", "Time spent publishing ring state after changes."),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (col *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- col.nodes
+	ch <- col.points
+	ch <- col.share
+	ch <- col.lookups
+	ch <- col.rebuilds
+}
+
+// Collect implements prometheus.Collector
+func (col *Collector) Collect(ch chan<- prometheus.Metric) {
+	s := col.c.current()
+	ch <- prometheus.MustNewConstMetric(col.nodes, prometheus.GaugeValue, float64(s.count))
+	ch <- prometheus.MustNewConstMetric(col.points, prometheus.GaugeValue, float64(s.ring.Len()))
+	for node, share := range arcShares(s.ring) {
+		ch <- prometheus.MustNewConstMetric(col.share, prometheus.GaugeValue, share, node)
+	}
+	for node, n := range col.c.LoadCounters() {
+		ch <- prometheus.MustNewConstMetric(col.lookups, prometheus.CounterValue, float64(n), node)
+	}
+	count, total := col.c.rebuilds.load()
+	ch <- prometheus.MustNewConstSummary(col.rebuilds, count, total.Seconds(), nil)
+}
@@ -0,0 +1,36 @@
+//go:build prometheus
+
+package consistent
+
+import "strings"
+import "testing"
+
+import "github.com/prometheus/client_golang/prometheus"
+import "github.com/prometheus/client_golang/prometheus/testutil"
+
+func TestCollector(t *testing.T) {
+	c := NewConsistentWithOptions(WithLookupCounters())
+	c.AddNodes([]string{"node1", "node2"})
+	c.GetNode("xxx")
+	col := NewCollector(c, CollectorOpts{
+		Namespace:   "app",
+		ConstLabels: prometheus.Labels{"ring": "cache"},
+	})
+	exp := `
+# HELP app_ring_nodes Number of physical nodes.
+# TYPE app_ring_nodes gauge
+app_ring_nodes{ring="cache"} 2
+# HELP app_ring_points Number of virtual nodes on the ring.
+# TYPE app_ring_points gauge
+app_ring_points{ring="cache"} 200
+`
+	if err := testutil.CollectAndCompare(col, strings.NewReader(exp), "app_ring_nodes", "app_ring_points"); err != nil {
+		t.Errorf("collected metrics %v\n", err)
+	}
+	if n := testutil.CollectAndCount(col, "app_ring_ownership_share"); n != 2 {
+		t.Errorf("ownership_share series exp: 2, got %v\n", n)
+	}
+	if n := testutil.CollectAndCount(col, "app_ring_lookups_total"); n != 2 {
+		t.Errorf("lookups_total series exp: 2, got %v\n", n)
+	}
+}
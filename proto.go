@@ -0,0 +1,221 @@
+package consistent
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Field numbers of consistent.proto
+const (
+	protoRingReplicas = 1
+	protoRingHash     = 2
+	protoRingSeed     = 3
+	protoRingEpoch    = 4
+	protoRingNodes    = 5
+
+	protoNodeName     = 1
+	protoNodeVNodes   = 2
+	protoNodeCapacity = 3
+	protoNodeToken    = 4
+	protoNodeAddress  = 5
+	protoNodeMeta     = 6
+	protoNodeExplicit = 7
+)
+
+// Wire types of protobuf encoding
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// ToProto encodes Config of consistent as Ring message of consistent.proto,
+// for consumers in other languages
+func (c *Consistent) ToProto() []byte {
+	cfg := c.Config()
+	var e protoEncoder
+	e.varint(protoRingReplicas, uint64(cfg.Replicas))
+	e.string(protoRingHash, cfg.Hash)
+	if cfg.Seed != 0 {
+		e.tag(protoRingSeed, wireFixed64)
+		e.fixed64(cfg.Seed)
+	}
+	e.varint(protoRingEpoch, cfg.Epoch)
+	for _, n := range cfg.Nodes {
+		var ne protoEncoder
+		ne.string(protoNodeName, n.Node)
+		ne.varint(protoNodeVNodes, uint64(n.VNodes))
+		if n.Capacity != 0 {
+			ne.tag(protoNodeCapacity, wireFixed64)
+			ne.fixed64(math.Float64bits(n.Capacity))
+		}
+		ne.string(protoNodeToken, n.Token)
+		ne.string(protoNodeAddress, n.Address)
+		keys := make([]string, 0, len(n.Meta))
+		for k := range n.Meta {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			// map entries are messages of key 1 and value 2
+			var me protoEncoder
+			me.string(1, k)
+			me.string(2, n.Meta[k])
+			ne.bytes(protoNodeMeta, me.buf)
+		}
+		ne.varint(protoNodeExplicit, uint64(boolInt(n.Explicit)))
+		e.bytes(protoRingNodes, ne.buf)
+	}
+	return e.buf
+}
+
+// FromProto replaces state of consistent by Ring message of
+// consistent.proto, like UnmarshalJSON. Unknown fields are skipped.
+func (c *Consistent) FromProto(data []byte) error {
+	var cfg Config
+	err := protoFields(data, func(num int, typ int, v uint64, b []byte) error {
+		switch {
+		case num == protoRingReplicas && typ == wireVarint:
+			cfg.Replicas = int(int64(v))
+		case num == protoRingHash && typ == wireBytes:
+			cfg.Hash = string(b)
+		case num == protoRingSeed && typ == wireFixed64:
+			cfg.Seed = v
+		case num == protoRingEpoch && typ == wireVarint:
+			cfg.Epoch = v
+		case num == protoRingNodes && typ == wireBytes:
+			n, err := protoNode(b)
+			if err != nil {
+				return err
+			}
+			cfg.Nodes = append(cfg.Nodes, n)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	c.lock()
+	defer c.unlock()
+	c.init()
+	return c.setConfig(cfg, nil)
+}
+
+func protoNode(data []byte) (NodeConfig, error) {
+	var n NodeConfig
+	err := protoFields(data, func(num int, typ int, v uint64, b []byte) error {
+		switch {
+		case num == protoNodeName && typ == wireBytes:
+			n.Node = string(b)
+		case num == protoNodeVNodes && typ == wireVarint:
+			n.VNodes = int(int64(v))
+		case num == protoNodeCapacity && typ == wireFixed64:
+			n.Capacity = math.Float64frombits(v)
+		case num == protoNodeToken && typ == wireBytes:
+			n.Token = string(b)
+		case num == protoNodeExplicit && typ == wireVarint:
+			n.Explicit = v != 0
+		case num == protoNodeAddress && typ == wireBytes:
+			n.Address = string(b)
+		case num == protoNodeMeta && typ == wireBytes:
+			var key, value string
+			err := protoFields(b, func(num int, typ int, v uint64, b []byte) error {
+				if typ == wireBytes && num == 1 {
+					key = string(b)
+				} else if typ == wireBytes && num == 2 {
+					value = string(b)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if n.Meta == nil {
+				n.Meta = make(map[string]string)
+			}
+			n.Meta[key] = value
+		}
+		return nil
+	})
+	return n, err
+}
+
+// protoFields calls fn for each field of message data with its number,
+// wire type and value, which is v for numeric types and b for bytes
+func protoFields(data []byte, fn func(num int, typ int, v uint64, b []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 || key>>3 == 0 {
+			return fmt.Errorf("%w: bad protobuf field key", ErrInvalidData)
+		}
+		data = data[n:]
+		num, typ := int(key>>3), int(key&7)
+		var v uint64
+		var b []byte
+		switch typ {
+		case wireVarint:
+			if v, n = binary.Uvarint(data); n <= 0 {
+				return fmt.Errorf("%w: truncated protobuf varint", ErrInvalidData)
+			}
+			data = data[n:]
+		case wireFixed64:
+			if len(data) < 8 {
+				return fmt.Errorf("%w: truncated protobuf fixed64", ErrInvalidData)
+			}
+			v, data = binary.LittleEndian.Uint64(data), data[8:]
+		case wireFixed32:
+			if len(data) < 4 {
+				return fmt.Errorf("%w: truncated protobuf fixed32", ErrInvalidData)
+			}
+			v, data = uint64(binary.LittleEndian.Uint32(data)), data[4:]
+		case wireBytes:
+			l, n := binary.Uvarint(data)
+			if n <= 0 || l > uint64(len(data)-n) {
+				return fmt.Errorf("%w: truncated protobuf bytes", ErrInvalidData)
+			}
+			b, data = data[n:n+int(l)], data[n+int(l):]
+		default:
+			return fmt.Errorf("%w: unsupported protobuf wire type %d", ErrInvalidData, typ)
+		}
+		if err := fn(num, typ, v, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// protoEncoder appends protobuf fields, omitting zero values like proto3.
+// Its varints and little endian fixed64 are those of encoder.
+type protoEncoder struct {
+	encoder
+}
+
+func (e *protoEncoder) tag(num int, typ int) {
+	e.uvarint(uint64(num)<<3 | uint64(typ))
+}
+
+func (e *protoEncoder) varint(num int, v uint64) {
+	if v == 0 {
+		return
+	}
+	e.tag(num, wireVarint)
+	e.uvarint(v)
+}
+
+func (e *protoEncoder) string(num int, s string) {
+	if s == "" {
+		return
+	}
+	e.tag(num, wireBytes)
+	e.encoder.string(s)
+}
+
+// bytes appends embedded message, kept even if empty
+func (e *protoEncoder) bytes(num int, b []byte) {
+	e.tag(num, wireBytes)
+	e.uvarint(uint64(len(b)))
+	e.buf = append(e.buf, b...)
+}
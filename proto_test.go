@@ -0,0 +1,46 @@
+package consistent
+
+import "bytes"
+import "errors"
+import "reflect"
+import "testing"
+
+func TestToProto(t *testing.T) {
+	c := NewConsistentWithN(10)
+	c.AddNode("a")
+	exp := []byte{
+		0x08, 0x0a, // replicas 10
+		0x12, 0x05, 'c', 'r', 'c', '6', '4', // hash
+		0x20, 0x01, // epoch 1
+		0x2a, 0x05, 0x0a, 0x01, 'a', 0x10, 0x0a, // node a of 10 vnodes
+	}
+	if got := c.ToProto(); !bytes.Equal(got, exp) {
+		t.Errorf("ToProto exp: % x, got % x\n", exp, got)
+	}
+}
+
+func TestFromProto(t *testing.T) {
+	c := NewConsistentWithOptions(WithReplicas(20), WithHashName("wyhash"), WithSeed(1<<60))
+	c.AddNodes([]string{"node1", "node2"})
+	c.AddNodeWithCapacity("node3", 0.5)
+	c.AddNodeInfo(Node{ID: "node4", Address: "10.0.0.4:80", Meta: map[string]string{ZoneLabel: "z1", "rack": "r1"}})
+	c.ReplaceNode("node1", "node5")
+	data := c.ToProto()
+	// fields of later schema versions are skipped
+	data = append(data, 0x78, 0x01, 0x7d, 1, 2, 3, 4)
+
+	var got Consistent
+	if err := got.FromProto(data); err != nil {
+		t.Fatalf("FromProto %v\n", err)
+	}
+	if !reflect.DeepEqual(got.Config(), c.Config()) || got.Epoch() != c.Epoch() {
+		t.Errorf("FromProto exp: %+v, got %+v\n", c.Config(), got.Config())
+	}
+	sameLookups(t, c, &got)
+
+	for _, bad := range [][]byte{data[:len(data)-12], {0x2a, 0x7f}, {0x00}} {
+		if err := got.FromProto(bad); !errors.Is(err, ErrInvalidData) {
+			t.Errorf("FromProto(% x) exp: %v, got %v\n", bad, ErrInvalidData, err)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package consistent
+
+// GetQuorum returns n owners of key like GetNNode, plus majority of n
+// which reads and writes must reach for quorum protocols
+func (c *Consistent) GetQuorum(key string, n int) (nodes []string, quorum int, err error) {
+	nodes, err = c.GetNNode(key, n)
+	if err != nil {
+		return nodes, 0, err
+	}
+	return nodes, n/2 + 1, nil
+}
+
+// GetZoneQuorum is GetQuorum with owners from n distinct zones, see
+// GetNNodePerZone, so losing a zone loses at most one vote
+func (c *Consistent) GetZoneQuorum(key string, n int) (nodes []string, quorum int, err error) {
+	nodes, err = c.GetNNodePerZone(key, n)
+	if err != nil {
+		return nodes, 0, err
+	}
+	return nodes, n/2 + 1, nil
+}
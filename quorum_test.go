@@ -0,0 +1,39 @@
+package consistent
+
+import "errors"
+import "fmt"
+import "reflect"
+import "testing"
+
+func TestGetQuorum(t *testing.T) {
+	c := NewConsistent()
+	for i, zone := range []string{"a", "a", "b", "b", "c"} {
+		c.AddNodeInfo(Node{ID: fmt.Sprintf("node%v", i), Meta: map[string]string{ZoneLabel: zone}})
+	}
+
+	testQuorum := []struct {
+		N      int
+		Quorum int
+	}{
+		{1, 1}, {2, 2}, {3, 2}, {4, 3}, {5, 3},
+	}
+	for _, v := range testQuorum {
+		exp, _ := c.GetNNode("xxx", v.N)
+		nodes, q, err := c.GetQuorum("xxx", v.N)
+		if err != nil || q != v.Quorum || !reflect.DeepEqual(nodes, exp) {
+			t.Errorf("GetQuorum err: %v, exp: %v %v, got: %v %v\n", err, exp, v.Quorum, nodes, q)
+		}
+	}
+	if _, _, err := c.GetQuorum("xxx", 6); !errors.Is(err, ErrNotEnoughNodes) {
+		t.Errorf("GetQuorum err, exp: %v, got: %v\n", ErrNotEnoughNodes, err)
+	}
+
+	nodes, q, err := c.GetZoneQuorum("xxx", 3)
+	exp, _ := c.GetNNodePerZone("xxx", 3)
+	if err != nil || q != 2 || !reflect.DeepEqual(nodes, exp) {
+		t.Errorf("GetZoneQuorum err: %v, exp: %v, got: %v %v\n", err, exp, nodes, q)
+	}
+	if _, _, err := c.GetZoneQuorum("xxx", 4); !errors.Is(err, ErrNotEnoughNodes) {
+		t.Errorf("GetZoneQuorum err, exp: %v, got: %v\n", ErrNotEnoughNodes, err)
+	}
+}
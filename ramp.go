@@ -0,0 +1,179 @@
+package consistent
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultRampSteps is step number of DrainNode and WarmUpNode
+const DefaultRampSteps = 10
+
+// RampEvent reports a step of DrainNode or WarmUpNode
+type RampEvent struct {
+	Node   string
+	Step   int // 1 based
+	Steps  int
+	VNodes int  // virtual nodes of Node after this step
+	Done   bool // last event, node is at full weight or removed
+}
+
+// Ramp is a running DrainNode or WarmUpNode. It moves virtual nodes of a
+// node linearly from one number to another, one step per interval.
+type Ramp struct {
+	c        *Consistent
+	node     string
+	from, to int
+	interval time.Duration
+	events   chan RampEvent
+
+	mu      sync.Mutex
+	step    int           // finished steps
+	stop    chan struct{} // closed to cancel running ramp
+	stopped chan struct{} // closed when running goroutine exits
+	done    bool
+}
+
+func newRamp(c *Consistent, node string, from, to int, over time.Duration) *Ramp {
+	interval := over / DefaultRampSteps
+	if interval <= 0 {
+		interval = 1
+	}
+	return &Ramp{
+		c:        c,
+		node:     node,
+		from:     from,
+		to:       to,
+		interval: interval,
+		events:   make(chan RampEvent, DefaultRampSteps),
+	}
+}
+
+// Events returns channel of step events, closed after the last one.
+// It's buffered for all steps, so ramp goes on if nobody reads it.
+func (r *Ramp) Events() <-chan RampEvent {
+	return r.events
+}
+
+// Cancel pauses ramp, node keeps virtual nodes of the last finished step.
+// It waits until a step in progress is finished.
+func (r *Ramp) Cancel() {
+	r.mu.Lock()
+	stop, stopped := r.stop, r.stopped
+	r.stop = nil
+	r.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-stopped
+}
+
+// Resume continues a cancelled ramp from the step it stopped at
+func (r *Ramp) Resume() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.done || r.stop != nil {
+		return
+	}
+	r.stop, r.stopped = make(chan struct{}), make(chan struct{})
+	go r.run(r.stop, r.stopped)
+}
+
+// vnodes returns virtual nodes at step, rounded towards from, so a
+// draining node keeps at least one point until the last step
+func (r *Ramp) vnodes(step int) int {
+	d := (r.from - r.to) * (DefaultRampSteps - step)
+	if d > 0 {
+		d = (d + DefaultRampSteps - 1) / DefaultRampSteps
+	} else {
+		d /= DefaultRampSteps
+	}
+	return r.to + d
+}
+
+func (r *Ramp) run(stop, stopped chan struct{}) {
+	defer close(stopped)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		if !r.next() {
+			return
+		}
+	}
+}
+
+// next applies next step, returns false when ramp is over
+func (r *Ramp) next() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, step := r.c, r.step+1
+	vnodes := r.vnodes(step)
+	last := step == DefaultRampSteps
+	c.lock()
+	_, ok := c.node[r.node]
+	switch {
+	case !ok:
+		// removed by others meanwhile
+		last = true
+	case last && vnodes == 0:
+		c.removeNode(r.node)
+	default:
+		c.resizeNode(r.node, vnodes)
+	}
+	c.unlock()
+	if ok {
+		r.step = step
+		r.events <- RampEvent{
+			Node:   r.node,
+			Step:   step,
+			Steps:  DefaultRampSteps,
+			VNodes: vnodes,
+			Done:   last,
+		}
+	}
+	if last {
+		r.done = true
+		close(r.events)
+	}
+	return !last
+}
+
+// DrainNode steps virtual nodes of node down to zero in DefaultRampSteps
+// steps spread evenly over given duration, then removes it. Keys move off
+// the node gradually instead of all at once.
+func (c *Consistent) DrainNode(node string, over time.Duration) (*Ramp, error) {
+	c.rlock()
+	start, ok := c.node[node]
+	c.runlock()
+	if !ok {
+		return nil, ErrNodeNotFound
+	}
+	r := newRamp(c, node, start, 0, over)
+	r.Resume()
+	return r, nil
+}
+
+// WarmUpNode adds node with a small fraction of default replicas and steps
+// it up to full in DefaultRampSteps steps spread evenly over given
+// duration, so cold caches of node fill gradually.
+func (c *Consistent) WarmUpNode(node string, over time.Duration) (*Ramp, error) {
+	c.lock()
+	if _, ok := c.node[node]; ok {
+		c.unlock()
+		return nil, ErrNodeExists
+	}
+	start := c.replicas / DefaultRampSteps
+	if start < 1 {
+		start = 1
+	}
+	c.addNode(node, start)
+	r := newRamp(c, node, start, c.replicas, over)
+	c.unlock()
+	r.Resume()
+	return r, nil
+}
@@ -0,0 +1,67 @@
+package consistent
+
+import "testing"
+import "time"
+
+func TestDrainNode(t *testing.T) {
+	c := NewConsistent()
+	c.AddNodes([]string{"node1", "node2"})
+
+	if _, err := c.DrainNode("node3", time.Millisecond); err == nil {
+		t.Errorf("DrainNode should fail on unknown node\n")
+	}
+
+	r, err := c.DrainNode("node1", 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("DrainNode err: %v\n", err)
+	}
+	last, steps := DefaultReplica, 0
+	for e := range r.Events() {
+		steps++
+		if e.VNodes >= last && !e.Done {
+			t.Errorf("Virtual nodes not decreasing, step %v: %v\n", e.Step, e.VNodes)
+		}
+		last = e.VNodes
+	}
+	if steps != DefaultRampSteps || last != 0 {
+		t.Errorf("Wrong drain steps, exp: %v, got %v, last: %v\n", DefaultRampSteps, steps, last)
+	}
+	if c.HasNode("node1") || c.ring.Len() != DefaultReplica {
+		t.Errorf("Drained node not removed\n")
+	}
+}
+
+func TestWarmUpNode(t *testing.T) {
+	c := NewConsistent()
+	c.AddNodes([]string{"node1", "node2"})
+
+	if _, err := c.WarmUpNode("node1", time.Millisecond); err == nil {
+		t.Errorf("WarmUpNode should fail on existing node\n")
+	}
+
+	r, err := c.WarmUpNode("node3", time.Hour)
+	if err != nil {
+		t.Fatalf("WarmUpNode err: %v\n", err)
+	}
+	r.Cancel()
+	if c.ring.Len() != 2*DefaultReplica+DefaultReplica/DefaultRampSteps {
+		t.Errorf("Wrong initial virtual nodes, got %v\n", c.ring.Len()-2*DefaultReplica)
+	}
+
+	r.interval = time.Millisecond
+	r.Resume()
+	last, steps := 0, 0
+	for e := range r.Events() {
+		steps++
+		if e.VNodes <= last {
+			t.Errorf("Virtual nodes not increasing, step %v: %v\n", e.Step, e.VNodes)
+		}
+		last = e.VNodes
+	}
+	if steps != DefaultRampSteps || last != DefaultReplica {
+		t.Errorf("Wrong warm up steps, exp: %v, got %v, last: %v\n", DefaultRampSteps, steps, last)
+	}
+	if c.ring.Len() != 3*DefaultReplica {
+		t.Errorf("Wrong virtual nodes, exp: %v, got %v\n", 3*DefaultReplica, c.ring.Len())
+	}
+}
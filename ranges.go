@@ -0,0 +1,146 @@
+package consistent
+
+import "sort"
+
+// Range is a hash interval (Start, End] on the ring. Start > End means it
+// wraps around zero, and Start == End means the whole ring.
+type Range struct {
+	Start uint64
+	End   uint64
+}
+
+// Contains tests whether hash h falls in range
+func (r Range) Contains(h uint64) bool {
+	switch {
+	case r.Start < r.End:
+		return h > r.Start && h <= r.End
+	case r.Start > r.End:
+		return h > r.Start || h <= r.End
+	}
+	return true
+}
+
+// OwnedRanges returns hash intervals whose keys map to node, adjacent
+// intervals are merged. Ranges are in ring order, starting from the one
+// owning the smallest hashes.
+func (c *Consistent) OwnedRanges(node string) []Range {
+	c.rlock()
+	defer c.runlock()
+	return ownedRanges(c.ring, node)
+}
+
+func ownedRanges(r ring, node string) []Range {
+	var ranges []Range
+	l := r.Len()
+	for i := 0; i < l; i++ {
+		if r.Owner(i) != node {
+			continue
+		}
+		start := r.Hash((i + l - 1) % l)
+		for i+1 < l && r.Owner(i+1) == node {
+			i++
+		}
+		ranges = append(ranges, Range{Start: start, End: r.Hash(i)})
+	}
+	if n := len(ranges); n > 1 && r.Owner(0) == node && r.Owner(l-1) == node {
+		// first and last ranges meet at zero
+		ranges[0].Start = ranges[n-1].Start
+		ranges = ranges[:n-1]
+	}
+	return ranges
+}
+
+// Successor returns the physical node following node on the ring, which
+// takes over its keys when it's removed. Node owns many points, so it's
+// the node following most of them, ties broken by name.
+func (c *Consistent) Successor(node string) (string, error) {
+	return c.neighbor(node, 1)
+}
+
+// Predecessor returns the physical node preceding most points of node on
+// the ring, ties broken by name
+func (c *Consistent) Predecessor(node string) (string, error) {
+	return c.neighbor(node, -1)
+}
+
+// neighbor counts nearest other owners in direction dir of node's points
+func (c *Consistent) neighbor(node string, dir int) (string, error) {
+	c.rlock()
+	defer c.runlock()
+	if _, ok := c.node[node]; !ok {
+		return "", ErrNodeNotFound
+	}
+	if c.count < 2 {
+		return "", ErrNotEnoughNodes
+	}
+	l := c.ring.Len()
+	counts := map[string]int{}
+	for i := 0; i < l; i++ {
+		if c.ring.Owner(i) != node {
+			continue
+		}
+		for j := (i + l + dir) % l; j != i; j = (j + l + dir) % l {
+			if o := c.ring.Owner(j); o != node {
+				counts[o]++
+				break
+			}
+		}
+	}
+	best := ""
+	for n, cnt := range counts {
+		if best == "" || cnt > counts[best] || cnt == counts[best] && n < best {
+			best = n
+		}
+	}
+	return best, nil
+}
+
+// OwnersInRange returns sorted distinct nodes owning any key whose hash
+// falls in (start, end], same wrapping rules as Range
+func (c *Consistent) OwnersInRange(start, end uint64) []string {
+	c.rlock()
+	defer c.runlock()
+	l := c.ring.Len()
+	if l == 0 {
+		return []string{}
+	}
+	seen := map[string]bool{}
+	r := Range{Start: start, End: end}
+	if start == end {
+		for node := range c.node {
+			seen[node] = true
+		}
+	}
+	// first point after start, then on until a point at or past end,
+	// which owns the tail of the range
+	for i, n := c.ring.Search(start+1), 0; n < l && start != end; i, n = (i+1)%l, n+1 {
+		h := c.ring.Hash(i)
+		seen[c.ring.Owner(i)] = true
+		if h == end || !r.Contains(h) {
+			break
+		}
+	}
+	nodes := make([]string, 0, len(seen))
+	for node := range seen {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// WalkRing calls fn for virtual nodes in ring order, starting from the
+// first point at or after start, once around or until fn returns false.
+// Read lock is held during walk, so fn must not mutate consistent.
+func (c *Consistent) WalkRing(start uint64, fn func(hash uint64, node string) bool) {
+	c.rlock()
+	defer c.runlock()
+	l := c.ring.Len()
+	if l == 0 {
+		return
+	}
+	for i, n := c.ring.Search(start), 0; n < l; i, n = (i+1)%l, n+1 {
+		if !fn(c.ring.Hash(i), c.ring.Owner(i)) {
+			return
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package consistent
+
+import "fmt"
+import "reflect"
+import "testing"
+
+func TestOwnedRanges(t *testing.T) {
+	c := NewConsistentWithN(20)
+	if r := c.OwnedRanges("node1"); len(r) != 0 {
+		t.Errorf("Empty ring shouldn't own ranges, got %v\n", r)
+	}
+
+	c.AddNode("node1")
+	if r := c.OwnedRanges("node1"); len(r) != 1 || r[0].Start != r[0].End {
+		t.Errorf("Single node should own whole ring, got %v\n", r)
+	}
+
+	c.AddNodes([]string{"node2", "node3"})
+	ranges := map[string][]Range{}
+	for _, n := range c.Members() {
+		ranges[n] = c.OwnedRanges(n)
+	}
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key%v", i)
+		h := crc64h([]byte(key))
+		node, _ := c.GetNode(key)
+		for n, rs := range ranges {
+			in := false
+			for _, r := range rs {
+				in = in || r.Contains(h)
+			}
+			if in != (n == node) {
+				t.Errorf("Key %v owned by %v, but ranges of %v contains it: %v\n", key, node, n, in)
+			}
+		}
+	}
+}
+
+func TestSuccessorPredecessor(t *testing.T) {
+	c := NewConsistentWithN(1)
+	if _, err := c.Successor("node1"); err == nil {
+		t.Errorf("Successor should fail on unknown node\n")
+	}
+	c.AddNode("node1")
+	if _, err := c.Predecessor("node1"); err == nil {
+		t.Errorf("Predecessor should fail on single node ring\n")
+	}
+	c.AddNodes([]string{"node2", "node3"})
+
+	// with one point per node, ring order is plain
+	order := []string{c.ring.Owner(0), c.ring.Owner(1), c.ring.Owner(2)}
+	for i, n := range order {
+		if s, err := c.Successor(n); err != nil || s != order[(i+1)%3] {
+			t.Errorf("Successor err: %v, exp: %v, got: %v\n", err, order[(i+1)%3], s)
+		}
+		if p, err := c.Predecessor(n); err != nil || p != order[(i+2)%3] {
+			t.Errorf("Predecessor err: %v, exp: %v, got: %v\n", err, order[(i+2)%3], p)
+		}
+	}
+
+	// successor takes over most keys of removed node
+	c = NewConsistent()
+	c.AddNodes([]string{"node1", "node2", "node3", "node4"})
+	succ, _ := c.Successor("node1")
+	moved := map[string]int{}
+	keys := []string{}
+	for i := 0; i < 2000; i++ {
+		key := fmt.Sprintf("key%v", i)
+		if n, _ := c.GetNode(key); n == "node1" {
+			keys = append(keys, key)
+		}
+	}
+	c.RemoveNode("node1")
+	for _, key := range keys {
+		n, _ := c.GetNode(key)
+		moved[n]++
+	}
+	for n, cnt := range moved {
+		if cnt > moved[succ] {
+			t.Errorf("Successor %v got %v keys, less than %v got %v\n", succ, moved[succ], n, cnt)
+		}
+	}
+}
+
+func TestOwnersInRange(t *testing.T) {
+	c := NewConsistentWithN(20)
+	if o := c.OwnersInRange(1, 2); len(o) != 0 {
+		t.Errorf("Empty ring shouldn't have owners, got %v\n", o)
+	}
+	c.AddNodes([]string{"node1", "node2", "node3", "node4"})
+
+	if o := c.OwnersInRange(5, 5); !reflect.DeepEqual(o, c.Members()) {
+		t.Errorf("Whole ring should be owned by all, got %v\n", o)
+	}
+
+	// brute force owners of sampled keys inside range
+	for _, r := range []Range{{0, 1 << 60}, {1 << 63, 1<<63 + 1<<50}, {1<<64 - 1<<58, 1 << 58}, {100, 101}} {
+		exp := map[string]bool{}
+		for _, node := range c.Members() {
+			for _, o := range c.OwnedRanges(node) {
+				if o.Contains(r.End) || r.Contains(o.End) {
+					exp[node] = true
+				}
+			}
+		}
+		got := c.OwnersInRange(r.Start, r.End)
+		if len(got) != len(exp) {
+			t.Errorf("OwnersInRange(%v), exp: %v, got: %v\n", r, exp, got)
+		}
+		for _, n := range got {
+			if !exp[n] {
+				t.Errorf("OwnersInRange(%v) returned %v not owning it\n", r, n)
+			}
+		}
+	}
+}
+
+func TestWalkRing(t *testing.T) {
+	c := NewConsistentWithN(10)
+	c.WalkRing(0, func(uint64, string) bool {
+		t.Errorf("Empty ring shouldn't be walked\n")
+		return true
+	})
+	c.AddNodes([]string{"node1", "node2", "node3"})
+
+	h := crc64h([]byte("xxx"))
+	var nodes []string
+	points := 0
+	c.WalkRing(h, func(hash uint64, node string) bool {
+		if points == 0 && hash < h && c.ring.Search(h) != 0 {
+			t.Errorf("Walk should start at or after %v, got %v\n", h, hash)
+		}
+		points++
+		if !stringInSlice(nodes, node) {
+			nodes = append(nodes, node)
+		}
+		return true
+	})
+	if points != 30 {
+		t.Errorf("Walk should visit all points once, got %v\n", points)
+	}
+	if exp, _ := c.GetNNode("xxx", 3); !reflect.DeepEqual(nodes, exp) {
+		t.Errorf("Walk order differs from GetNNode, exp: %v, got %v\n", exp, nodes)
+	}
+
+	points = 0
+	c.WalkRing(h, func(uint64, string) bool {
+		points++
+		return points < 5
+	})
+	if points != 5 {
+		t.Errorf("Walk should stop when fn returns false, got %v\n", points)
+	}
+}
@@ -0,0 +1,387 @@
+package consistent
+
+import (
+	"runtime"
+	"sort"
+)
+
+// ring stores virtual node points ordered by hash, so lookups can binary
+// search the first point clockwise of a key and walk on by index.
+type ring interface {
+	// Len returns number of points on the ring
+	Len() int
+	// Hash returns hash of the i-th point
+	Hash(i int) uint64
+	// Owner returns physical node of the i-th point
+	Owner(i int) string
+	// Search returns index of first point whose hash >= h, wrapping to 0
+	Search(h uint64) int
+	// Lookup returns owner of point at exactly h
+	Lookup(h uint64) (string, bool)
+	// Insert adds points of node
+	Insert(node string, hashes []uint64)
+	// Delete removes points
+	Delete(hashes []uint64)
+	// Clone returns a ring not affected by later mutations of this one
+	Clone() ring
+	// Reset removes all points
+	Reset()
+}
+
+// bulkRing is a ring able to defer ordering work of a series of mutations
+// to one step. Between Begin and Commit only Insert and Delete may be used.
+type bulkRing interface {
+	ring
+	Begin()
+	Commit()
+}
+
+// indexRing is a ring numbering its nodes, so walks can track seen nodes
+// in a bitmap
+type indexRing interface {
+	ring
+	// OwnerIndex returns index of owner of the i-th point
+	OwnerIndex(i int) uint32
+	// NodeSlots returns upper bound of node indexes
+	NodeSlots() int
+}
+
+// loadRing is a ring able to take ascending points at once, saving
+// ordering work of Insert
+type loadRing interface {
+	ring
+	// Load replaces points by ascending hashes owned by owners
+	Load(hashes []uint64, owners []string)
+}
+
+// growRing is a ring able to reserve room for more points
+type growRing interface {
+	ring
+	Grow(points int)
+}
+
+// point is a virtual node on sliceRing, node indexes ring's node table
+type point struct {
+	hash uint64
+	node uint32
+}
+
+type points []point
+
+func (s points) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s points) Len() int           { return len(s) }
+func (s points) Less(i, j int) bool { return s[i].hash < s[j].hash }
+
+// sliceRing is the default ring, a sorted slice of points naming owners by
+// index into a small node table, so lookups touch one array. Every
+// mutation merges into or shifts the slice.
+type sliceRing struct {
+	points points
+	arena  *arena            // allocates points off heap, nil uses heap
+	nodes  []string          // node table
+	ids    map[string]uint32 // node to its index in nodes
+	refs   []int             // points per node index, 0 is free
+	free   []uint32          // released node indexes
+	// bulk mode stages inserts and defers removal until Commit
+	bulk    bool
+	staged  points
+	added   map[uint64]int // staged index of inserted hash
+	removed map[point]int  // points to remove on Commit
+	dead    []uint32       // node indexes to release on Commit
+}
+
+func newSliceRing() *sliceRing {
+	return &sliceRing{ids: make(map[string]uint32)}
+}
+
+// Points of snapshots of WithArena are unmapped by finalizer of their
+// ring, methods reading points keep ring alive till they are read.
+
+func (r *sliceRing) Len() int { return len(r.points) }
+
+func (r *sliceRing) Hash(i int) uint64 {
+	h := r.points[i].hash
+	runtime.KeepAlive(r)
+	return h
+}
+
+func (r *sliceRing) Owner(i int) string {
+	id := r.points[i].node
+	runtime.KeepAlive(r)
+	return r.nodes[id]
+}
+
+func (r *sliceRing) OwnerIndex(i int) uint32 {
+	id := r.points[i].node
+	runtime.KeepAlive(r)
+	return id
+}
+
+func (r *sliceRing) NodeSlots() int { return len(r.nodes) }
+
+func (r *sliceRing) Search(h uint64) int {
+	ind := r.search(h)
+	if ind >= len(r.points) {
+		ind = 0
+	}
+	return ind
+}
+
+func (r *sliceRing) search(h uint64) int {
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i].hash >= h })
+	runtime.KeepAlive(r)
+	return i
+}
+
+// find returns index of point at exactly h
+func (r *sliceRing) find(h uint64) (int, bool) {
+	i := r.search(h)
+	ok := i < len(r.points) && r.points[i].hash == h
+	runtime.KeepAlive(r)
+	return i, ok
+}
+
+func (r *sliceRing) Lookup(h uint64) (string, bool) {
+	if r.bulk {
+		if i, ok := r.added[h]; ok {
+			return r.nodes[r.staged[i].node], true
+		}
+	}
+	i, ok := r.find(h)
+	if !ok || r.removed[r.points[i]] > 0 {
+		return "", false
+	}
+	id := r.points[i].node
+	runtime.KeepAlive(r)
+	return r.nodes[id], true
+}
+
+// acquire returns index of node in node table, adding it when missing
+func (r *sliceRing) acquire(node string, n int) uint32 {
+	id, ok := r.ids[node]
+	if !ok {
+		if k := len(r.free); k > 0 {
+			id = r.free[k-1]
+			r.free = r.free[:k-1]
+			r.nodes[id] = node
+		} else {
+			id = uint32(len(r.nodes))
+			r.nodes = append(r.nodes, node)
+			r.refs = append(r.refs, 0)
+		}
+		r.ids[node] = id
+	}
+	r.refs[id] += n
+	return id
+}
+
+// release drops a point of node index id, freeing it with its last point
+func (r *sliceRing) release(id uint32) {
+	r.refs[id]--
+	if r.refs[id] > 0 {
+		return
+	}
+	if r.bulk {
+		r.dead = append(r.dead, id)
+		return
+	}
+	delete(r.ids, r.nodes[id])
+	r.free = append(r.free, id)
+}
+
+func (r *sliceRing) Insert(node string, hashes []uint64) {
+	if len(hashes) == 0 {
+		return
+	}
+	id := r.acquire(node, len(hashes))
+	if r.bulk {
+		for _, h := range hashes {
+			r.added[h] = len(r.staged)
+			r.staged = append(r.staged, point{h, id})
+		}
+		return
+	}
+	batch := make(points, len(hashes))
+	for i, h := range hashes {
+		batch[i] = point{h, id}
+	}
+	sort.Sort(batch)
+	r.reserve(len(batch))
+	r.points = mergeSorted(r.points, batch)
+}
+
+// mergeSorted merges sorted b into sorted a in O(len(a)+len(b)), filling
+// a from its end so no extra slice is needed when a has room
+func mergeSorted(a, b points) points {
+	i, j := len(a)-1, len(b)-1
+	for k := 0; k < len(b); k++ {
+		a = append(a, point{})
+	}
+	for k := len(a) - 1; j >= 0; k-- {
+		if i >= 0 && a[i].hash > b[j].hash {
+			a[k] = a[i]
+			i--
+		} else {
+			a[k] = b[j]
+			j--
+		}
+	}
+	return a
+}
+
+func (r *sliceRing) Delete(hashes []uint64) {
+	if r.bulk {
+		for _, h := range hashes {
+			r.stageDelete(h)
+		}
+		return
+	}
+	if len(hashes) == 1 {
+		if i, ok := r.find(hashes[0]); ok {
+			r.release(r.points[i].node)
+			r.points = append(r.points[:i], r.points[i+1:]...)
+		}
+		return
+	}
+	// shifting per point is quadratic, compact in one pass instead
+	remove := make(map[point]int, len(hashes))
+	for _, h := range hashes {
+		if i, ok := r.find(h); ok {
+			remove[r.points[i]]++
+		}
+	}
+	r.compact(remove)
+}
+
+func (r *sliceRing) stageDelete(h uint64) {
+	if i, ok := r.added[h]; ok {
+		delete(r.added, h)
+		r.removed[r.staged[i]]++
+		r.release(r.staged[i].node)
+		return
+	}
+	if i, ok := r.find(h); ok && r.removed[r.points[i]] == 0 {
+		r.removed[r.points[i]]++
+		r.release(r.points[i].node)
+	}
+}
+
+// compact drops points counted in remove in one pass over sorted points,
+// releasing them from node table unless bulk Delete did already
+func (r *sliceRing) compact(remove map[point]int) {
+	kept := r.points[:0]
+	for _, p := range r.points {
+		if remove[p] > 0 {
+			remove[p]--
+			if !r.bulk {
+				r.release(p.node)
+			}
+			continue
+		}
+		kept = append(kept, p)
+	}
+	r.points = kept
+}
+
+func (r *sliceRing) Clone() ring {
+	n := r.clone(append(points(nil), r.points...))
+	runtime.KeepAlive(r)
+	return n
+}
+
+// clone returns copy of r holding points p
+func (r *sliceRing) clone(p points) *sliceRing {
+	n := &sliceRing{
+		points: p,
+		nodes:  append([]string(nil), r.nodes...),
+		ids:    make(map[string]uint32, len(r.ids)),
+		refs:   append([]int(nil), r.refs...),
+		free:   append([]uint32(nil), r.free...),
+	}
+	for k, v := range r.ids {
+		n.ids[k] = v
+	}
+	return n
+}
+
+func (r *sliceRing) Grow(n int) {
+	if cap(r.points)-len(r.points) < n {
+		r.resize(len(r.points) + n)
+	}
+}
+
+// reserve makes room for n more points, growing like append does
+func (r *sliceRing) reserve(n int) {
+	if cap(r.points)-len(r.points) >= n {
+		return
+	}
+	size := 2 * cap(r.points)
+	if size < len(r.points)+n {
+		size = len(r.points) + n
+	}
+	r.resize(size)
+}
+
+// resize moves points to storage of capacity size, from arena if any
+func (r *sliceRing) resize(size int) {
+	var p points
+	if r.arena != nil {
+		p = r.arena.alloc(size)
+	} else {
+		p = make(points, 0, size)
+	}
+	p = append(p, r.points...)
+	if r.arena != nil {
+		r.arena.free(r.points)
+	}
+	r.points = p
+}
+
+func (r *sliceRing) Load(hashes []uint64, owners []string) {
+	r.Reset()
+	r.reserve(len(hashes))
+	for i, h := range hashes {
+		r.points = append(r.points, point{h, r.acquire(owners[i], 1)})
+	}
+}
+
+// Reset removes all points, keeping storage for reuse
+func (r *sliceRing) Reset() {
+	*r = sliceRing{points: r.points[:0], arena: r.arena, ids: make(map[string]uint32)}
+}
+
+func (r *sliceRing) Begin() {
+	r.bulk = true
+	r.added = make(map[uint64]int)
+	r.removed = make(map[point]int)
+}
+
+// Commit sorts staged points once, merges them and drops deleted points
+// in one pass
+func (r *sliceRing) Commit() {
+	sort.Sort(r.staged)
+	r.reserve(len(r.staged))
+	r.points = mergeSorted(r.points, r.staged)
+	r.compact(r.removed)
+	r.bulk = false
+	for _, id := range r.dead {
+		// id may be listed twice, or taken by its node again
+		if cur, ok := r.ids[r.nodes[id]]; ok && cur == id && r.refs[id] == 0 {
+			delete(r.ids, r.nodes[id])
+			r.free = append(r.free, id)
+		}
+	}
+	r.staged, r.added, r.removed, r.dead = nil, nil, nil, nil
+}
+
+// lookupBySearch implements Lookup for rings without hash index
+func lookupBySearch(r ring, h uint64) (string, bool) {
+	if r.Len() == 0 {
+		return "", false
+	}
+	i := r.Search(h)
+	if r.Hash(i) != h {
+		return "", false
+	}
+	return r.Owner(i), true
+}
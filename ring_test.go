@@ -0,0 +1,146 @@
+package consistent
+
+import "math/rand"
+import "reflect"
+import "testing"
+
+// testRingMatchesSliceRing applies same mutations to kr and a sliceRing and
+// compares their order and search results
+func testRingMatchesSliceRing(t *testing.T, kr ring) {
+	sr := newSliceRing()
+	rnd := rand.New(rand.NewSource(42))
+	var added []uint64
+	for round := 0; round < 50; round++ {
+		hashes := make([]uint64, 20)
+		for i := range hashes {
+			hashes[i] = rnd.Uint64()
+		}
+		sr.Insert("n", hashes)
+		kr.Insert("n", hashes)
+		added = append(added, hashes...)
+		if round%3 == 0 {
+			del := added[:10]
+			added = added[10:]
+			sr.Delete(del)
+			kr.Delete(del)
+		}
+	}
+
+	if sr.Len() != kr.Len() {
+		t.Fatalf("Wrong Len(), exp: %v, got %v\n", sr.Len(), kr.Len())
+	}
+	for i := 0; i < sr.Len(); i++ {
+		if sr.Hash(i) != kr.Hash(i) {
+			t.Fatalf("Wrong Hash(%v), exp: %v, got %v\n", i, sr.Hash(i), kr.Hash(i))
+		}
+	}
+	for i := 0; i < 100; i++ {
+		h := rnd.Uint64()
+		if sr.Search(h) != kr.Search(h) {
+			t.Errorf("Wrong Search(%v), exp: %v, got %v\n", h, sr.Search(h), kr.Search(h))
+		}
+	}
+}
+
+func TestMergeSorted(t *testing.T) {
+	cases := []struct {
+		a, b, exp points
+	}{
+		{nil, nil, nil},
+		{points{{1, 0}, {5, 0}, {9, 0}}, nil, points{{1, 0}, {5, 0}, {9, 0}}},
+		{nil, points{{2, 1}, {3, 1}}, points{{2, 1}, {3, 1}}},
+		{points{{1, 0}, {5, 0}, {9, 0}}, points{{0, 1}, {6, 1}, {10, 1}}, points{{0, 1}, {1, 0}, {5, 0}, {6, 1}, {9, 0}, {10, 1}}},
+		{points{{4, 0}, {5, 0}}, points{{1, 1}, {2, 1}, {3, 1}}, points{{1, 1}, {2, 1}, {3, 1}, {4, 0}, {5, 0}}},
+	}
+	for _, cs := range cases {
+		if got := mergeSorted(append(points(nil), cs.a...), cs.b); !reflect.DeepEqual(got, cs.exp) {
+			t.Errorf("mergeSorted(%v, %v) exp: %v, got %v\n", cs.a, cs.b, cs.exp, got)
+		}
+	}
+}
+
+func TestSliceRingDelete(t *testing.T) {
+	r := newSliceRing()
+	r.Insert("a", []uint64{10, 30, 50, 70})
+	r.Insert("b", []uint64{20, 40, 60})
+	r.Delete([]uint64{30, 70, 10})
+	r.Delete([]uint64{40})
+	exp := points{{20, 1}, {50, 0}, {60, 1}}
+	if !reflect.DeepEqual(r.points, exp) {
+		t.Errorf("Delete exp: %v, got %v\n", exp, r.points)
+	}
+	for _, p := range exp {
+		if _, ok := r.Lookup(p.hash); !ok {
+			t.Errorf("Lookup(%v) should find remaining point\n", p.hash)
+		}
+	}
+	if _, ok := r.Lookup(30); ok {
+		t.Errorf("Lookup(30) should miss deleted point\n")
+	}
+
+	r.Delete([]uint64{50})
+	if _, ok := r.ids["a"]; ok {
+		t.Errorf("node without points should leave node table\n")
+	}
+	r.Insert("c", []uint64{30})
+	if id := r.ids["c"]; id != 0 {
+		t.Errorf("freed node index should be reused, exp: 0, got %v\n", id)
+	}
+	if node, _ := r.Lookup(30); node != "c" {
+		t.Errorf("Lookup(30) exp: c, got %v\n", node)
+	}
+}
+
+func TestSliceRingBulk(t *testing.T) {
+	r := newSliceRing()
+	r.Insert("a", []uint64{10, 30})
+	r.Insert("b", []uint64{20})
+	r.Begin()
+	r.Delete([]uint64{20})
+	if _, ok := r.Lookup(20); ok {
+		t.Errorf("Lookup should miss point deleted in bulk\n")
+	}
+	r.Insert("c", []uint64{20, 5})
+	if node, _ := r.Lookup(20); node != "c" {
+		t.Errorf("Lookup of point inserted in bulk exp: c, got %v\n", node)
+	}
+	r.Insert("d", []uint64{40})
+	r.Delete([]uint64{40})
+	r.Commit()
+	exp := []string{"c", "a", "c", "a"}
+	if r.Len() != len(exp) {
+		t.Fatalf("Len exp: %v, got %v\n", len(exp), r.Len())
+	}
+	for i, node := range exp {
+		if r.Owner(i) != node {
+			t.Errorf("Owner(%v) exp: %v, got %v\n", i, node, r.Owner(i))
+		}
+	}
+	if _, ok := r.ids["b"]; ok {
+		t.Errorf("b should leave node table on Commit\n")
+	}
+	if _, ok := r.ids["d"]; ok {
+		t.Errorf("d should leave node table on Commit\n")
+	}
+}
+
+func TestSliceRingLoad(t *testing.T) {
+	r := newSliceRing()
+	r.Insert("old", []uint64{5})
+	r.Load([]uint64{1, 2, 3}, []string{"a", "b", "a"})
+	exp := newSliceRing()
+	exp.Insert("a", []uint64{1, 3})
+	exp.Insert("b", []uint64{2})
+	if r.Len() != 3 || r.NodeSlots() != 2 {
+		t.Fatalf("Load exp: 3 points of 2 nodes, got %v of %v\n", r.Len(), r.NodeSlots())
+	}
+	for i := 0; i < 3; i++ {
+		if r.Hash(i) != exp.Hash(i) || r.Owner(i) != exp.Owner(i) {
+			t.Errorf("point %v exp: %v %v, got %v %v\n", i, exp.Hash(i), exp.Owner(i), r.Hash(i), r.Owner(i))
+		}
+	}
+	r.Delete([]uint64{2})
+	if _, ok := r.ids["b"]; ok {
+		t.Errorf("exp: b released with its last point\n")
+	}
+}
@@ -0,0 +1,89 @@
+package consistent
+
+import (
+	"sort"
+	"sync"
+)
+
+// DefaultRingSetShards is shard number of NewRingSet for n <= 0
+const DefaultRingSetShards = 32
+
+// RingSet holds independent rings by name, e.g. one per tenant. Rings are
+// striped over shards, each with own lock, and every ring has own lock as
+// well, so writers of different rings don't serialize behind one mutex.
+type RingSet struct {
+	opts   []Option
+	shards []ringShard
+}
+
+type ringShard struct {
+	mu    sync.RWMutex
+	rings map[string]*Consistent
+}
+
+// NewRingSet returns set of n shards creating rings with opts
+func NewRingSet(n int, opts ...Option) *RingSet {
+	if n <= 0 {
+		n = DefaultRingSetShards
+	}
+	s := &RingSet{opts: opts, shards: make([]ringShard, n)}
+	for i := range s.shards {
+		s.shards[i].rings = make(map[string]*Consistent)
+	}
+	return s
+}
+
+func (s *RingSet) shard(name string) *ringShard {
+	return &s.shards[crc64h([]byte(name))%uint64(len(s.shards))]
+}
+
+// Ring returns ring of name, creating empty one if missing
+func (s *RingSet) Ring(name string) *Consistent {
+	sh := s.shard(name)
+	sh.mu.RLock()
+	c, ok := sh.rings[name]
+	sh.mu.RUnlock()
+	if ok {
+		return c
+	}
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if c, ok := sh.rings[name]; ok {
+		return c
+	}
+	c = NewConsistentWithOptions(s.opts...)
+	sh.rings[name] = c
+	return c
+}
+
+// Get returns ring of name if it exists
+func (s *RingSet) Get(name string) (*Consistent, bool) {
+	sh := s.shard(name)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	c, ok := sh.rings[name]
+	return c, ok
+}
+
+// Delete drops ring of name
+func (s *RingSet) Delete(name string) {
+	sh := s.shard(name)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	delete(sh.rings, name)
+}
+
+// Names returns sorted names of rings
+func (s *RingSet) Names() []string {
+	var names []string
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.mu.RLock()
+		for name := range sh.rings {
+			names = append(names, name)
+		}
+		sh.mu.RUnlock()
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,80 @@
+package consistent
+
+import "fmt"
+import "reflect"
+import "sync"
+import "sync/atomic"
+import "testing"
+
+func TestRingSet(t *testing.T) {
+	s := NewRingSet(4, WithReplicas(10))
+	a, b := s.Ring("tenantA"), s.Ring("tenantB")
+	if a == b || s.Ring("tenantA") != a {
+		t.Errorf("Ring should return one ring per name\n")
+	}
+	a.AddNode("node1")
+	if b.HasNode("node1") {
+		t.Errorf("rings should be independent\n")
+	}
+	if a.VirtualNodes("node1") != 10 {
+		t.Errorf("Ring should apply options, exp: 10, got %v\n", a.VirtualNodes("node1"))
+	}
+	if names := s.Names(); !reflect.DeepEqual(names, []string{"tenantA", "tenantB"}) {
+		t.Errorf("Names exp: [tenantA tenantB], got %v\n", names)
+	}
+	s.Delete("tenantA")
+	if _, ok := s.Get("tenantA"); ok {
+		t.Errorf("Get should miss deleted ring\n")
+	}
+	if c, ok := s.Get("tenantB"); !ok || c != b {
+		t.Errorf("Get should return existing ring\n")
+	}
+}
+
+func TestRingSetConcurrent(t *testing.T) {
+	s := NewRingSet(0)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				s.Ring(fmt.Sprint(j)).AddNode(fmt.Sprintf("node%d", i))
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, name := range s.Names() {
+		if n := s.Ring(name).NodeNumber(); n != 8 {
+			t.Errorf("ring %v exp: 8 nodes, got %v\n", name, n)
+		}
+	}
+}
+
+func benchmarkWriters(b *testing.B, ring func(tenant int) *Consistent) {
+	var next int64
+	b.RunParallel(func(pb *testing.PB) {
+		tenant := int(atomic.AddInt64(&next, 1))
+		node := fmt.Sprintf("node%d", tenant)
+		c := ring(tenant)
+		for pb.Next() {
+			c.AddNode(node)
+			c.RemoveNode(node)
+		}
+	})
+}
+
+func BenchmarkWritersSharedRing(b *testing.B) {
+	c := NewConsistent()
+	c.AddNodes([]string{"n1", "n2", "n3", "n4"})
+	benchmarkWriters(b, func(int) *Consistent { return c })
+}
+
+func BenchmarkWritersRingSet(b *testing.B) {
+	s := NewRingSet(0)
+	benchmarkWriters(b, func(tenant int) *Consistent {
+		c := s.Ring(fmt.Sprint(tenant))
+		c.AddNodes([]string{"n1", "n2", "n3", "n4"})
+		return c
+	})
+}
@@ -0,0 +1,44 @@
+package consistent
+
+import "fmt"
+import "testing"
+
+func TestSipHash(t *testing.T) {
+	var key [16]byte
+	msg := make([]byte, 15)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	for i := range msg {
+		msg[i] = byte(i)
+	}
+	// vectors of SipHash paper, appendix A
+	fn := SipHash(key)
+	if got := fn(msg); got != 0xa129ca6149be45e5 {
+		t.Errorf("SipHash exp: a129ca6149be45e5, got %x\n", got)
+	}
+	if got := fn(nil); got != 0x726fdb47dd0e0e31 {
+		t.Errorf("SipHash of empty exp: 726fdb47dd0e0e31, got %x\n", got)
+	}
+	if other := SipHash([16]byte{1}); other(msg) == fn(msg) {
+		t.Errorf("SipHash should depend on key\n")
+	}
+}
+
+func TestSipHashDistribution(t *testing.T) {
+	c := NewConsistentWithHash(DefaultReplica, SipHash([16]byte{7, 7, 7}))
+	for i := 0; i < 4; i++ {
+		c.AddNode(fmt.Sprintf("node%d", i))
+	}
+	counts := make(map[string]int)
+	for i := 0; i < 4000; i++ {
+		node, _ := c.GetNode(fmt.Sprintf("user%d", i))
+		counts[node]++
+	}
+	for node, n := range counts {
+		// 100 replicas keep every share within about 30% of fair 1000
+		if n < 700 || n > 1300 {
+			t.Errorf("SipHash unbalanced, %v got %v of 4000 keys\n", node, n)
+		}
+	}
+}
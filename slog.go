@@ -0,0 +1,51 @@
+//go:build go1.21
+
+package consistent
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LogLevels sets levels of events logged by WithLogger
+type LogLevels struct {
+	Change    slog.Level // node added, removed or its weight changed
+	Rebuild   slog.Level // published state rebuilt after changes
+	Collision slog.Level // virtual node dropped on hash collision
+	NoOp      slog.Level // adding existing or removing missing node
+	Store     slog.Level // saving to store or change log failed
+}
+
+// DefaultLogLevels are levels of WithLogger
+var DefaultLogLevels = LogLevels{
+	Change:    slog.LevelInfo,
+	Rebuild:   slog.LevelDebug,
+	Collision: slog.LevelWarn,
+	NoOp:      slog.LevelWarn,
+	Store:     slog.LevelError,
+}
+
+// WithLogger logs membership changes, rebuilds, collisions, no-op
+// changes and failed saves and appends to l at DefaultLogLevels
+func WithLogger(l *slog.Logger) Option {
+	return WithLoggerLevels(l, DefaultLogLevels)
+}
+
+// WithLoggerLevels is WithLogger at given levels
+func WithLoggerLevels(l *slog.Logger, levels LogLevels) Option {
+	return func(c *Consistent) {
+		c.logger = &slogLogger{
+			l:      l,
+			levels: [...]slog.Level{levels.Change, levels.Rebuild, levels.Collision, levels.NoOp, levels.Store},
+		}
+	}
+}
+
+type slogLogger struct {
+	l      *slog.Logger
+	levels [5]slog.Level // by logEvent
+}
+
+func (s *slogLogger) log(ev logEvent, msg string, args ...interface{}) {
+	s.l.Log(context.Background(), s.levels[ev], msg, args...)
+}
@@ -0,0 +1,42 @@
+//go:build go1.21
+
+package consistent
+
+import "bytes"
+import "log/slog"
+import "strings"
+import "testing"
+
+func TestWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	replace := func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.TimeKey || a.Key == "duration" {
+			return slog.Attr{}
+		}
+		return a
+	}
+	l := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug, ReplaceAttr: replace}))
+	c := NewConsistentWithOptions(WithLogger(l), WithReplicas(2), WithHashFunc(func([]byte) uint64 { return 1 }))
+	c.AddNode("node1")
+	c.AddNode("node1")
+	c.GetNode("xxx")
+	c.RemoveNode("node2")
+	exp := []string{
+		`level=WARN msg="virtual node dropped on collision" node=node1 hash=1`,
+		`level=INFO msg="node added" node=node1 vnodes=2`,
+		`level=DEBUG msg="ring rebuilt" nodes=1 points=1`,
+		`level=WARN msg="node already exists" node=node1`,
+		`level=DEBUG msg="ring rebuilt" nodes=1 points=1`,
+		`level=WARN msg="node not found" node=node2`,
+		`level=DEBUG msg="ring rebuilt" nodes=1 points=1`,
+	}
+	got := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(got) != len(exp) {
+		t.Fatalf("log exp: %q, got %q\n", exp, got)
+	}
+	for i := range exp {
+		if got[i] != exp[i] {
+			t.Errorf("log line %v exp: %v, got %v\n", i, exp[i], got[i])
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package consistent
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// RingSnapshot is a read-only view of consistent at the time it was taken.
+// Later mutations of consistent don't affect it, and it's safe for
+// concurrent use without locking.
+type RingSnapshot struct {
+	ring      ring
+	count     int
+	hashfunc  HashFunc
+	normalize KeyNormalizer
+	members   []string           // sorted
+	pins      map[string]string  // only pins to current members
+	cache     *shardedCache      // nil unless WithCache
+	table     *lookupTable       // nil unless WithLookupTable
+	counts    map[string]*uint64 // nil unless WithLookupCounters
+}
+
+// Snapshot returns current state of consistent. It's the same state
+// GetNode reads, so it's free until the next mutation.
+func (c *Consistent) Snapshot() *RingSnapshot {
+	return c.current()
+}
+
+// lock takes write lock, writers must release it with unlock. Changes
+// staged by WithLazyRebuild are applied first.
+func (c *Consistent) lock() {
+	c.mu.Lock()
+	c.commit()
+}
+
+// unlock publishes new state, saves changes to store, releases write
+// lock and notifies listeners of applied changes. State is built here, so
+// readers only load it; changes staged by WithLazyRebuild are built by
+// next read instead.
+func (c *Consistent) unlock() {
+	if c.staged {
+		c.state.Store((*RingSnapshot)(nil))
+	} else {
+		c.publish()
+	}
+	if c.store != nil {
+		c.persist()
+	}
+	notify := len(c.listeners) > 0 && !c.staged
+	c.mu.Unlock()
+	if notify {
+		c.notify()
+	}
+}
+
+// rlock takes read lock over fully applied ring, see WithLazyRebuild
+func (c *Consistent) rlock() {
+	for {
+		c.mu.RLock()
+		if !c.staged {
+			return
+		}
+		c.mu.RUnlock()
+		c.Flush()
+	}
+}
+
+func (c *Consistent) runlock() {
+	c.mu.RUnlock()
+}
+
+// current returns published state for lock-free reads. It's built by
+// writers, only first read of a new consistent or after changes staged by
+// WithLazyRebuild builds it.
+func (c *Consistent) current() *RingSnapshot {
+	if s, _ := c.state.Load().(*RingSnapshot); s != nil {
+		return s
+	}
+	c.rlock()
+	defer c.runlock()
+	// writers are excluded, so concurrent rebuilds store equal states
+	if s, _ := c.state.Load().(*RingSnapshot); s != nil {
+		return s
+	}
+	return c.publish()
+}
+
+// publish builds state of consistent and stores it for readers, caller
+// must hold lock
+func (c *Consistent) publish() *RingSnapshot {
+	start := time.Now()
+	s := c.snapshot()
+	c.state.Store(s)
+	d := time.Since(start)
+	c.rebuilds.observe(d)
+	if c.sink != nil {
+		c.sink.Timing("rebuild", d)
+	}
+	if c.logger != nil {
+		c.logger.log(logRebuild, "ring rebuilt", "nodes", s.count, "points", s.ring.Len(), "duration", d)
+	}
+	return s
+}
+
+// rebuildStats sums time spent building published states
+type rebuildStats struct {
+	count uint64
+	nanos uint64
+}
+
+func (r *rebuildStats) observe(d time.Duration) {
+	atomic.AddUint64(&r.count, 1)
+	atomic.AddUint64(&r.nanos, uint64(d))
+}
+
+func (r *rebuildStats) load() (count uint64, total time.Duration) {
+	return atomic.LoadUint64(&r.count), time.Duration(atomic.LoadUint64(&r.nanos))
+}
+
+// snapshot builds state of consistent, caller must hold lock
+func (c *Consistent) snapshot() *RingSnapshot {
+	members := make([]string, 0, len(c.node))
+	for n := range c.node {
+		members = append(members, n)
+	}
+	sort.Strings(members)
+	pins := make(map[string]string)
+	for key, node := range c.pins {
+		if _, ok := c.node[node]; ok {
+			pins[key] = node
+		}
+	}
+	var cache *shardedCache
+	if c.cacheSize > 0 {
+		cache = newShardedCache(c.cacheSize)
+	}
+	var counts map[string]*uint64
+	if c.counters != nil {
+		counts = c.counters.slots(members)
+	}
+	r := snapshotRing(c.ring)
+	return &RingSnapshot{
+		ring:      r,
+		count:     c.count,
+		hashfunc:  c.hashfunc,
+		normalize: c.normalize,
+		members:   members,
+		pins:      pins,
+		cache:     cache,
+		table:     newLookupTable(r, c.tableBits),
+		counts:    counts,
+	}
+}
+
+// GetNode returns first found node
+func (s *RingSnapshot) GetNode(key string) (string, error) {
+	return s.getNode(keyBytes(key))
+}
+
+func (s *RingSnapshot) getNode(key []byte) (string, error) {
+	if s.ring.Len() == 0 {
+		return "", ErrNoNodes
+	}
+	if s.cache == nil {
+		node := s.lookup(key)
+		s.counted(node)
+		return node, nil
+	}
+	node, ok := s.cache.get(key)
+	if !ok {
+		node = s.lookup(key)
+		s.cache.add(key, node)
+	}
+	s.counted(node)
+	return node, nil
+}
+
+func (s *RingSnapshot) lookup(key []byte) string {
+	k := s.normalized(key)
+	if node, ok := s.pins[string(k)]; ok {
+		return node
+	}
+	return s.ring.Owner(s.table.search(s.ring, s.hashfunc(k)))
+}
+
+// GetNNode returns found distinct nodes with given n
+func (s *RingSnapshot) GetNNode(key string, n int) ([]string, error) {
+	return s.getNNode(keyBytes(key), n)
+}
+
+func (s *RingSnapshot) getNNode(key []byte, n int) ([]string, error) {
+	nodes, err := s.appendNNode(nil, key, n)
+	if err != nil {
+		return []string{}, err
+	}
+	return nodes, nil
+}
+
+// GetNNodeAppend is GetNNode appending nodes to dst, so callers can reuse
+// result slice. On error dst is returned as is.
+func (s *RingSnapshot) GetNNodeAppend(dst []string, key string, n int) ([]string, error) {
+	return s.appendNNode(dst, keyBytes(key), n)
+}
+
+// appendNNode fails with ErrNotEnoughNodes also when members shadowed by
+// collisions leave less than n nodes owning points
+func (s *RingSnapshot) appendNNode(dst []string, key []byte, n int) ([]string, error) {
+	if n > s.count {
+		return dst, ErrNotEnoughNodes
+	}
+	from := len(dst)
+	dst = s.appendOwners(dst, key, n)
+	if len(dst)-from < n {
+		return dst[:from], ErrNotEnoughNodes
+	}
+	if s.counts != nil {
+		for _, node := range dst[from:] {
+			s.counted(node)
+		}
+	}
+	return dst, nil
+}
+
+// appendOwners is appendNNode without counting lookups
+func (s *RingSnapshot) appendOwners(dst []string, key []byte, n int) []string {
+	k := s.normalized(key)
+	ind := s.table.search(s.ring, s.hashfunc(k))
+	from := len(dst)
+	if node, ok := s.pins[string(k)]; ok && n > 0 {
+		dst = append(dst, node)
+	}
+	return appendDistinct(dst, from, s.ring, ind, n)
+}
+
+// NodeNumber return physical node number
+func (s *RingSnapshot) NodeNumber() int {
+	return s.count
+}
+
+// Members returns sorted copy of physical nodes
+func (s *RingSnapshot) Members() []string {
+	return append([]string(nil), s.members...)
+}
+
+// HasNode tests exsiting node
+func (s *RingSnapshot) HasNode(node string) bool {
+	i := sort.SearchStrings(s.members, node)
+	return i < len(s.members) && s.members[i] == node
+}
+
+func (s *RingSnapshot) normalized(key []byte) []byte {
+	if s.normalize == nil {
+		return key
+	}
+	return s.normalize(string(key))
+}
@@ -0,0 +1,121 @@
+package consistent
+
+import "fmt"
+import "reflect"
+import "testing"
+
+func TestSnapshot(t *testing.T) {
+	for _, opt := range []Option{WithReplicas(DefaultReplica), WithPersistentRing()} {
+		c := NewConsistentWithOptions(opt)
+		c.AddNodes([]string{"node1", "node2", "node3", "node4", "node5"})
+		s := c.Snapshot()
+		exp, _ := c.GetNNode("xxx", 3)
+
+		c.RemoveNodes([]string{"node1", "node2", "node3", "node4", "node5"})
+		c.AddNode("node6")
+
+		if !reflect.DeepEqual(s.Members(), []string{"node1", "node2", "node3", "node4", "node5"}) {
+			t.Errorf("Wrong Members(), got %v\n", s.Members())
+		}
+		if !s.HasNode("node3") || s.HasNode("node6") {
+			t.Errorf("Wrong HasNode()\n")
+		}
+		if s.NodeNumber() != 5 {
+			t.Errorf("Wrong NodeNumber(), exp: 5, got %v\n", s.NodeNumber())
+		}
+		if node, err := s.GetNode("Abc"); err != nil || node != "node1" {
+			t.Errorf("GetNode err: %v, exp: node1, got: %v\n", err, node)
+		}
+		if nodes, err := s.GetNNode("xxx", 3); err != nil || !reflect.DeepEqual(nodes, exp) {
+			t.Errorf("GetNNode err: %v, exp: %v, got: %v\n", err, exp, nodes)
+		}
+	}
+}
+
+func TestSnapshotConcurrent(t *testing.T) {
+	c := NewConsistentWithOptions(WithPersistentRing())
+	c.AddNodes([]string{"node1", "node2", "node3"})
+	s := c.Snapshot()
+	exp, _ := s.GetNode("xxx")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			c.AddNode("node4")
+			c.RemoveNode("node4")
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		if node, _ := s.GetNode("xxx"); node != exp {
+			t.Errorf("Snapshot changed, exp: %v, got %v\n", exp, node)
+		}
+	}
+	<-done
+}
+
+func TestReadState(t *testing.T) {
+	c := NewConsistent()
+	c.AddNodes([]string{"node1", "node2", "node3"})
+	if c.Snapshot() != c.Snapshot() {
+		t.Errorf("Snapshot should be shared until mutation\n")
+	}
+	s := c.Snapshot()
+	c.RemoveNode("node1")
+	if c.Snapshot() == s {
+		t.Errorf("Snapshot should be rebuilt after mutation\n")
+	}
+	for i := 0; i < 100; i++ {
+		if node, _ := c.GetNode(fmt.Sprint(i)); node == "node1" {
+			t.Errorf("GetNode returned removed node1\n")
+		}
+	}
+	c.AddNode("node1")
+	if !c.Snapshot().HasNode("node1") {
+		t.Errorf("GetNode state misses added node1\n")
+	}
+}
+
+func BenchmarkGetNodeParallel(b *testing.B) {
+	b.ReportAllocs()
+	c := NewConsistent()
+	c.AddNodes([]string{"n1", "n2", "n3", "n4", "n5", "n6", "n7", "n8", "n9", "n10", "n11", "n12"})
+	b.SetParallelism(64)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c.GetNode("xxx")
+		}
+	})
+}
+
+func TestAccessorsConcurrent(t *testing.T) {
+	c := NewConsistent()
+	c.AddNodes([]string{"node1", "node2"})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			c.AddNode("node3")
+			c.RemoveNode("node3")
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		n, members := c.NodeNumber(), c.Members()
+		if n < 2 || n > 3 || len(members) < 2 || !c.HasNode("node1") {
+			t.Errorf("accessors saw partial state, nodes: %v, members: %v\n", n, members)
+		}
+	}
+	<-done
+}
+
+func TestRebuildStats(t *testing.T) {
+	c := NewConsistent()
+	c.AddNode("node1")
+	c.GetNode("xxx")
+	c.GetNode("yyy")
+	c.AddNode("node2")
+	c.GetNode("xxx")
+	if count, total := c.rebuilds.load(); count != 2 || total <= 0 {
+		t.Errorf("rebuilds exp: 2, got %v in %v\n", count, total)
+	}
+}
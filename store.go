@@ -0,0 +1,80 @@
+package consistent
+
+// Store persists membership of consistent, see WithStore
+type Store interface {
+	// Load returns saved config, zero Config if nothing was saved
+	Load() (Config, error)
+	// Save replaces saved config at once
+	Save(cfg Config) error
+}
+
+// WithStore saves Config of consistent to s after each committed change
+// of membership, node info or settings, under write lock so saves keep
+// order of changes. Failed save is logged, see WithLogger, kept for
+// StoreError and retried on next change or by Sync.
+// NewConsistentFromStore restores saved ring on startup.
+func WithStore(s Store) Option {
+	return func(c *Consistent) { c.store = s }
+}
+
+// NewConsistentFromStore returns consistent of config saved in s, which
+// keeps saving its changes. Empty store gives empty consistent, options
+// apply first. Config carries only hash names, so ring of unnamed hash
+// function, see SetHashFunc, must be given it again by WithHashFunc.
+func NewConsistentFromStore(s Store, opts ...Option) (*Consistent, error) {
+	cfg, err := s.Load()
+	if err != nil {
+		return nil, err
+	}
+	c := NewConsistentWithOptions(append(opts[:len(opts):len(opts)], WithStore(s))...)
+	if cfg.Replicas == 0 {
+		return c, nil
+	}
+	c.lock()
+	defer c.unlock()
+	if err := c.setConfig(cfg, nil); err != nil {
+		return nil, err
+	}
+	// loaded state is saved already
+	c.saved, c.unsaved = c.epoch, false
+	return c, nil
+}
+
+// Sync saves config of consistent to store of WithStore now
+func (c *Consistent) Sync() error {
+	c.lock()
+	defer c.unlock()
+	if c.store == nil {
+		return nil
+	}
+	return c.save()
+}
+
+// StoreError returns error of last failed save to store of WithStore, nil
+// once changes are saved
+func (c *Consistent) StoreError() error {
+	c.rlock()
+	defer c.runlock()
+	return c.storeErr
+}
+
+// persist saves config if it changed since last save, caller must hold
+// write lock
+func (c *Consistent) persist() {
+	if c.epoch == c.saved && !c.unsaved {
+		return
+	}
+	if err := c.save(); err != nil && c.logger != nil {
+		c.logger.log(logStore, "saving to store failed", "epoch", c.epoch, "error", err)
+	}
+}
+
+// save saves config to store, caller must hold write lock
+func (c *Consistent) save() error {
+	if err := c.store.Save(c.config()); err != nil {
+		c.storeErr = err
+		return err
+	}
+	c.saved, c.unsaved, c.storeErr = c.epoch, false, nil
+	return nil
+}
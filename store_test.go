@@ -0,0 +1,112 @@
+package consistent
+
+import "errors"
+import "reflect"
+import "testing"
+
+type memStore struct {
+	cfg   Config
+	saves int
+	err   error
+}
+
+func (s *memStore) Load() (Config, error) { return s.cfg, nil }
+
+func (s *memStore) Save(cfg Config) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.cfg = cfg
+	s.saves++
+	return nil
+}
+
+func TestStore(t *testing.T) {
+	s := &memStore{}
+	c, err := NewConsistentFromStore(s, WithReplicas(10))
+	if err != nil || c.NodeNumber() != 0 || s.saves != 0 {
+		t.Fatalf("empty store exp: empty ring, got %v %v\n", c.Members(), err)
+	}
+	c.AddNodes([]string{"node1", "node2"})
+	c.AddNode("node3")
+	c.AddNode("node3")
+	c.PinKey("key", "node1")
+	if s.saves != 2 || !reflect.DeepEqual(s.cfg, c.Config()) {
+		t.Errorf("saves exp: 2 of %+v, got %v of %+v\n", c.Config(), s.saves, s.cfg)
+	}
+
+	s.err = errors.New("disk full")
+	c.RemoveNode("node3")
+	if len(s.cfg.Nodes) != 3 {
+		t.Errorf("failed save exp: 3 nodes kept, got %+v\n", s.cfg)
+	}
+	if err := c.Sync(); err != s.err {
+		t.Errorf("Sync exp: %v, got %v\n", s.err, err)
+	}
+	s.err = nil
+	if err := c.Sync(); err != nil || !reflect.DeepEqual(s.cfg, c.Config()) {
+		t.Errorf("Sync exp: %+v, got %+v %v\n", c.Config(), s.cfg, err)
+	}
+
+	saves := s.saves
+	got, err := NewConsistentFromStore(s)
+	if err != nil || s.saves != saves {
+		t.Fatalf("NewConsistentFromStore exp: no saves, got %v %v\n", s.saves-saves, err)
+	}
+	if !reflect.DeepEqual(got.Config(), c.Config()) || got.Fingerprint() != c.Fingerprint() {
+		t.Errorf("restored config exp: %+v, got %+v\n", c.Config(), got.Config())
+	}
+	got.RemoveNode("node1")
+	if s.saves != saves+1 || len(s.cfg.Nodes) != 1 {
+		t.Errorf("restored ring saves exp: 1 of node2, got %v %+v\n", s.saves-saves, s.cfg)
+	}
+
+	s.cfg.Replicas = -1
+	if _, err := NewConsistentFromStore(s); !errors.Is(err, ErrInvalidReplicas) {
+		t.Errorf("invalid stored config exp: %v, got %v\n", ErrInvalidReplicas, err)
+	}
+}
+
+func TestStoreSettings(t *testing.T) {
+	s := &memStore{}
+	c, _ := NewConsistentFromStore(s, WithReplicas(10))
+	c.AddNodes([]string{"node1", "node2"})
+
+	ops := []struct {
+		name string
+		op   func() error
+	}{
+		{"SetHashName", func() error { return c.SetHashName("xxhash64") }},
+		{"SetReplicas", func() error { return c.SetReplicas(20) }},
+		{"AddNodeInfo", func() error {
+			c.AddNodeInfo(Node{ID: "node1", Meta: map[string]string{ZoneLabel: "z1"}})
+			return nil
+		}},
+		{"AddNodeWithCapacity", func() error { return c.AddNodeWithCapacity("node3", 2) }},
+		{"SetCapacity", func() error { return c.SetCapacity("node3", 3) }},
+	}
+	for _, tt := range ops {
+		saves := s.saves
+		if err := tt.op(); err != nil {
+			t.Fatalf("%s %v\n", tt.name, err)
+		}
+		if s.saves == saves || !reflect.DeepEqual(s.cfg, c.Config()) {
+			t.Errorf("%s exp: saved %+v, got %+v\n", tt.name, c.Config(), s.cfg)
+		}
+	}
+	got, err := NewConsistentFromStore(s)
+	if err != nil || got.Fingerprint() != c.Fingerprint() || !reflect.DeepEqual(got.Config(), c.Config()) {
+		t.Errorf("restored exp: %+v, got %+v %v\n", c.Config(), got.Config(), err)
+	}
+
+	s.err = errors.New("disk full")
+	c.AddNode("node4")
+	if err := c.StoreError(); err != s.err {
+		t.Errorf("StoreError exp: %v, got %v\n", s.err, err)
+	}
+	s.err = nil
+	c.AddNode("node5")
+	if err := c.StoreError(); err != nil || len(s.cfg.Nodes) != 5 {
+		t.Errorf("StoreError after save exp: nil, got %v %+v\n", err, s.cfg)
+	}
+}
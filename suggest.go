@@ -0,0 +1,43 @@
+package consistent
+
+import "math"
+
+// SuggestWeights returns virtual node numbers, for SetVirtualNodes, of
+// nodes whose lookups counted by WithLookupCounters exceed target times
+// mean, scaled to bring them to the mean. It assumes lookups spread evenly
+// over points of a node. Target less than 1 is treated as 1. Without
+// counters or lookups nothing is suggested.
+func (c *Consistent) SuggestWeights(target float64) map[string]int {
+	if target < 1 {
+		target = 1
+	}
+	counts := c.LoadCounters()
+	c.rlock()
+	defer c.runlock()
+	suggested := map[string]int{}
+	if len(c.node) == 0 {
+		return suggested
+	}
+	var sum float64
+	for node := range c.node {
+		sum += float64(counts[node])
+	}
+	mean := sum / float64(len(c.node))
+	if mean == 0 {
+		return suggested
+	}
+	for node, vnodes := range c.node {
+		load := float64(counts[node])
+		if load <= target*mean {
+			continue
+		}
+		n := int(math.Round(float64(vnodes) * mean / load))
+		if n < 1 {
+			n = 1
+		}
+		if n != vnodes {
+			suggested[node] = n
+		}
+	}
+	return suggested
+}
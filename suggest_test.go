@@ -0,0 +1,42 @@
+package consistent
+
+import "reflect"
+import "testing"
+
+func TestSuggestWeights(t *testing.T) {
+	c := NewConsistentWithOptions(WithLookupCounters())
+	c.AddNodes([]string{"node1", "node2", "node3"})
+	if got := c.SuggestWeights(1.2); len(got) != 0 {
+		t.Errorf("SuggestWeights without lookups exp: none, got %v\n", got)
+	}
+
+	// node1 gets twice the mean of 100 lookups, counters exist once
+	// state is published
+	c.Snapshot()
+	*c.counters.count["node1"] = 200
+	*c.counters.count["node2"] = 60
+	*c.counters.count["node3"] = 40
+	tests := []struct {
+		target float64
+		exp    map[string]int
+	}{
+		{1.5, map[string]int{"node1": DefaultReplica / 2}},
+		{2, map[string]int{}},
+		{0, map[string]int{"node1": DefaultReplica / 2}},
+	}
+	for _, tt := range tests {
+		if got := c.SuggestWeights(tt.target); !reflect.DeepEqual(got, tt.exp) {
+			t.Errorf("SuggestWeights(%v) exp: %v, got %v\n", tt.target, tt.exp, got)
+		}
+	}
+
+	for node, vnodes := range c.SuggestWeights(1.5) {
+		c.SetVirtualNodes(node, vnodes)
+	}
+	if got := c.VirtualNodes("node1"); got != DefaultReplica/2 {
+		t.Errorf("SetVirtualNodes exp: %v, got %v\n", DefaultReplica/2, got)
+	}
+	if err := c.SetVirtualNodes("node4", 1); err != ErrNodeNotFound {
+		t.Errorf("SetVirtualNodes of unknown node exp: %v, got %v\n", ErrNodeNotFound, err)
+	}
+}
@@ -0,0 +1,61 @@
+package consistent
+
+import "sort"
+
+// DefaultTableBits is hash prefix bits of WithLookupTable for bits <= 0
+const DefaultTableBits = 16
+
+// WithLookupTable indexes ring GetNode family reads by top bits of hash,
+// 2^bits slots of 4 bytes each, so lookups jump next to the owning point
+// instead of binary searching the whole ring. Placement is unchanged.
+// Table is rebuilt with published state after membership changes.
+func WithLookupTable(bits int) Option {
+	return func(c *Consistent) {
+		if bits <= 0 {
+			bits = DefaultTableBits
+		}
+		if bits > 24 {
+			bits = 24
+		}
+		c.tableBits = bits
+	}
+}
+
+// lookupTable holds index of first point of every hash prefix slot, plus
+// ring length as sentinel
+type lookupTable struct {
+	bits  uint
+	first []uint32
+}
+
+func newLookupTable(r ring, bits int) *lookupTable {
+	if bits <= 0 {
+		return nil
+	}
+	slots := 1 << uint(bits)
+	t := &lookupTable{bits: uint(bits), first: make([]uint32, slots+1)}
+	i, n := 0, r.Len()
+	for s := 0; s < slots; s++ {
+		start := uint64(s) << (64 - t.bits)
+		for i < n && r.Hash(i) < start {
+			i++
+		}
+		t.first[s] = uint32(i)
+	}
+	t.first[slots] = uint32(n)
+	return t
+}
+
+// search is ring Search narrowed to slot of h
+func (t *lookupTable) search(r ring, h uint64) int {
+	if t == nil {
+		return r.Search(h)
+	}
+	slot := h >> (64 - t.bits)
+	lo, hi := int(t.first[slot]), int(t.first[slot+1])
+	i := lo + sort.Search(hi-lo, func(j int) bool { return r.Hash(lo+j) >= h })
+	if i >= r.Len() {
+		i = 0
+	}
+	return i
+}
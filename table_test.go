@@ -0,0 +1,53 @@
+package consistent
+
+import "fmt"
+import "math/rand"
+import "reflect"
+import "testing"
+
+func TestLookupTable(t *testing.T) {
+	for _, bits := range []int{0, 1, 8, 20} {
+		c := NewConsistentWithOptions(WithLookupTable(bits))
+		plain := NewConsistent()
+		for i := 0; i < 30; i++ {
+			c.AddNode(fmt.Sprintf("node%d", i))
+			plain.AddNode(fmt.Sprintf("node%d", i))
+		}
+		c.RemoveNode("node5")
+		plain.RemoveNode("node5")
+		for i := 0; i < 1000; i++ {
+			key := fmt.Sprint(i)
+			exp, _ := plain.GetNNode(key, 3)
+			if got, _ := c.GetNNode(key, 3); !reflect.DeepEqual(got, exp) {
+				t.Errorf("bits %v GetNNode(%v) exp: %v, got %v\n", bits, key, exp, got)
+			}
+		}
+	}
+}
+
+func TestLookupTableSearch(t *testing.T) {
+	r := newSliceRing()
+	r.Insert("a", []uint64{0, 1, 1 << 62, 3 << 62, 1<<64 - 1})
+	table := newLookupTable(r, 4)
+	rnd := rand.New(rand.NewSource(1))
+	hashes := []uint64{0, 1, 2, 1 << 62, 1<<62 + 1, 3 << 62, 1<<64 - 1}
+	for i := 0; i < 100; i++ {
+		hashes = append(hashes, rnd.Uint64())
+	}
+	for _, h := range hashes {
+		if exp, got := r.Search(h), table.search(r, h); got != exp {
+			t.Errorf("search(%x) exp: %v, got %v\n", h, exp, got)
+		}
+	}
+}
+
+func BenchmarkGetNodeLookupTable(b *testing.B) {
+	c := NewConsistentWithOptions(WithLookupTable(0))
+	for i := 0; i < 1000; i++ {
+		c.AddNode(fmt.Sprintf("node%d", i))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.GetNode("xxx")
+	}
+}
@@ -0,0 +1,250 @@
+package consistent
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseTopology reads ring definition of line based DSL, one setting or
+// node per line, # starting comments:
+//
+//	replicas 100
+//	hash xxhash64
+//	node cache-1 weight=2 zone=us-east-1a
+//	node cache-2 vnodes=150 address=10.0.0.2:11211 rack="r 2"
+//
+// Node gets weight*replicas virtual nodes, weight 1 by default, or exact
+// vnodes, kept as Explicit. Attributes zone, address, token and capacity fill NodeConfig,
+// zone as ZoneLabel of meta, others go to meta. Values holding spaces,
+// quotes or # are quoted as Go strings. Replicas default to
+// DefaultReplica, and errors wrap ErrInvalidConfig with line number.
+func ParseTopology(r io.Reader) (Config, error) {
+	cfg := Config{Replicas: DefaultReplica}
+	type weighted struct {
+		node   int // index in cfg.Nodes
+		weight int
+	}
+	var pending []weighted
+	seen := make(map[string]int)
+	settings := make(map[string]int)
+	s := bufio.NewScanner(r)
+	line := 0
+	fail := func(format string, args ...interface{}) (Config, error) {
+		return Config{}, fmt.Errorf("%w: topology line %d: %s", ErrInvalidConfig, line, fmt.Sprintf(format, args...))
+	}
+	for s.Scan() {
+		line++
+		fields, err := topologyFields(s.Text())
+		if err != nil {
+			return fail("%v", err)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "replicas", "hash", "seed", "node":
+		default:
+			return fail("unknown directive %q", fields[0])
+		}
+		if fields[0] != "node" {
+			if prev, ok := settings[fields[0]]; ok {
+				return fail("%s already set at line %d", fields[0], prev)
+			}
+			settings[fields[0]] = line
+			if len(fields) != 2 {
+				return fail("expected %s and value", fields[0])
+			}
+		}
+		switch fields[0] {
+		case "replicas":
+			n, err := strconv.Atoi(fields[1])
+			if err != nil || n <= 0 {
+				return fail("replicas must be positive integer, got %q", fields[1])
+			}
+			cfg.Replicas = n
+		case "hash":
+			if _, err := lookupHash(fields[1]); err != nil {
+				return fail("unknown hash %q", fields[1])
+			}
+			cfg.Hash = fields[1]
+		case "seed":
+			if cfg.Seed, err = strconv.ParseUint(fields[1], 0, 64); err != nil {
+				return fail("seed must be unsigned integer, got %q", fields[1])
+			}
+		case "node":
+			if len(fields) < 2 || strings.Contains(fields[1], "=") {
+				return fail("node needs name")
+			}
+			n := NodeConfig{Node: fields[1]}
+			if prev, ok := seen[n.Node]; ok {
+				return fail("node %q already defined at line %d", n.Node, prev)
+			}
+			seen[n.Node] = line
+			weight := 0
+			attrs := make(map[string]bool)
+			for _, f := range fields[2:] {
+				i := strings.Index(f, "=")
+				if i <= 0 {
+					return fail("node %q: expected key=value, got %q", n.Node, f)
+				}
+				key, value := f[:i], f[i+1:]
+				if attrs[key] {
+					return fail("node %q: %s set twice", n.Node, key)
+				}
+				attrs[key] = true
+				switch key {
+				case "weight", "vnodes":
+					v, err := strconv.Atoi(value)
+					if err != nil || v <= 0 {
+						return fail("node %q: %s must be positive integer, got %q", n.Node, key, value)
+					}
+					if attrs["weight"] && attrs["vnodes"] {
+						return fail("node %q: weight and vnodes are exclusive", n.Node)
+					}
+					if key == "weight" {
+						weight = v
+					} else {
+						n.VNodes, n.Explicit = v, true
+					}
+				case "capacity":
+					v, err := strconv.ParseFloat(value, 64)
+					if err != nil || v <= 0 {
+						return fail("node %q: capacity must be positive number, got %q", n.Node, value)
+					}
+					n.Capacity = v
+				case "address":
+					n.Address = value
+				case "token":
+					n.Token = value
+				default:
+					if n.Meta == nil {
+						n.Meta = make(map[string]string)
+					}
+					// zone is ZoneLabel
+					n.Meta[key] = value
+				}
+			}
+			if n.VNodes == 0 {
+				if weight == 0 {
+					weight = 1
+				}
+				pending = append(pending, weighted{len(cfg.Nodes), weight})
+			}
+			cfg.Nodes = append(cfg.Nodes, n)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return Config{}, err
+	}
+	// replicas may follow nodes
+	for _, p := range pending {
+		cfg.Nodes[p.node].VNodes = p.weight * cfg.Replicas
+	}
+	return cfg, nil
+}
+
+// WriteTopology writes cfg in DSL of ParseTopology, nodes in name order
+// and their meta in key order, so edits make small diffs. Virtual nodes
+// are written as weight when multiple of replicas and not Explicit. Meta keys must be
+// words not taken by node attributes.
+func WriteTopology(w io.Writer, cfg Config) error {
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	nodes := append([]NodeConfig(nil), cfg.Nodes...)
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Node < nodes[j].Node })
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "replicas %d\n", cfg.Replicas)
+	if cfg.Hash != "" {
+		fmt.Fprintf(bw, "hash %s\n", topologyValue(cfg.Hash))
+	}
+	if cfg.Seed != 0 {
+		fmt.Fprintf(bw, "seed %d\n", cfg.Seed)
+	}
+	for _, n := range nodes {
+		if strings.Contains(n.Node, "=") {
+			return fmt.Errorf("%w: node %q: name holds =", ErrInvalidConfig, n.Node)
+		}
+		fmt.Fprintf(bw, "node %s", topologyValue(n.Node))
+		if n.Explicit || n.VNodes%cfg.Replicas != 0 {
+			fmt.Fprintf(bw, " vnodes=%d", n.VNodes)
+		} else if weight := n.VNodes / cfg.Replicas; weight != 1 {
+			fmt.Fprintf(bw, " weight=%d", weight)
+		}
+		if zone, ok := n.Meta[ZoneLabel]; ok {
+			fmt.Fprintf(bw, " %s=%s", ZoneLabel, topologyValue(zone))
+		}
+		if n.Address != "" {
+			fmt.Fprintf(bw, " address=%s", topologyValue(n.Address))
+		}
+		if n.Token != "" {
+			fmt.Fprintf(bw, " token=%s", topologyValue(n.Token))
+		}
+		if n.Capacity != 0 {
+			fmt.Fprintf(bw, " capacity=%s", strconv.FormatFloat(n.Capacity, 'g', -1, 64))
+		}
+		keys := make([]string, 0, len(n.Meta))
+		for k := range n.Meta {
+			if k != ZoneLabel {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			switch {
+			case k == "weight" || k == "vnodes" || k == "address" || k == "token" || k == "capacity":
+				return fmt.Errorf("%w: node %q: meta key %q is node attribute", ErrInvalidConfig, n.Node, k)
+			case k == "" || k != topologyValue(k) || strings.Contains(k, "="):
+				return fmt.Errorf("%w: node %q: meta key %q isn't a word", ErrInvalidConfig, n.Node, k)
+			}
+			fmt.Fprintf(bw, " %s=%s", k, topologyValue(n.Meta[k]))
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// topologyValue quotes s if it isn't a plain word
+func topologyValue(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\"#") || strconv.Quote(s) != `"`+s+`"` {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// topologyFields splits line into fields at spaces, unquoting quoted
+// values, up to # comment
+func topologyFields(line string) ([]string, error) {
+	var fields []string
+	for {
+		line = strings.TrimLeft(line, " \t\r")
+		if line == "" || line[0] == '#' {
+			return fields, nil
+		}
+		end := strings.IndexAny(line, " \t\r\"")
+		if end < 0 {
+			return append(fields, line), nil
+		}
+		if line[end] != '"' {
+			fields = append(fields, line[:end])
+			line = line[end:]
+			continue
+		}
+		// quoted value, e.g. rack="r 2"
+		prefix := line[:end]
+		quoted, err := strconv.QuotedPrefix(line[end:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid quoted string %s", line[end:])
+		}
+		value, _ := strconv.Unquote(quoted)
+		fields = append(fields, prefix+value)
+		line = line[end+len(quoted):]
+		if line != "" && line[0] != ' ' && line[0] != '\t' && line[0] != '\r' {
+			return nil, fmt.Errorf("expected space after %s", quoted)
+		}
+	}
+}
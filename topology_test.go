@@ -0,0 +1,106 @@
+package consistent
+
+import "bytes"
+import "errors"
+import "reflect"
+import "strings"
+import "testing"
+
+func TestParseTopology(t *testing.T) {
+	data := `# cache ring
+node cache-1 weight=2 zone=us-east-1a
+node cache-2 vnodes=15 address=10.0.0.2:11211 rack="r 2" # spare
+node cache-3 token=cache-0 capacity=1.5
+
+replicas 10
+hash xxhash64
+`
+	exp := Config{Replicas: 10, Hash: "xxhash64", Nodes: []NodeConfig{
+		{Node: "cache-1", VNodes: 20, Meta: map[string]string{ZoneLabel: "us-east-1a"}},
+		{Node: "cache-2", VNodes: 15, Explicit: true, Address: "10.0.0.2:11211", Meta: map[string]string{"rack": "r 2"}},
+		{Node: "cache-3", VNodes: 10, Token: "cache-0", Capacity: 1.5},
+	}}
+	cfg, err := ParseTopology(strings.NewReader(data))
+	if err != nil || !reflect.DeepEqual(cfg, exp) {
+		t.Fatalf("ParseTopology exp: %+v, got %+v %v\n", exp, cfg, err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTopology(&buf, cfg); err != nil {
+		t.Fatalf("WriteTopology %v\n", err)
+	}
+	written := `replicas 10
+hash xxhash64
+node cache-1 weight=2 zone=us-east-1a
+node cache-2 vnodes=15 address=10.0.0.2:11211 rack="r 2"
+node cache-3 token=cache-0 capacity=1.5
+`
+	if buf.String() != written {
+		t.Errorf("WriteTopology exp: %q, got %q\n", written, buf.String())
+	}
+	if again, err := ParseTopology(&buf); err != nil || !reflect.DeepEqual(again, exp) {
+		t.Errorf("parsed written topology exp: %+v, got %+v %v\n", exp, again, err)
+	}
+}
+
+func TestWriteTopologyRing(t *testing.T) {
+	c := NewConsistentWithOptions(WithReplicas(20), WithSeed(9))
+	c.AddNodes([]string{"node1", "node2"})
+	c.SetVirtualNodes("node2", 7)
+	c.AddNodeInfo(Node{ID: "node 3", Meta: map[string]string{"rack": `"r#3"`, ZoneLabel: "z1"}})
+	c.ReplaceNode("node1", "node4")
+	var buf bytes.Buffer
+	if err := WriteTopology(&buf, c.Config()); err != nil {
+		t.Fatalf("WriteTopology %v\n", err)
+	}
+	cfg, err := ParseTopology(&buf)
+	if err != nil {
+		t.Fatalf("ParseTopology %v\n", err)
+	}
+	cfg.Epoch = c.Epoch()
+	if !reflect.DeepEqual(cfg, c.Config()) {
+		t.Errorf("round trip exp: %+v, got %+v\n", c.Config(), cfg)
+	}
+
+	bad := []NodeConfig{
+		{Node: "a=b", VNodes: 1},
+		{Node: "a", VNodes: 1, Meta: map[string]string{"weight": "2"}},
+		{Node: "a", VNodes: 1, Meta: map[string]string{"r k": "v"}},
+	}
+	for _, n := range bad {
+		err := WriteTopology(&buf, Config{Replicas: 1, Nodes: []NodeConfig{n}})
+		if !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("WriteTopology(%+v) exp: %v, got %v\n", n, ErrInvalidConfig, err)
+		}
+	}
+}
+
+func TestParseTopologyErrors(t *testing.T) {
+	tests := []struct {
+		data string
+		msg  string
+	}{
+		{"replica 10\n", `line 1: unknown directive "replica"`},
+		{"replicas\n", "line 1: expected replicas and value"},
+		{"replicas 10\nreplicas 20\n", "line 2: replicas already set at line 1"},
+		{"replicas 0\n", `line 1: replicas must be positive integer, got "0"`},
+		{"hash md5\n", `line 1: unknown hash "md5"`},
+		{"seed -1\n", `line 1: seed must be unsigned integer, got "-1"`},
+		{"node\n", "line 1: node needs name"},
+		{"node weight=2\n", "line 1: node needs name"},
+		{"node a\n\nnode a\n", `line 3: node "a" already defined at line 1`},
+		{"node a weight\n", `line 1: node "a": expected key=value, got "weight"`},
+		{"node a weight=0\n", `line 1: node "a": weight must be positive integer, got "0"`},
+		{"node a weight=2 vnodes=3\n", `line 1: node "a": weight and vnodes are exclusive`},
+		{"node a zone=z1 zone=z2\n", `line 1: node "a": zone set twice`},
+		{"node a capacity=x\n", `line 1: node "a": capacity must be positive number, got "x"`},
+		{"node a rack=\"r1\n", "line 1: invalid quoted string"},
+		{"node a rack=\"r\"1\n", `line 1: expected space after "r"`},
+	}
+	for _, tt := range tests {
+		_, err := ParseTopology(strings.NewReader(tt.data))
+		if !errors.Is(err, ErrInvalidConfig) || !strings.Contains(err.Error(), tt.msg) {
+			t.Errorf("ParseTopology(%q) exp: %v, got %v\n", tt.data, tt.msg, err)
+		}
+	}
+}
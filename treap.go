@@ -0,0 +1,164 @@
+package consistent
+
+// treapNode is immutable once published, mutations copy the path
+// from root to the touched node and share the rest.
+type treapNode struct {
+	hash        uint64
+	node        string
+	prio        uint64
+	size        int
+	left, right *treapNode
+}
+
+func (t *treapNode) len() int {
+	if t == nil {
+		return 0
+	}
+	return t.size
+}
+
+func (t *treapNode) fix() *treapNode {
+	t.size = 1 + t.left.len() + t.right.len()
+	return t
+}
+
+func treapInsert(t *treapNode, h uint64, node string) *treapNode {
+	if t == nil {
+		// priority derives from hash, so shape is deterministic and
+		// no shared random source is needed
+		return &treapNode{hash: h, node: node, prio: mix64(h), size: 1}
+	}
+	n := *t
+	switch {
+	case h == t.hash:
+		// collided point, latest owner wins like map assignment
+		n.node = node
+	case h < t.hash:
+		n.left = treapInsert(t.left, h, node)
+		if n.left.prio > n.prio {
+			// n.left is a fresh copy, safe to modify
+			l := n.left
+			n.left = l.right
+			l.right = n.fix()
+			return l.fix()
+		}
+	default:
+		n.right = treapInsert(t.right, h, node)
+		if n.right.prio > n.prio {
+			r := n.right
+			n.right = r.left
+			r.left = n.fix()
+			return r.fix()
+		}
+	}
+	return n.fix()
+}
+
+func treapDelete(t *treapNode, h uint64) *treapNode {
+	if t == nil {
+		return nil
+	}
+	switch {
+	case h < t.hash:
+		l := treapDelete(t.left, h)
+		if l == t.left {
+			return t
+		}
+		n := *t
+		n.left = l
+		return n.fix()
+	case h > t.hash:
+		r := treapDelete(t.right, h)
+		if r == t.right {
+			return t
+		}
+		n := *t
+		n.right = r
+		return n.fix()
+	}
+	return treapMerge(t.left, t.right)
+}
+
+// treapMerge joins two treaps where all hashes of a are less than b's
+func treapMerge(a, b *treapNode) *treapNode {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	if a.prio > b.prio {
+		n := *a
+		n.right = treapMerge(a.right, b)
+		return n.fix()
+	}
+	n := *b
+	n.left = treapMerge(a, b.left)
+	return n.fix()
+}
+
+// treapRing is a persistent order statistic treap
+type treapRing struct {
+	root *treapNode
+}
+
+func (r *treapRing) at(i int) *treapNode {
+	t := r.root
+	for {
+		l := t.left.len()
+		switch {
+		case i < l:
+			t = t.left
+		case i == l:
+			return t
+		default:
+			i -= l + 1
+			t = t.right
+		}
+	}
+}
+
+func (r *treapRing) Len() int           { return r.root.len() }
+func (r *treapRing) Hash(i int) uint64  { return r.at(i).hash }
+func (r *treapRing) Owner(i int) string { return r.at(i).node }
+
+func (r *treapRing) Search(h uint64) int {
+	rank := 0
+	for t := r.root; t != nil; {
+		if t.hash < h {
+			rank += t.left.len() + 1
+			t = t.right
+		} else {
+			t = t.left
+		}
+	}
+	if rank >= r.Len() {
+		rank = 0
+	}
+	return rank
+}
+
+func (r *treapRing) Lookup(h uint64) (string, bool) {
+	return lookupBySearch(r, h)
+}
+
+func (r *treapRing) Insert(node string, hashes []uint64) {
+	for _, h := range hashes {
+		r.root = treapInsert(r.root, h, node)
+	}
+}
+
+func (r *treapRing) Delete(hashes []uint64) {
+	for _, h := range hashes {
+		r.root = treapDelete(r.root, h)
+	}
+}
+
+// Clone is O(1), both rings share nodes until either one mutates
+func (r *treapRing) Clone() ring {
+	return &treapRing{root: r.root}
+}
+
+func (r *treapRing) Reset() {
+	r.root = nil
+}
@@ -0,0 +1,52 @@
+package consistent
+
+import "fmt"
+import "testing"
+
+func TestTreapRingMatchesSliceRing(t *testing.T) {
+	testRingMatchesSliceRing(t, &treapRing{})
+}
+
+func TestTreapRingClone(t *testing.T) {
+	r := &treapRing{}
+	r.Insert("a", []uint64{10, 20, 30})
+	old := r.Clone()
+	r.Insert("b", []uint64{15, 25})
+	r.Delete([]uint64{20})
+
+	if old.Len() != 3 || old.Hash(1) != 20 || old.Owner(1) != "a" {
+		t.Errorf("Clone changed by later mutation, got len: %v, hash: %v\n", old.Len(), old.Hash(1))
+	}
+	exp := []uint64{10, 15, 25, 30}
+	if r.Len() != len(exp) {
+		t.Fatalf("Wrong Len(), exp: %v, got %v\n", len(exp), r.Len())
+	}
+	for i, h := range exp {
+		if r.Hash(i) != h {
+			t.Errorf("Wrong Hash(%v), exp: %v, got %v\n", i, h, r.Hash(i))
+		}
+	}
+}
+
+// benchmarkChurnReads adds and removes a node with a read after each
+// change, so every change is published
+func benchmarkChurnReads(b *testing.B, opts ...Option) {
+	b.ReportAllocs()
+	c := NewConsistentWithOptions(opts...)
+	nodes := make([]string, 1000)
+	for i := range nodes {
+		nodes[i] = fmt.Sprintf("n%v", i)
+	}
+	c.AddNodes(nodes)
+	node := "Node"
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.AddNode(node)
+		c.GetNode("xxx")
+		c.RemoveNode(node)
+		c.GetNode("xxx")
+	}
+}
+
+func BenchmarkChurnReadsSliceRing(b *testing.B)      { benchmarkChurnReads(b) }
+func BenchmarkChurnReadsPersistentRing(b *testing.B) { benchmarkChurnReads(b, WithPersistentRing()) }
@@ -0,0 +1,69 @@
+package consistent
+
+// Tx stages membership changes of Apply, all applied under one write lock
+type Tx struct {
+	c *Consistent
+}
+
+// AddNode to consistent, fails with ErrNodeExists on existing node
+func (tx *Tx) AddNode(node string) error {
+	return tx.AddWeightedNode(node, 1)
+}
+
+// AddWeightedNode adds node with replica number scaled by weight
+func (tx *Tx) AddWeightedNode(node string, weight int) error {
+	if weight < 1 {
+		weight = 1
+	}
+	return tx.c.addNode(node, tx.c.replicas*weight)
+}
+
+// RemoveNode from consistent, fails with ErrNodeNotFound on unknown node
+func (tx *Tx) RemoveNode(node string) error {
+	return tx.c.removeNode(node)
+}
+
+// UpdateWeight changes replica number of node to replicas*weight, like
+// Consistent.UpdateWeight
+func (tx *Tx) UpdateWeight(node string, weight int) error {
+	if weight < 1 {
+		weight = 1
+	}
+	if _, ok := tx.c.node[node]; !ok {
+		return ErrNodeNotFound
+	}
+	tx.c.resizeExplicit(node, tx.c.replicas*weight, false)
+	return nil
+}
+
+// Apply runs fn with a Tx holding the write lock, so readers see either
+// none or all of its changes. Sorted slice ring is ordered once at the end
+// instead of after every change. fn must not call methods of consistent.
+func (c *Consistent) Apply(fn func(tx *Tx)) {
+	c.lock()
+	defer c.unlock()
+	if b, ok := c.ring.(bulkRing); ok && !c.staged {
+		b.Begin()
+		defer b.Commit()
+	}
+	fn(&Tx{c: c})
+}
+
+// Batch removes and adds nodes atomically. All nodes are tried, first
+// error is returned.
+func (c *Consistent) Batch(adds, removes []string) error {
+	var err error
+	c.Apply(func(tx *Tx) {
+		for _, n := range removes {
+			if e := tx.RemoveNode(n); e != nil && err == nil {
+				err = e
+			}
+		}
+		for _, n := range adds {
+			if e := tx.AddNode(n); e != nil && err == nil {
+				err = e
+			}
+		}
+	})
+	return err
+}
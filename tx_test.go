@@ -0,0 +1,54 @@
+package consistent
+
+import "errors"
+import "fmt"
+import "reflect"
+import "testing"
+
+func TestBatch(t *testing.T) {
+	for _, opt := range []Option{WithReplicas(DefaultReplica), WithPersistentRing()} {
+		c := NewConsistentWithOptions(opt)
+		c.AddNodes([]string{"node1", "node2", "node3"})
+		err := c.Batch([]string{"node4", "node5", "node1"}, []string{"node1", "node2", "node9"})
+		if !errors.Is(err, ErrNodeNotFound) {
+			t.Errorf("Batch err, exp: %v, got: %v\n", ErrNodeNotFound, err)
+		}
+
+		exp := NewConsistent()
+		exp.AddNodes([]string{"node1", "node3", "node4", "node5"})
+		if !reflect.DeepEqual(c.Members(), exp.Members()) || c.ring.Len() != exp.ring.Len() {
+			t.Fatalf("Wrong Members(), exp: %v, got %v\n", exp.Members(), c.Members())
+		}
+		for i := 0; i < exp.ring.Len(); i++ {
+			if c.ring.Hash(i) != exp.ring.Hash(i) || c.ring.Owner(i) != exp.ring.Owner(i) {
+				t.Fatalf("Wrong point %v, exp: %v, got %v\n", i, exp.ring.Owner(i), c.ring.Owner(i))
+			}
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	c := NewConsistent()
+	c.AddNodes([]string{"node1", "node2"})
+	c.Apply(func(tx *Tx) {
+		tx.AddNode("node3")
+		tx.UpdateWeight("node3", 3)
+		tx.UpdateWeight("node1", 2)
+		tx.UpdateWeight("node1", 1)
+		tx.RemoveNode("node2")
+		if err := tx.UpdateWeight("node2", 2); !errors.Is(err, ErrNodeNotFound) {
+			t.Errorf("UpdateWeight err, exp: %v, got: %v\n", ErrNodeNotFound, err)
+		}
+	})
+
+	exp := NewConsistent()
+	exp.AddNode("node1")
+	exp.AddWeightedNode("node3", 3)
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key%v", i)
+		n1, _ := exp.GetNode(key)
+		if n2, _ := c.GetNode(key); n1 != n2 {
+			t.Errorf("Wrong node of %v, exp: %v, got %v\n", key, n1, n2)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build go1.20
+
+package consistent
+
+import "unsafe"
+
+// keyBytes returns bytes of key without copying. Callers only pass them to
+// HashFunc, which must not modify or retain them.
+func keyBytes(key string) []byte {
+	return unsafe.Slice(unsafe.StringData(key), len(key))
+}
@@ -0,0 +1,8 @@
+//go:build !go1.20
+
+package consistent
+
+// keyBytes returns bytes of key, copying them before go1.20
+func keyBytes(key string) []byte {
+	return []byte(key)
+}
@@ -0,0 +1,42 @@
+package consistent
+
+// View is a lookup view of consistent salting keys of a namespace, see
+// Namespace
+type View struct {
+	c    *Consistent
+	salt []byte
+}
+
+// Namespace returns view hashing every key prefixed with prefix and a zero
+// byte. Logical keyspaces sharing key strings, e.g. sessions and carts of
+// the same user ID, then spread independently over the same nodes.
+func (c *Consistent) Namespace(prefix string) *View {
+	return &View{c: c, salt: append([]byte(prefix), 0)}
+}
+
+func (v *View) salted(key []byte) []byte {
+	key = v.c.normalized(key)
+	b := make([]byte, 0, len(v.salt)+len(key))
+	return append(append(b, v.salt...), key...)
+}
+
+// GetNode returns first found node of key in namespace
+func (v *View) GetNode(key string) (string, error) {
+	return v.GetNodeBytes([]byte(key))
+}
+
+// GetNodeBytes is GetNode for binary keys
+func (v *View) GetNodeBytes(key []byte) (string, error) {
+	k := v.salted(key)
+	v.c.rlock()
+	defer v.c.runlock()
+	return v.c.getNode(v.c.hashfunc(k))
+}
+
+// GetNNode returns found distinct nodes of key in namespace with given n
+func (v *View) GetNNode(key string, n int) ([]string, error) {
+	k := v.salted([]byte(key))
+	v.c.rlock()
+	defer v.c.runlock()
+	return v.c.getNNode(v.c.hashfunc(k), n)
+}
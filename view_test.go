@@ -0,0 +1,41 @@
+package consistent
+
+import "fmt"
+import "testing"
+
+func TestNamespace(t *testing.T) {
+	c := NewConsistent()
+	c.AddNodes([]string{"node1", "node2", "node3", "node4", "node5"})
+	sessions, carts := c.Namespace("sessions"), c.Namespace("carts")
+
+	exp, _ := c.GetNode("sessions\x00xxx")
+	if node, _ := sessions.GetNode("xxx"); node != exp {
+		t.Errorf("Namespace should salt key with prefix, exp: %v, got: %v\n", exp, node)
+	}
+
+	same := 0
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("user%v", i)
+		s, _ := sessions.GetNode(key)
+		k, _ := carts.GetNode(key)
+		if s == k {
+			same++
+		}
+		if b, _ := sessions.GetNodeBytes([]byte(key)); b != s {
+			t.Errorf("GetNodeBytes differs, exp: %v, got: %v\n", s, b)
+		}
+		nodes, err := sessions.GetNNode(key, 2)
+		if err != nil || nodes[0] != s {
+			t.Errorf("GetNNode err: %v, exp first: %v, got: %v\n", err, s, nodes)
+		}
+	}
+	// independent spread matches by chance about 1/5 of the time
+	if same > 300 {
+		t.Errorf("Namespaces correlated, %v of 1000 keys on same node\n", same)
+	}
+
+	c.RemoveNode("node1")
+	if nodes, err := sessions.GetNNode("xxx", 4); err != nil || len(nodes) != 4 || stringInSlice(nodes, "node1") {
+		t.Errorf("View should follow ring changes, got %v\n", nodes)
+	}
+}
@@ -0,0 +1,181 @@
+package consistent
+
+import (
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Watcher polls topology file of LoadConfig and applies its changes to
+// consistent, see Watch
+type Watcher struct {
+	c       *Consistent
+	path    string
+	onError func(error)
+
+	mu   sync.Mutex // serializes reloads
+	mod  time.Time
+	size int64
+
+	done    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+}
+
+// Watch applies topology file at path to consistent, then checks it every
+// interval and applies edits as one change: removed nodes leave, new
+// nodes join and nodes of changed weight are resized, so only their keys
+// move and listeners of OnChange get those changes. Change of replicas,
+// hash or seed replaces the whole ring. onError, if not nil, is called
+// with errors of reading or parsing the file, which keep the ring as is.
+func (c *Consistent) Watch(path string, interval time.Duration, onError func(error)) (*Watcher, error) {
+	w := &Watcher{
+		c:       c,
+		path:    path,
+		onError: onError,
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	if err := w.Reload(); err != nil {
+		return nil, err
+	}
+	go w.run(interval)
+	return w, nil
+}
+
+func (w *Watcher) run(interval time.Duration) {
+	defer close(w.stopped)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			if err := w.poll(); err != nil && w.onError != nil {
+				w.onError(err)
+			}
+		}
+	}
+}
+
+// poll reloads file if its modification time or size changed
+func (w *Watcher) poll() error {
+	fi, err := os.Stat(w.path)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	same := fi.ModTime().Equal(w.mod) && fi.Size() == w.size
+	w.mu.Unlock()
+	if same {
+		return nil
+	}
+	return w.Reload()
+}
+
+// Reload applies file now, regardless of its modification time
+func (w *Watcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fi, err := os.Stat(w.path)
+	if err != nil {
+		return err
+	}
+	// invalid file is taken as well, so it's reported once per edit
+	w.mod, w.size = fi.ModTime(), fi.Size()
+	cfg, err := readConfigFile(w.path)
+	if err != nil {
+		return err
+	}
+	return w.c.UpdateConfig(cfg)
+}
+
+// Close stops polling, reloads in progress finish first
+func (w *Watcher) Close() {
+	w.once.Do(func() { close(w.done) })
+	<-w.stopped
+}
+
+// UpdateConfig changes consistent to cfg at once like SetConfig, but
+// only removes, adds and resizes nodes which differ, so other nodes keep
+// their keys and listeners only hear of the differences. Change of
+// replicas, hash or seed falls back to SetConfig.
+func (c *Consistent) UpdateConfig(cfg Config) error {
+	c.lock()
+	defer c.unlock()
+	c.init()
+	return c.updateConfig(cfg)
+}
+
+// updateConfig is UpdateConfig, caller must hold write lock
+func (c *Consistent) updateConfig(cfg Config) error {
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	if cfg.Replicas != c.replicas || cfg.Hash != "" && cfg.Hash != c.hashName || cfg.Seed != c.seed {
+		return c.setConfig(cfg, nil)
+	}
+	if b, ok := c.ring.(bulkRing); ok && !c.staged {
+		b.Begin()
+		defer b.Commit()
+	}
+	nodes := append([]NodeConfig(nil), cfg.Nodes...)
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Node < nodes[j].Node })
+	keep := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		keep[n.Node] = true
+	}
+	var gone []string
+	for node := range c.node {
+		if !keep[node] {
+			gone = append(gone, node)
+		}
+	}
+	sort.Strings(gone)
+	for _, node := range gone {
+		c.removeNode(node)
+	}
+	for _, n := range nodes {
+		token := n.Token
+		if token == n.Node {
+			token = ""
+		}
+		if _, ok := c.node[n.Node]; ok && c.token[n.Node] != token {
+			// placed by other name, points differ
+			c.removeNode(n.Node)
+		}
+		if token != "" {
+			c.token[n.Node] = token
+		}
+		info, capacity, explicit := c.info[n.Node], c.capacity[n.Node], c.explicit[n.Node]
+		if n.Address != "" || n.Meta != nil {
+			c.info[n.Node] = Node{ID: n.Node, Address: n.Address, Meta: n.Meta}.copy()
+		} else {
+			delete(c.info, n.Node)
+		}
+		if n.Capacity > 0 {
+			c.capacity[n.Node] = n.Capacity
+		} else {
+			delete(c.capacity, n.Node)
+		}
+		// flag first, so change log records it with the node
+		c.setExplicit(n.Node, n.Explicit)
+		vnodes, ok := c.node[n.Node]
+		if !ok {
+			c.addNode(n.Node, n.VNodes)
+		} else if vnodes != n.VNodes {
+			c.resizeNode(n.Node, n.VNodes)
+		}
+		if !reflect.DeepEqual(c.info[n.Node], info) || c.capacity[n.Node] != capacity ||
+			ok && vnodes == n.VNodes && c.explicit[n.Node] != explicit {
+			c.changedInfo(n.Node)
+		}
+	}
+	if cfg.Epoch != 0 {
+		c.epoch = cfg.Epoch
+	}
+	return nil
+}
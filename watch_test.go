@@ -0,0 +1,158 @@
+package consistent
+
+import "errors"
+import "fmt"
+import "os"
+import "path/filepath"
+import "reflect"
+import "sync"
+import "testing"
+import "time"
+
+func TestUpdateConfig(t *testing.T) {
+	c := NewConsistentWithOptions(WithReplicas(10))
+	c.AddNodes([]string{"a", "b", "c"})
+	owner := make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprint(i)
+		owner[key], _ = c.GetNode(key)
+	}
+	l := &recordingListener{}
+	c.OnChange(l)
+
+	cfg := Config{Replicas: 10, Hash: "crc64", Nodes: []NodeConfig{
+		{Node: "d", VNodes: 10},
+		{Node: "a", VNodes: 10, Meta: map[string]string{ZoneLabel: "z1"}},
+		{Node: "b", VNodes: 20},
+	}}
+	if err := c.UpdateConfig(cfg); err != nil {
+		t.Fatalf("UpdateConfig %v\n", err)
+	}
+	exp := []string{"-c", "~b", "+d"}
+	if !reflect.DeepEqual(l.events, exp) || len(l.moved) != 1 {
+		t.Errorf("events exp: %v in one change, got %v %v\n", exp, l.events, l.moved)
+	}
+	want, _ := NewConsistentFromConfig(cfg)
+	if c.Fingerprint() != want.Fingerprint() {
+		t.Errorf("updated config exp: %+v, got %+v\n", want.Config(), c.Config())
+	}
+	if n, _ := c.NodeInfo("a"); n.Meta[ZoneLabel] != "z1" {
+		t.Errorf("info of a exp: zone z1, got %v\n", n)
+	}
+	for key, old := range owner {
+		node, _ := c.GetNode(key)
+		if node != old && old != "c" && node != "b" && node != "d" {
+			t.Errorf("key %v exp: kept by %v, got %v\n", key, old, node)
+		}
+	}
+
+	l.events = nil
+	if err := c.UpdateConfig(cfg); err != nil || len(l.events) != 0 {
+		t.Errorf("same config exp: no events, got %v %v\n", l.events, err)
+	}
+	cfg.Nodes = append(cfg.Nodes, NodeConfig{Node: "a", VNodes: 1})
+	if err := c.UpdateConfig(cfg); !errors.Is(err, ErrInvalidConfig) || len(l.events) != 0 {
+		t.Errorf("invalid config exp: %v, got %v %v\n", ErrInvalidConfig, err, l.events)
+	}
+
+	// other replicas replace whole ring
+	cfg.Replicas, cfg.Nodes = 20, cfg.Nodes[:1]
+	c.UpdateConfig(cfg)
+	if exp := []string{"-a", "-b", "-d", "+d"}; !reflect.DeepEqual(l.events, exp) {
+		t.Errorf("replicas change events exp: %v, got %v\n", exp, l.events)
+	}
+}
+
+type syncListener struct {
+	mu sync.Mutex
+	recordingListener
+}
+
+func (l *syncListener) NodeAdded(node string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.recordingListener.NodeAdded(node)
+}
+
+func (l *syncListener) NodeRemoved(node string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.recordingListener.NodeRemoved(node)
+}
+
+func (l *syncListener) WeightChanged(node string, vnodes int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.recordingListener.WeightChanged(node, vnodes)
+}
+
+func (l *syncListener) OwnershipChanged(moved float64) {}
+
+func (l *syncListener) got() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.events...)
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ring.yaml")
+	modified := time.Now()
+	write := func(data string) {
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		// coarse file times may not tell edits apart
+		modified = modified.Add(time.Second)
+		os.Chtimes(path, modified, modified)
+	}
+	write("replicas: 10\nnodes:\n  - name: a\n  - name: b\n")
+
+	if _, err := NewConsistent().Watch(filepath.Join(t.TempDir(), "missing.yaml"), time.Millisecond, nil); err == nil {
+		t.Errorf("Watch missing file exp: error, got nil\n")
+	}
+	errs := make(chan error, 10)
+	c := NewConsistentWithOptions(WithReplicas(10))
+	w, err := c.Watch(path, time.Millisecond, func(err error) { errs <- err })
+	if err != nil {
+		t.Fatalf("Watch %v\n", err)
+	}
+	defer w.Close()
+	if members := c.Members(); !reflect.DeepEqual(members, []string{"a", "b"}) {
+		t.Errorf("initial members exp: [a b], got %v\n", members)
+	}
+	l := &syncListener{}
+	c.OnChange(l)
+
+	wait := func(exp []string) {
+		deadline := time.Now().Add(5 * time.Second)
+		for !reflect.DeepEqual(l.got(), exp) && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if got := l.got(); !reflect.DeepEqual(got, exp) {
+			t.Fatalf("watched events exp: %v, got %v\n", exp, got)
+		}
+	}
+	write("replicas: 10\nnodes:\n  - name: a\n    weight: 2\n  - name: c\n")
+	wait([]string{"-b", "~a", "+c"})
+
+	write("replicas: 10\nnodes:\n  - name: a\n  - name: c\n  - name: c\n")
+	select {
+	case err := <-errs:
+		if !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("invalid file error exp: %v, got %v\n", ErrInvalidConfig, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("invalid file exp: error, got none\n")
+	}
+	if members := c.Members(); !reflect.DeepEqual(members, []string{"a", "c"}) {
+		t.Errorf("members after invalid file exp: [a c], got %v\n", members)
+	}
+
+	write("replicas: 10\nnodes:\n  - name: c\n")
+	wait([]string{"-b", "~a", "+c", "-a"})
+	w.Close()
+	w.Close()
+	if len(errs) != 0 {
+		t.Errorf("errors exp: reported once, got %v more\n", len(errs))
+	}
+}
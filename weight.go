@@ -0,0 +1,184 @@
+package consistent
+
+import (
+	"math"
+	"sort"
+)
+
+// UpdateWeight changes replica number of node to replicas*weight. Only the
+// difference of virtual nodes is added or removed, so keys move only from
+// or to this node. Node added by AddNodeWithCapacity drops its capacity,
+// keeping the weight as other capacity nodes renormalize. Weight less
+// than 1 is treated as 1.
+func (c *Consistent) UpdateWeight(node string, weight int) error {
+	if weight < 1 {
+		weight = 1
+	}
+	c.lock()
+	defer c.unlock()
+	if _, ok := c.node[node]; !ok {
+		return ErrNodeNotFound
+	}
+	c.resizeExplicit(node, c.replicas*weight, false)
+	return nil
+}
+
+// SetVirtualNodes changes virtual node number of node like UpdateWeight,
+// at finer grain than multiples of replicas. Less than 1 is treated as 1.
+func (c *Consistent) SetVirtualNodes(node string, vnodes int) error {
+	if vnodes < 1 {
+		vnodes = 1
+	}
+	c.lock()
+	defer c.unlock()
+	if _, ok := c.node[node]; !ok {
+		return ErrNodeNotFound
+	}
+	c.resizeExplicit(node, vnodes, true)
+	return nil
+}
+
+// resizeExplicit is resizeNode of size given by hand, setting explicit
+// flag of node first, so change log records it, and dropping its
+// capacity. Dropped capacity and change of flag alone are recorded like
+// change of info.
+func (c *Consistent) resizeExplicit(node string, vnodes int, explicit bool) {
+	_, capacity := c.capacity[node]
+	delete(c.capacity, node)
+	changed := c.explicit[node] != explicit
+	c.setExplicit(node, explicit)
+	resized := c.node[node] != vnodes
+	c.resizeNode(node, vnodes)
+	if capacity || changed && !resized {
+		c.changedInfo(node)
+	}
+	if capacity {
+		c.normalizeCapacity()
+	}
+}
+
+// resizeNode changes virtual node number of existing node to vnodes
+func (c *Consistent) resizeNode(node string, vnodes int) {
+	old, ok := c.node[node]
+	if !ok || old == vnodes {
+		return
+	}
+	if vnodes > old {
+		c.insertPoints(node, c.nodeKeys(node, vnodes)[old:])
+	} else {
+		c.deletePoints(node, c.nodeKeys(node, old)[vnodes:])
+	}
+	c.node[node] = vnodes
+	c.record(weightChanged, node, old, vnodes)
+}
+
+// AddNodeWithCapacity adds node whose virtual node number derives from its
+// capacity (CPU, RAM units, ...) relative to other nodes added with capacity.
+// Node of mean capacity gets default replicas, and all capacity nodes are
+// renormalized as they join and leave.
+func (c *Consistent) AddNodeWithCapacity(node string, capacity float64) error {
+	if capacity <= 0 {
+		return ErrInvalidCapacity
+	}
+	c.lock()
+	defer c.unlock()
+	if _, ok := c.node[node]; ok {
+		return ErrNodeExists
+	}
+	c.capacity[node] = capacity
+	c.addNode(node, c.capacityShares()[node])
+	c.changedInfo(node)
+	c.normalizeCapacity()
+	return nil
+}
+
+// SetCapacity changes capacity of node added by AddNodeWithCapacity and
+// renormalizes all capacity nodes
+func (c *Consistent) SetCapacity(node string, capacity float64) error {
+	if capacity <= 0 {
+		return ErrInvalidCapacity
+	}
+	c.lock()
+	defer c.unlock()
+	if _, ok := c.capacity[node]; !ok {
+		return ErrNodeNotFound
+	}
+	c.capacity[node] = capacity
+	c.changedInfo(node)
+	c.normalizeCapacity()
+	return nil
+}
+
+// Capacity returns capacity of node, 0 if node isn't added with capacity
+func (c *Consistent) Capacity(node string) float64 {
+	c.rlock()
+	defer c.runlock()
+	return c.capacity[node]
+}
+
+// capacityShares apportions virtual nodes among capacity nodes by their
+// capacity, node of mean capacity getting replicas. Total is replicas per
+// node, or budget if smaller, and is met exactly by largest remainder
+// method as long as each node can keep one point.
+func (c *Consistent) capacityShares() map[string]int {
+	type share struct {
+		node  string
+		exact float64
+	}
+	var total float64
+	for _, v := range c.capacity {
+		total += v
+	}
+	n := len(c.capacity)
+	points := c.replicas * n
+	if c.budget > 0 && points > c.budget {
+		points = c.budget
+	}
+	shares := make([]share, 0, n)
+	vnodes := make(map[string]int, n)
+	left := points
+	for node, capacity := range c.capacity {
+		exact := float64(points) * capacity / total
+		v := int(exact)
+		if v < 1 {
+			v = 1
+		}
+		vnodes[node] = v
+		left -= v
+		shares = append(shares, share{node, exact})
+	}
+	// largest remainders get points left, smallest give up ones taken
+	// by nodes below one point
+	sort.Slice(shares, func(i, j int) bool {
+		ri, rj := shares[i].exact-math.Floor(shares[i].exact), shares[j].exact-math.Floor(shares[j].exact)
+		if ri != rj {
+			return ri > rj
+		}
+		return shares[i].node < shares[j].node
+	})
+	for i := 0; left > 0 && i < len(shares); i++ {
+		vnodes[shares[i].node]++
+		left--
+	}
+	for left < 0 {
+		taken := false
+		for i := len(shares) - 1; left < 0 && i >= 0; i-- {
+			if node := shares[i].node; vnodes[node] > 1 {
+				vnodes[node]--
+				left++
+				taken = true
+			}
+		}
+		if !taken {
+			// fewer points than nodes
+			break
+		}
+	}
+	return vnodes
+}
+
+func (c *Consistent) normalizeCapacity() {
+	for node, vnodes := range c.capacityShares() {
+		c.resizeNode(node, vnodes)
+	}
+}
@@ -0,0 +1,280 @@
+package consistent
+
+import "bytes"
+import "fmt"
+import "reflect"
+import "testing"
+
+func TestUpdateWeight(t *testing.T) {
+	c := NewConsistent()
+	c.AddNodes([]string{"node1", "node2", "node3"})
+	before := map[string]string{}
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key%v", i)
+		before[key], _ = c.GetNode(key)
+	}
+
+	c.UpdateWeight("node1", 3)
+	if c.ring.Len() != 5*DefaultReplica {
+		t.Errorf("Wrong virtual nodes, exp: %v, got %v\n", 5*DefaultReplica, c.ring.Len())
+	}
+	for key, old := range before {
+		// keys may only move to the heavier node
+		if node, _ := c.GetNode(key); node != old && node != "node1" {
+			t.Errorf("Key %v moved from %v to %v\n", key, old, node)
+		}
+	}
+
+	c.UpdateWeight("node1", 1)
+	for key, old := range before {
+		if node, _ := c.GetNode(key); node != old {
+			t.Errorf("Key %v not restored, exp: %v, got %v\n", key, old, node)
+		}
+	}
+
+	c.UpdateWeight("node4", 2)
+	if c.HasNode("node4") || c.ring.Len() != 3*DefaultReplica {
+		t.Errorf("UpdateWeight shouldn't add unknown node\n")
+	}
+}
+
+func TestAddNodeWithReplicas(t *testing.T) {
+	c := NewConsistent()
+	c.AddNodes([]string{"node1", "node2"})
+	c.AddNodeWithReplicas("canary", 2)
+	c.AddNodeWithReplicas("node3", -1)
+
+	if c.ring.Len() != 2*DefaultReplica+3 || c.NodeNumber() != 4 {
+		t.Errorf("Wrong virtual nodes, exp: %v, got %v\n", 2*DefaultReplica+3, c.ring.Len())
+	}
+
+	hits := 0
+	for i := 0; i < 1000; i++ {
+		if node, _ := c.GetNode(fmt.Sprintf("key%v", i)); node == "canary" {
+			hits++
+		}
+	}
+	if hits > 100 {
+		t.Errorf("Canary got too many keys: %v\n", hits)
+	}
+
+	c.RemoveNode("canary")
+	if c.ring.Len() != 2*DefaultReplica+1 {
+		t.Errorf("Wrong virtual nodes after remove, exp: %v, got %v\n", 2*DefaultReplica+1, c.ring.Len())
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	c := NewConsistent()
+	c.AddNodeWithCapacity("node1", 8)
+	c.AddNodeWithCapacity("node2", 8)
+	if c.node["node1"] != DefaultReplica || c.node["node2"] != DefaultReplica {
+		t.Errorf("Equal capacity should get default replicas, got %v\n", c.node)
+	}
+
+	c.AddNodeWithCapacity("node3", 32)
+	exp := map[string]int{"node1": 50, "node2": 50, "node3": 200}
+	for node, n := range exp {
+		if c.node[node] != n {
+			t.Errorf("Wrong virtual nodes of %v, exp: %v, got %v\n", node, n, c.node[node])
+		}
+	}
+	if c.ring.Len() != 300 {
+		t.Errorf("Wrong ring size, exp: 300, got %v\n", c.ring.Len())
+	}
+
+	c.SetCapacity("node3", 8)
+	c.RemoveNode("node1")
+	if c.node["node2"] != DefaultReplica || c.node["node3"] != DefaultReplica || c.Capacity("node1") != 0 {
+		t.Errorf("Capacity not renormalized, got %v\n", c.node)
+	}
+	if c.ring.Len() != 2*DefaultReplica {
+		t.Errorf("Wrong ring size, exp: %v, got %v\n", 2*DefaultReplica, c.ring.Len())
+	}
+}
+
+func TestUpdateWeightOfCapacity(t *testing.T) {
+	updates := []struct {
+		name   string
+		update func(c *Consistent)
+		exp    int
+	}{
+		{"UpdateWeight", func(c *Consistent) { c.UpdateWeight("node1", 3) }, 3 * DefaultReplica},
+		{"SetVirtualNodes", func(c *Consistent) { c.SetVirtualNodes("node1", 7) }, 7},
+		{"Tx.UpdateWeight", func(c *Consistent) { c.Apply(func(tx *Tx) { tx.UpdateWeight("node1", 3) }) }, 3 * DefaultReplica},
+	}
+	for _, u := range updates {
+		c := NewConsistent()
+		var log bytes.Buffer
+		c.AppendLog(&log)
+		c.AddNodeWithCapacity("node1", 8)
+		c.AddNodeWithCapacity("node2", 24)
+		u.update(c)
+		c.AddNodeWithCapacity("node3", 8)
+		c.AddNode("node4")
+		exp := map[string]int{"node1": u.exp, "node2": 3 * DefaultReplica / 2, "node3": DefaultReplica / 2, "node4": DefaultReplica}
+		if !reflect.DeepEqual(c.node, exp) || c.Capacity("node1") != 0 {
+			t.Errorf("%s of capacity node exp: %v, got %v capacity %v\n", u.name, exp, c.node, c.Capacity("node1"))
+		}
+		replayed := NewConsistent()
+		if err := replayed.Replay(&log); err != nil || !reflect.DeepEqual(replayed.node, exp) || replayed.Capacity("node1") != 0 {
+			t.Errorf("%s replayed exp: %v, got %v %v\n", u.name, exp, replayed.node, err)
+		}
+	}
+}
+
+func TestVirtualNodeBudget(t *testing.T) {
+	c := NewConsistentWithOptions(WithVirtualNodeBudget(1000))
+	for i := 0; i < 8; i++ {
+		c.AddNodeWithCapacity(fmt.Sprintf("small%v", i), 0.5)
+	}
+	if c.ring.Len() != 8*DefaultReplica {
+		t.Errorf("Under budget should get default replicas, exp: %v, got %v\n", 8*DefaultReplica, c.ring.Len())
+	}
+
+	for i := 0; i < 40; i++ {
+		c.AddNodeWithCapacity(fmt.Sprintf("big%v", i), 1.5)
+	}
+	if c.ring.Len() != 1000 {
+		t.Errorf("Ring size exp: whole budget of 1000, got %v\n", c.ring.Len())
+	}
+	// rounding costs each node less than one point
+	if big, small := c.node["big0"], c.node["small0"]; big < 3*small-3 || big > 3*small+3 {
+		t.Errorf("Relative weight not kept, big: %v, small: %v\n", big, small)
+	}
+
+	tests := []struct {
+		budget     int
+		capacities []float64
+	}{
+		{100, []float64{1, 1, 1}},
+		{10, []float64{1, 1, 1, 1, 1, 1, 1}},
+		{100, []float64{0.3, 0.3, 0.4, 7.1, 2.9}},
+		{100, []float64{1000, 0.001, 0.001, 0.001, 0.001, 0.001}},
+		{5, []float64{1, 1, 1, 1, 1, 1, 1}}, // each keeps one point
+	}
+	for _, tt := range tests {
+		c := NewConsistentWithOptions(WithVirtualNodeBudget(tt.budget))
+		for i, capacity := range tt.capacities {
+			c.AddNodeWithCapacity(fmt.Sprintf("node%v", i), capacity)
+			exp := DefaultReplica * (i + 1)
+			if exp > tt.budget {
+				exp = tt.budget
+			}
+			if exp < i+1 {
+				exp = i + 1
+			}
+			sum := 0
+			for _, vnodes := range c.node {
+				sum += vnodes
+			}
+			if sum != exp || c.ring.Len() != exp {
+				t.Errorf("%v of budget %v virtual nodes exp: %v, got %v\n", tt.capacities[:i+1], tt.budget, exp, c.node)
+			}
+		}
+	}
+}
+
+func TestSetReplicas(t *testing.T) {
+	c := NewConsistent()
+	c.AddNode("node1")
+	c.AddWeightedNode("node2", 2)
+	c.AddNodeWithCapacity("node3", 1)
+
+	if err := c.SetReplicas(0); err == nil {
+		t.Errorf("SetReplicas should fail on 0\n")
+	}
+	if err := c.SetReplicas(10); err != nil {
+		t.Fatalf("SetReplicas err: %v\n", err)
+	}
+	exp := map[string]int{"node1": 10, "node2": 20, "node3": 10}
+	for node, n := range exp {
+		if c.node[node] != n {
+			t.Errorf("Wrong virtual nodes of %v, exp: %v, got %v\n", node, n, c.node[node])
+		}
+	}
+	if c.ring.Len() != 40 {
+		t.Errorf("Wrong ring size, exp: 40, got %v\n", c.ring.Len())
+	}
+
+	fresh := NewConsistentWithN(10)
+	fresh.AddNode("node1")
+	fresh.AddWeightedNode("node2", 2)
+	fresh.AddNodeWithCapacity("node3", 1)
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key%v", i)
+		exp, _ := fresh.GetNode(key)
+		if node, _ := c.GetNode(key); node != exp {
+			t.Errorf("Rebuilt ring differs from fresh one for %v, exp: %v, got %v\n", key, exp, node)
+		}
+	}
+}
+
+func TestSetReplicasKeepsExplicit(t *testing.T) {
+	c := NewConsistentWithN(10)
+	c.AddNode("weighted")
+	c.AddNodeWithReplicas("canary", 20)
+	c.AddNode("resized")
+	c.SetVirtualNodes("resized", 30)
+	c.AddNodeWithReplicas("reweighted", 5)
+	c.UpdateWeight("reweighted", 2)
+	c.AddNodeWithReplicas("old", 40)
+	c.ReplaceNode("old", "new")
+
+	c.SetReplicas(100)
+	exp := map[string]int{"weighted": 100, "canary": 20, "resized": 30, "reweighted": 200, "new": 40}
+	if !reflect.DeepEqual(c.node, exp) {
+		t.Errorf("SetReplicas virtual nodes exp: %v, got %v\n", exp, c.node)
+	}
+	clone := c.Clone()
+	clone.SetReplicas(10)
+	if exp := map[string]int{"weighted": 10, "canary": 20, "resized": 30, "reweighted": 20, "new": 40}; !reflect.DeepEqual(clone.node, exp) {
+		t.Errorf("SetReplicas of clone exp: %v, got %v\n", exp, clone.node)
+	}
+
+	c.SetConfig(Config{Replicas: 10, Nodes: []NodeConfig{{Node: "a", VNodes: 20}, {Node: "b", VNodes: 15, Explicit: true}}})
+	c.SetReplicas(20)
+	if exp := map[string]int{"a": 40, "b": 15}; !reflect.DeepEqual(c.node, exp) {
+		t.Errorf("SetReplicas after SetConfig exp: %v, got %v\n", exp, c.node)
+	}
+}
+
+func TestExplicitMultipleOfReplicas(t *testing.T) {
+	c := NewConsistentWithN(100)
+	var log bytes.Buffer
+	c.AppendLog(&log)
+	c.AddNode("weighted")
+	c.AddNodeWithReplicas("explicit", 200)
+	c.AddNodeWithReplicas("reweighted", 100)
+	c.UpdateWeight("reweighted", 1)
+	exp := map[string]int{"weighted": 10, "explicit": 200, "reweighted": 10}
+
+	bin, _ := c.MarshalBinary()
+	js, _ := c.MarshalJSON()
+	mp, _ := c.MarshalMsgpack()
+	pb := c.ToProto()
+	rings := []struct {
+		name string
+		load func(c *Consistent) error
+	}{
+		{"SetConfig", func(n *Consistent) error { return n.SetConfig(c.Config()) }},
+		{"UpdateConfig", func(n *Consistent) error { return n.UpdateConfig(c.Config()) }},
+		{"UnmarshalBinary", func(n *Consistent) error { return n.UnmarshalBinary(bin) }},
+		{"UnmarshalJSON", func(n *Consistent) error { return n.UnmarshalJSON(js) }},
+		{"UnmarshalMsgpack", func(n *Consistent) error { return n.UnmarshalMsgpack(mp) }},
+		{"FromProto", func(n *Consistent) error { return n.FromProto(pb) }},
+		{"Replay", func(n *Consistent) error { return n.Replay(bytes.NewReader(log.Bytes())) }},
+	}
+	for _, r := range rings {
+		// same replicas, so UpdateConfig only changes nodes
+		n := NewConsistentWithN(100)
+		n.AddNodeWithReplicas("weighted", 100)
+		if err := r.load(n); err != nil {
+			t.Fatalf("%s %v\n", r.name, err)
+		}
+		n.SetReplicas(10)
+		if !reflect.DeepEqual(n.node, exp) {
+			t.Errorf("SetReplicas after %s exp: %v, got %v\n", r.name, exp, n.node)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package consistent
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// wyp is default secret of wyhash final4
+var wyp = [4]uint64{0x2d358dccaa6c78a5, 0x8bb84b93962eacc9, 0x4b33a62ed433d4a3, 0x4d5a2da51de1aa47}
+
+// NewConsistentWithWyHash return consistent with given replica number
+// hashing with WyHash
+func NewConsistentWithWyHash(replicas int) *Consistent {
+	return NewConsistentWithOptions(WithReplicas(replicas), WithHashName("wyhash"))
+}
+
+// WyHash is wyhash final4 (wyhash.h v4.2) with seed 0 and default secret.
+// The variant is pinned: output won't change in later releases, a newer
+// wyhash would be added under a new name, so placements never drift.
+func WyHash(key []byte) uint64 {
+	return wyhash(key, 0)
+}
+
+// WyHashSeeded returns WyHash of seed, a keyed hash whose seed, unlike
+// seeds of hash/maphash, can be stored: output depends only on key and
+// seed, on every platform and release. HashFunc alone has no hash name,
+// pass WyHashName to WithHashName to keep seed with the ring.
+func WyHashSeeded(seed uint64) HashFunc {
+	return func(key []byte) uint64 {
+		return wyhash(key, seed)
+	}
+}
+
+// WyHashName returns hash name of WyHashSeeded(seed), "wyhash/" and seed
+// in 16 hex digits, or "wyhash" for seed 0. Config carries it, so rings
+// serialized, stored or shared with peers keep hashing with the seed.
+func WyHashName(seed uint64) string {
+	if seed == 0 {
+		return "wyhash"
+	}
+	return fmt.Sprintf("wyhash/%016x", seed)
+}
+
+// wyhashByName resolves names of WyHashName with seed, only in the form
+// WyHashName gives, so each seed has one name
+func wyhashByName(name string) (HashFunc, bool) {
+	const prefix = "wyhash/"
+	if !strings.HasPrefix(name, prefix) {
+		return nil, false
+	}
+	seed, err := strconv.ParseUint(name[len(prefix):], 16, 64)
+	if err != nil || WyHashName(seed) != name {
+		return nil, false
+	}
+	return WyHashSeeded(seed), true
+}
+
+func wyhash(p []byte, seed uint64) uint64 {
+	n := len(p)
+	seed ^= wymix(seed^wyp[0], wyp[1])
+	var a, b uint64
+	switch {
+	case n >= 4 && n <= 16:
+		a = wyr4(p)<<32 | wyr4(p[(n>>3)<<2:])
+		b = wyr4(p[n-4:])<<32 | wyr4(p[n-4-((n>>3)<<2):])
+	case n > 0 && n < 4:
+		a = uint64(p[0])<<16 | uint64(p[n>>1])<<8 | uint64(p[n-1])
+	case n > 16:
+		// reads trail back before last block, so track offset in p
+		i, off := n, 0
+		if i > 48 {
+			see1, see2 := seed, seed
+			for ; i > 48; i, off = i-48, off+48 {
+				seed = wymix(wyr8(p[off:])^wyp[1], wyr8(p[off+8:])^seed)
+				see1 = wymix(wyr8(p[off+16:])^wyp[2], wyr8(p[off+24:])^see1)
+				see2 = wymix(wyr8(p[off+32:])^wyp[3], wyr8(p[off+40:])^see2)
+			}
+			seed ^= see1 ^ see2
+		}
+		for ; i > 16; i, off = i-16, off+16 {
+			seed = wymix(wyr8(p[off:])^wyp[1], wyr8(p[off+8:])^seed)
+		}
+		a = wyr8(p[off+i-16:])
+		b = wyr8(p[off+i-8:])
+	}
+	b, a = bits.Mul64(a^wyp[1], b^seed)
+	return wymix(a^wyp[0]^uint64(n), b^wyp[1])
+}
+
+// wymix multiplies to 128 bits and folds halves
+func wymix(a, b uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	return hi ^ lo
+}
+
+func wyr8(p []byte) uint64 { return binary.LittleEndian.Uint64(p) }
+func wyr4(p []byte) uint64 { return uint64(binary.LittleEndian.Uint32(p)) }
@@ -0,0 +1,83 @@
+package consistent
+
+import "fmt"
+import "testing"
+
+// test_vector.cpp of wyhash final4, seed is index of message
+func TestWyHash(t *testing.T) {
+	cases := []struct {
+		in  string
+		exp uint64
+	}{
+		{"", 0x93228a4de0eec5a2},
+		{"a", 0xc5bac3db178713c4},
+		{"abc", 0xa97f2f7b1d9b3314},
+		{"message digest", 0x786d1f1df3801df4},
+		{"abcdefghijklmnopqrstuvwxyz", 0xdca5a8138ad37c87},
+		{"ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789", 0xb9e734f117cfaf70},
+		{"12345678901234567890123456789012345678901234567890123456789012345678901234567890", 0x6cc5eab49a92d617},
+	}
+	for i, cs := range cases {
+		if got := wyhash([]byte(cs.in), uint64(i)); got != cs.exp {
+			t.Errorf("wyhash(%q, %v) exp: %x, got %x\n", cs.in, i, cs.exp, got)
+		}
+	}
+	if WyHash([]byte("")) != 0x93228a4de0eec5a2 {
+		t.Errorf("WyHash should use seed 0\n")
+	}
+}
+
+func TestWyHashSeeded(t *testing.T) {
+	if got, exp := WyHashSeeded(3)([]byte("message digest")), uint64(0x786d1f1df3801df4); got != exp {
+		t.Errorf("WyHashSeeded(3) exp: %x, got %x\n", exp, got)
+	}
+	names := []struct {
+		seed uint64
+		name string
+	}{
+		{0, "wyhash"},
+		{42, "wyhash/000000000000002a"},
+		{1<<64 - 1, "wyhash/ffffffffffffffff"},
+	}
+	for _, n := range names {
+		if got := WyHashName(n.seed); got != n.name {
+			t.Errorf("WyHashName(%v) exp: %v, got %v\n", n.seed, n.name, got)
+		}
+	}
+	for _, name := range []string{"wyhash/2a", "wyhash/000000000000002A", "wyhash/0000000000000000", "wyhash/+00000000000002a"} {
+		if _, err := lookupHash(name); err == nil {
+			t.Errorf("hash %q exp: unknown, got found\n", name)
+		}
+	}
+
+	a := NewConsistentWithOptions(WithHashName(WyHashName(42)))
+	other := NewConsistentWithOptions(WithHashName(WyHashName(43)))
+	for _, c := range []*Consistent{a, other} {
+		c.AddNodes([]string{"node1", "node2", "node3"})
+	}
+	data, err := a.MarshalJSON()
+	if err != nil {
+		t.Fatalf("MarshalJSON %v\n", err)
+	}
+	b := NewConsistent()
+	if err := b.UnmarshalJSON(data); err != nil {
+		t.Fatalf("UnmarshalJSON %v\n", err)
+	}
+	if name := b.Config().Hash; name != "wyhash/000000000000002a" {
+		t.Errorf("decoded hash name exp: wyhash/000000000000002a, got %v\n", name)
+	}
+	differ := 0
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprint(i)
+		na, _ := a.GetNode(key)
+		if nb, _ := b.GetNode(key); na != nb {
+			t.Errorf("decoded ring should place %v alike, got %v and %v\n", key, na, nb)
+		}
+		if no, _ := other.GetNode(key); na != no {
+			differ++
+		}
+	}
+	if differ == 0 {
+		t.Errorf("other seed should place differently\n")
+	}
+}
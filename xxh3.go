@@ -0,0 +1,17 @@
+//go:build xxh3
+
+package consistent
+
+import "github.com/zeebo/xxh3"
+
+// XXH3 hashes with xxh3 of github.com/zeebo/xxh3, vectorized with AVX2 or
+// SSE2 where available. Build with -tags xxh3 to use it. It's
+// XXH3_64bits with seed 0 and default secret, whose output is frozen since
+// xxHash v0.8.0, so placements don't drift with upgrades of either.
+func XXH3(key []byte) uint64 {
+	return xxh3.Hash(key)
+}
+
+func init() {
+	hashFuncs["xxh3"] = XXH3
+}
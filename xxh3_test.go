@@ -0,0 +1,33 @@
+//go:build xxh3
+
+package consistent
+
+import "fmt"
+import "testing"
+
+func TestXXH3(t *testing.T) {
+	c := NewConsistentWithHash(DefaultReplica, XXH3)
+	c.AddNodes([]string{"node1", "node2", "node3"})
+	for i := 0; i < 100; i++ {
+		if _, err := c.GetNode(fmt.Sprint(i)); err != nil {
+			t.Errorf("GetNode err: %v\n", err)
+		}
+	}
+	// XXH3_64bits of empty input, frozen since xxHash v0.8.0
+	if got := XXH3(nil); got != 0x2d06800538d394c2 {
+		t.Errorf("XXH3 exp: 2d06800538d394c2, got %x\n", got)
+	}
+}
+
+func benchmarkHash(b *testing.B, fn HashFunc, size int) {
+	key := make([]byte, size)
+	b.SetBytes(int64(size))
+	for i := 0; i < b.N; i++ {
+		fn(key)
+	}
+}
+
+func BenchmarkHashCRC64Short(b *testing.B) { benchmarkHash(b, crc64h, 16) }
+func BenchmarkHashXXH3Short(b *testing.B)  { benchmarkHash(b, XXH3, 16) }
+func BenchmarkHashCRC64Long(b *testing.B)  { benchmarkHash(b, crc64h, 1024) }
+func BenchmarkHashXXH3Long(b *testing.B)   { benchmarkHash(b, XXH3, 1024) }
@@ -0,0 +1,79 @@
+package consistent
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+const (
+	xxPrime1 uint64 = 11400714785074694791
+	xxPrime2 uint64 = 14029467366897019727
+	xxPrime3 uint64 = 1609587929392839161
+	xxPrime4 uint64 = 9650029242287828579
+	xxPrime5 uint64 = 2870177450012600261
+)
+
+// NewConsistentWithXXHash return consistent with given replica number
+// hashing with XXHash64
+func NewConsistentWithXXHash(replicas int) *Consistent {
+	return NewConsistentWithOptions(WithReplicas(replicas), WithHashName("xxhash64"))
+}
+
+// XXHash64 is xxHash64 with seed 0, faster than crc64 and well
+// distributed. It matches reference implementation and cespare/xxhash.
+func XXHash64(key []byte) uint64 {
+	n := len(key)
+	var h uint64
+	if n >= 32 {
+		p1 := xxPrime1 // variable, constant sums would overflow
+		v1 := p1 + xxPrime2
+		v2 := xxPrime2
+		v3 := uint64(0)
+		v4 := -p1
+		for ; len(key) >= 32; key = key[32:] {
+			v1 = xxRound(v1, binary.LittleEndian.Uint64(key[0:8]))
+			v2 = xxRound(v2, binary.LittleEndian.Uint64(key[8:16]))
+			v3 = xxRound(v3, binary.LittleEndian.Uint64(key[16:24]))
+			v4 = xxRound(v4, binary.LittleEndian.Uint64(key[24:32]))
+		}
+		h = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) +
+			bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		h = xxMergeRound(h, v1)
+		h = xxMergeRound(h, v2)
+		h = xxMergeRound(h, v3)
+		h = xxMergeRound(h, v4)
+	} else {
+		h = xxPrime5
+	}
+	h += uint64(n)
+	for ; len(key) >= 8; key = key[8:] {
+		h ^= xxRound(0, binary.LittleEndian.Uint64(key))
+		h = bits.RotateLeft64(h, 27)*xxPrime1 + xxPrime4
+	}
+	if len(key) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(key)) * xxPrime1
+		h = bits.RotateLeft64(h, 23)*xxPrime2 + xxPrime3
+		key = key[4:]
+	}
+	for _, b := range key {
+		h ^= uint64(b) * xxPrime5
+		h = bits.RotateLeft64(h, 11) * xxPrime1
+	}
+	h ^= h >> 33
+	h *= xxPrime2
+	h ^= h >> 29
+	h *= xxPrime3
+	h ^= h >> 32
+	return h
+}
+
+func xxRound(acc, input uint64) uint64 {
+	acc += input * xxPrime2
+	acc = bits.RotateLeft64(acc, 31)
+	return acc * xxPrime1
+}
+
+func xxMergeRound(acc, val uint64) uint64 {
+	acc ^= xxRound(0, val)
+	return acc*xxPrime1 + xxPrime4
+}
@@ -0,0 +1,43 @@
+package consistent
+
+import "fmt"
+import "testing"
+
+func TestXXHash64(t *testing.T) {
+	cases := []struct {
+		in  string
+		exp uint64
+	}{
+		{"", 0xef46db3751d8e999},
+		{"a", 0xd24ec4f1a98c6e5b},
+		{"abc", 0x44bc2cf5ad770999},
+		{"Nobody inspects the spammish repetition", 0xfbcea83c8a378bf1},
+	}
+	for _, cs := range cases {
+		if got := XXHash64([]byte(cs.in)); got != cs.exp {
+			t.Errorf("XXHash64(%q) exp: %x, got %x\n", cs.in, cs.exp, got)
+		}
+	}
+}
+
+func TestNewConsistentWithXXHash(t *testing.T) {
+	c := NewConsistentWithXXHash(DefaultReplica)
+	c.AddNodes([]string{"node1", "node2", "node3"})
+	counts := make(map[string]int)
+	for i := 0; i < 3000; i++ {
+		node, _ := c.GetNode(fmt.Sprint(i))
+		counts[node]++
+	}
+	for node, n := range counts {
+		if n < 600 || n > 1400 {
+			t.Errorf("XXHash64 unbalanced, %v got %v of 3000 keys\n", node, n)
+		}
+	}
+}
+
+func BenchmarkXXHash64(b *testing.B) {
+	key := []byte("node-with-a-longer-name.example.com:8080")
+	for i := 0; i < b.N; i++ {
+		XXHash64(key)
+	}
+}
@@ -0,0 +1,69 @@
+package consistent
+
+import "sort"
+
+// ZoneLabel is Node.Meta key of node's zone
+const ZoneLabel = "zone"
+
+// zone returns zone of node, nodes without one share the empty zone
+func (c *Consistent) zone(node string) string {
+	return c.info[node].Meta[ZoneLabel]
+}
+
+// zoneNumber returns distinct zone number of current nodes
+func (c *Consistent) zoneNumber() int {
+	zones := map[string]bool{}
+	for node := range c.node {
+		zones[c.zone(node)] = true
+	}
+	return len(zones)
+}
+
+// GetNNodePerZone walks ring like GetNNode but takes only first node of
+// each zone, returning owners from given number of distinct zones. Zones
+// are set by ZoneLabel of node metadata, see AddNodeInfo.
+func (c *Consistent) GetNNodePerZone(key string, zones int) ([]string, error) {
+	c.rlock()
+	defer c.runlock()
+	if zones > c.zoneNumber() {
+		return []string{}, ErrNotEnoughNodes
+	}
+	seen := map[string]bool{}
+	return enoughNodes(c.collectPinned([]byte(key), zones, func(node string) bool {
+		z := c.zone(node)
+		if seen[z] {
+			return false
+		}
+		seen[z] = true
+		return true
+	}), zones)
+}
+
+// GetNNodeByLocality returns same nodes as GetNNode, stably reordered by
+// distance, lower first. Every client agrees on which nodes own the key
+// while each reads from its nearest one.
+func (c *Consistent) GetNNodeByLocality(key string, n int, distance func(Node) int) ([]string, error) {
+	c.rlock()
+	defer c.runlock()
+	nodes, err := c.lookupN([]byte(key), n)
+	if err != nil {
+		return nodes, err
+	}
+	dist := make(map[string]int, len(nodes))
+	for _, node := range nodes {
+		info, _ := c.nodeInfo(node)
+		dist[node] = distance(info)
+	}
+	sort.SliceStable(nodes, func(i, j int) bool { return dist[nodes[i]] < dist[nodes[j]] })
+	return nodes, nil
+}
+
+// PreferZone is distance for GetNNodeByLocality putting nodes of zone first
+func PreferZone(zone string) func(Node) int {
+	return func(n Node) int {
+		if n.Meta[ZoneLabel] == zone {
+			return 0
+		}
+		return 1
+	}
+}
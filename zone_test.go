@@ -0,0 +1,62 @@
+package consistent
+
+import "fmt"
+import "testing"
+
+func TestGetNNodePerZone(t *testing.T) {
+	c := NewConsistent()
+	for i, zone := range []string{"a", "a", "a", "b", "b", "c"} {
+		c.AddNodeInfo(Node{ID: fmt.Sprintf("node%v", i), Meta: map[string]string{ZoneLabel: zone}})
+	}
+
+	if _, err := c.GetNNodePerZone("xxx", 4); err == nil {
+		t.Errorf("GetNNodePerZone should fail when zones are not enough\n")
+	}
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key%v", i)
+		nodes, err := c.GetNNodePerZone(key, 3)
+		if err != nil || len(nodes) != 3 {
+			t.Fatalf("GetNNodePerZone err: %v, got: %v\n", err, nodes)
+		}
+		if first, _ := c.GetNode(key); nodes[0] != first {
+			t.Errorf("First node should be primary owner, exp: %v, got %v\n", first, nodes[0])
+		}
+		zones := map[string]bool{}
+		for _, n := range nodes {
+			zones[c.zone(n)] = true
+		}
+		if len(zones) != 3 {
+			t.Errorf("Nodes not zone distinct, got %v\n", nodes)
+		}
+	}
+}
+
+func TestGetNNodeByLocality(t *testing.T) {
+	c := NewConsistent()
+	for i, zone := range []string{"a", "a", "b", "b", "c"} {
+		c.AddNodeInfo(Node{ID: fmt.Sprintf("node%v", i), Meta: map[string]string{ZoneLabel: zone}})
+	}
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key%v", i)
+		exp, _ := c.GetNNode(key, 3)
+		nodes, err := c.GetNNodeByLocality(key, 3, PreferZone("b"))
+		if err != nil || len(nodes) != 3 {
+			t.Fatalf("GetNNodeByLocality err: %v, got: %v\n", err, nodes)
+		}
+		for _, n := range exp {
+			if !stringInSlice(nodes, n) {
+				t.Errorf("Owner set changed, exp: %v, got: %v\n", exp, nodes)
+			}
+		}
+		seenOther := false
+		for _, n := range nodes {
+			if c.zone(n) != "b" {
+				seenOther = true
+			} else if seenOther {
+				t.Errorf("Zone b node after other zone: %v\n", nodes)
+			}
+		}
+	}
+}